@@ -0,0 +1,135 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+)
+
+// Bridged value moves between two chains built from this VM. On the source
+// chain, [BridgeLock] moves value into escrow. Relayers observe the lock and
+// sign a [BridgeMessage], which is submitted on the destination chain with
+// [BridgeMint] to credit the recipient. [BridgeBurn] and [BridgeUnlock]
+// reverse the flow. Each chain tracks how much it has escrowed for, and
+// minted from, every other chain so neither side can release more than it
+// received.
+const (
+	BridgeMessageMint   uint8 = 0
+	BridgeMessageUnlock uint8 = 1
+
+	// Minting and unlocking verify one BLS signature per relayer.
+	BridgeVerifyComputeUnits = auth.BLSComputeUnits
+
+	bridgeMessageDomain = "morpheusvm bridge v1"
+	bridgeMessageSize   = len(bridgeMessageDomain) + 1 + 3*ids.IDLen + codec.AddressLen + consts.Uint64Len
+)
+
+var (
+	ErrInvalidBridgeChain      = errors.New("bridge chain must differ from this chain")
+	ErrBridgeNotConfigured     = errors.New("bridge is not configured")
+	ErrBridgeTransferProcessed = errors.New("bridge transfer already processed")
+	ErrInvalidBridgeSigners    = errors.New("invalid bridge signers")
+	ErrInsufficientSigners     = errors.New("insufficient bridge signers")
+	ErrInvalidBridgeSignature  = errors.New("invalid bridge signature")
+	ErrInsufficientBridgeFunds = errors.New("insufficient bridged funds")
+)
+
+// BridgeMessage is the statement relayers sign to attest that [TransferID]
+// happened on [SourceChainID] and should be released on
+// [DestinationChainID].
+type BridgeMessage struct {
+	Kind               uint8
+	SourceChainID      ids.ID
+	DestinationChainID ids.ID
+	TransferID         ids.ID
+	Recipient          codec.Address
+	Value              uint64
+}
+
+// Bytes returns the canonical encoding of [m] that relayers sign.
+func (m *BridgeMessage) Bytes() []byte {
+	p := codec.NewWriter(bridgeMessageSize, bridgeMessageSize)
+	p.PackFixedBytes([]byte(bridgeMessageDomain))
+	p.PackByte(m.Kind)
+	p.PackID(m.SourceChainID)
+	p.PackID(m.DestinationChainID)
+	p.PackID(m.TransferID)
+	p.PackAddress(m.Recipient)
+	p.PackUint64(m.Value)
+	return p.Bytes()
+}
+
+// SignBridgeMessage returns the [auth.BLS] signature of [key] over [m].
+func SignBridgeMessage(key *bls.PrivateKey, m *BridgeMessage) *auth.BLS {
+	return &auth.BLS{
+		Signer:    bls.PublicFromPrivateKey(key),
+		Signature: bls.Sign(m.Bytes(), key),
+	}
+}
+
+// MarshalBridgeSignatures encodes [sigs] for [BridgeMint.Signatures] and
+// [BridgeUnlock.Signatures].
+func MarshalBridgeSignatures(sigs []*auth.BLS) []byte {
+	p := codec.NewWriter(len(sigs)*auth.BLSSize, len(sigs)*auth.BLSSize)
+	for _, sig := range sigs {
+		sig.Marshal(p)
+	}
+	return p.Bytes()
+}
+
+// VerifyBridgeMessage checks that [signatures] holds an [auth.BLS]
+// signature over [m] from at least [config.Quorum] distinct relayers.
+//
+// Signatures are decoded with [auth.UnmarshalBLS], the parser registered for
+// BLS auth in the VM, and each one is verified on its own so a relayer key
+// can never be chosen to cancel out another.
+func VerifyBridgeMessage(ctx context.Context, config *storage.BridgeConfig, signatures []byte, m *BridgeMessage) error {
+	if len(signatures)%auth.BLSSize != 0 || len(signatures)/auth.BLSSize > len(config.Relayers) {
+		return ErrInvalidBridgeSigners
+	}
+	if len(signatures)/auth.BLSSize < int(config.Quorum) {
+		return ErrInsufficientSigners
+	}
+	var (
+		msg    = m.Bytes()
+		signed = make([]bool, len(config.Relayers))
+		p      = codec.NewReader(signatures, len(signatures))
+	)
+	for !p.Empty() {
+		a, err := auth.UnmarshalBLS(p)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidBridgeSignature, err)
+		}
+		signer := bls.PublicKeyToBytes(a.(*auth.BLS).Signer)
+		i := slices.IndexFunc(config.Relayers, func(relayer codec.Bytes) bool {
+			return bytes.Equal(relayer, signer)
+		})
+		if i < 0 || signed[i] {
+			return ErrInvalidBridgeSigners
+		}
+		signed[i] = true
+		if err := a.Verify(ctx, msg); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidBridgeSignature, err)
+		}
+	}
+	return nil
+}
+
+// bridgeVerifyComputeUnits returns the compute units of verifying
+// [signatures].
+func bridgeVerifyComputeUnits(signatures []byte) uint64 {
+	return uint64(len(signatures)/auth.BLSSize) * BridgeVerifyComputeUnits
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const BridgeBurnComputeUnits = 1
+
+var _ chain.Action = (*BridgeBurn)(nil)
+
+// BridgeBurn destroys [Value] previously minted from [DestinationChainID] so
+// that relayers can unlock it to [Recipient] on that chain. The transfer is
+// identified by the ID of this action.
+type BridgeBurn struct {
+	// DestinationChainID is the chain the value was originally locked on.
+	DestinationChainID ids.ID `serialize:"true" json:"destinationChainID"`
+
+	// Recipient is credited on the destination chain.
+	Recipient codec.Address `serialize:"true" json:"recipient"`
+
+	// Value is the amount burned.
+	Value uint64 `serialize:"true" json:"value"`
+}
+
+func (*BridgeBurn) GetTypeID() uint8 {
+	return mconsts.BridgeBurnID
+}
+
+func (b *BridgeBurn) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return state.Keys{
+		string(storage.BalanceKey(actor)):                     state.Read | state.Write,
+		string(storage.SpendingLimitKey(actor)):               state.Read | state.Write,
+		string(storage.BridgeMintedKey(b.DestinationChainID)): state.Read | state.Write,
+	}
+}
+
+func (b *BridgeBurn) Execute(
+	ctx context.Context,
+	_ chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (codec.Typed, error) {
+	if b.Value == 0 {
+		return nil, ErrOutputValueZero
+	}
+	minted, err := storage.GetBridgeMinted(ctx, mu, b.DestinationChainID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Value > minted {
+		return nil, fmt.Errorf("%w: (minted=%d, value=%d)", ErrInsufficientBridgeFunds, minted, b.Value)
+	}
+	senderBalance, err := storage.SubBalance(ctx, mu, timestamp, actor, b.Value)
+	if err != nil {
+		return nil, err
+	}
+	minted, err = storage.SubBridgeMinted(ctx, mu, b.DestinationChainID, b.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BridgeBurnResult{
+		TransferID:    actionID,
+		SenderBalance: senderBalance,
+		Minted:        minted,
+	}, nil
+}
+
+func (*BridgeBurn) ComputeUnits(chain.Rules) uint64 {
+	return BridgeBurnComputeUnits
+}
+
+func (*BridgeBurn) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// SupplyChange returns the amount minted and burned by [b] when it succeeds.
+// [BridgeBurn] destroys [Value].
+func (b *BridgeBurn) SupplyChange() (uint64, uint64) {
+	return 0, b.Value
+}
+
+var _ codec.Typed = (*BridgeBurnResult)(nil)
+
+type BridgeBurnResult struct {
+	TransferID    ids.ID `serialize:"true" json:"transfer_id"`
+	SenderBalance uint64 `serialize:"true" json:"sender_balance"`
+
+	// Minted is the total outstanding amount minted from the destination
+	// chain.
+	Minted uint64 `serialize:"true" json:"minted"`
+}
+
+func (*BridgeBurnResult) GetTypeID() uint8 {
+	return mconsts.BridgeBurnID // Common practice is to use the action ID
+}
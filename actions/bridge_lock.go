@@ -0,0 +1,106 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const BridgeLockComputeUnits = 1
+
+var _ chain.Action = (*BridgeLock)(nil)
+
+// BridgeLock moves [Value] from the actor into escrow so that relayers can
+// mint it to [Recipient] on [DestinationChainID]. The transfer is identified
+// by the ID of this action.
+type BridgeLock struct {
+	// DestinationChainID is the chain the value is bridged to.
+	DestinationChainID ids.ID `serialize:"true" json:"destinationChainID"`
+
+	// Recipient is credited on the destination chain.
+	Recipient codec.Address `serialize:"true" json:"recipient"`
+
+	// Value is the amount locked.
+	Value uint64 `serialize:"true" json:"value"`
+}
+
+func (*BridgeLock) GetTypeID() uint8 {
+	return mconsts.BridgeLockID
+}
+
+func (b *BridgeLock) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return state.Keys{
+		string(storage.BalanceKey(actor)):                     state.Read | state.Write,
+		string(storage.SpendingLimitKey(actor)):               state.Read | state.Write,
+		string(storage.BridgeEscrowKey(b.DestinationChainID)): state.All,
+	}
+}
+
+func (b *BridgeLock) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (codec.Typed, error) {
+	if b.Value == 0 {
+		return nil, ErrOutputValueZero
+	}
+	if b.DestinationChainID == rules.GetChainID() {
+		return nil, ErrInvalidBridgeChain
+	}
+	senderBalance, err := storage.SubBalance(ctx, mu, timestamp, actor, b.Value)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := storage.AddBridgeEscrow(ctx, mu, b.DestinationChainID, b.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BridgeLockResult{
+		TransferID:    actionID,
+		SenderBalance: senderBalance,
+		Locked:        locked,
+	}, nil
+}
+
+func (*BridgeLock) ComputeUnits(chain.Rules) uint64 {
+	return BridgeLockComputeUnits
+}
+
+func (*BridgeLock) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// SupplyChange returns the amount minted and burned by [b] when it succeeds.
+// [BridgeLock] moves [Value] out of the balances and into escrow.
+func (b *BridgeLock) SupplyChange() (uint64, uint64) {
+	return 0, b.Value
+}
+
+var _ codec.Typed = (*BridgeLockResult)(nil)
+
+type BridgeLockResult struct {
+	TransferID    ids.ID `serialize:"true" json:"transfer_id"`
+	SenderBalance uint64 `serialize:"true" json:"sender_balance"`
+
+	// Locked is the total escrowed for the destination chain.
+	Locked uint64 `serialize:"true" json:"locked"`
+}
+
+func (*BridgeLockResult) GetTypeID() uint8 {
+	return mconsts.BridgeLockID // Common practice is to use the action ID
+}
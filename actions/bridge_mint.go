@@ -0,0 +1,144 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const BridgeMintComputeUnits = 1
+
+var _ chain.Action = (*BridgeMint)(nil)
+
+// BridgeMint credits [Recipient] with value locked on [SourceChainID] by the
+// [BridgeLock] action [TransferID]. It must carry the signatures of a quorum
+// of relayers. Anyone can submit it.
+type BridgeMint struct {
+	SourceChainID ids.ID        `serialize:"true" json:"sourceChainID"`
+	TransferID    ids.ID        `serialize:"true" json:"transferID"`
+	Recipient     codec.Address `serialize:"true" json:"recipient"`
+	Value         uint64        `serialize:"true" json:"value"`
+
+	// Signatures are the signatures of distinct relayers over the
+	// [BridgeMessage], encoded with [MarshalBridgeSignatures].
+	Signatures []byte `serialize:"true" json:"signatures"`
+}
+
+func (*BridgeMint) GetTypeID() uint8 {
+	return mconsts.BridgeMintID
+}
+
+func (b *BridgeMint) StateKeys(codec.Address, ids.ID) state.Keys {
+	return state.Keys{
+		string(storage.BridgeConfigKey()):                                state.Read,
+		string(storage.BridgeTransferKey(b.SourceChainID, b.TransferID)): state.All,
+		string(storage.BridgeMintedKey(b.SourceChainID)):                 state.All,
+		string(storage.BalanceKey(b.Recipient)):                          state.All,
+	}
+}
+
+func (b *BridgeMint) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	_ codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if b.Value == 0 {
+		return nil, ErrOutputValueZero
+	}
+	if b.SourceChainID == rules.GetChainID() {
+		return nil, ErrInvalidBridgeChain
+	}
+	if err := verifyBridgeTransfer(ctx, mu, b.Signatures, &BridgeMessage{
+		Kind:               BridgeMessageMint,
+		SourceChainID:      b.SourceChainID,
+		DestinationChainID: rules.GetChainID(),
+		TransferID:         b.TransferID,
+		Recipient:          b.Recipient,
+		Value:              b.Value,
+	}); err != nil {
+		return nil, err
+	}
+
+	minted, err := storage.AddBridgeMinted(ctx, mu, b.SourceChainID, b.Value)
+	if err != nil {
+		return nil, err
+	}
+	receiverBalance, err := storage.AddBalance(ctx, mu, b.Recipient, b.Value)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.SetBridgeTransfer(ctx, mu, b.SourceChainID, b.TransferID, timestamp); err != nil {
+		return nil, err
+	}
+
+	return &BridgeMintResult{
+		ReceiverBalance: receiverBalance,
+		Minted:          minted,
+	}, nil
+}
+
+func (b *BridgeMint) ComputeUnits(chain.Rules) uint64 {
+	return BridgeMintComputeUnits + bridgeVerifyComputeUnits(b.Signatures)
+}
+
+func (*BridgeMint) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// SupplyChange returns the amount minted and burned by [b] when it succeeds.
+// [BridgeMint] creates [Value].
+func (b *BridgeMint) SupplyChange() (uint64, uint64) {
+	return b.Value, 0
+}
+
+// verifyBridgeTransfer checks that [m] has not been processed yet and is
+// signed by a quorum of the configured relayers.
+func verifyBridgeTransfer(
+	ctx context.Context,
+	im state.Immutable,
+	signatures []byte,
+	m *BridgeMessage,
+) error {
+	config, exists, err := storage.GetBridgeConfig(ctx, im)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrBridgeNotConfigured
+	}
+	_, processed, err := storage.GetBridgeTransfer(ctx, im, m.SourceChainID, m.TransferID)
+	if err != nil {
+		return err
+	}
+	if processed {
+		return ErrBridgeTransferProcessed
+	}
+	return VerifyBridgeMessage(ctx, config, signatures, m)
+}
+
+var _ codec.Typed = (*BridgeMintResult)(nil)
+
+type BridgeMintResult struct {
+	ReceiverBalance uint64 `serialize:"true" json:"receiver_balance"`
+
+	// Minted is the total outstanding amount minted from the source chain.
+	Minted uint64 `serialize:"true" json:"minted"`
+}
+
+func (*BridgeMintResult) GetTypeID() uint8 {
+	return mconsts.BridgeMintID // Common practice is to use the action ID
+}
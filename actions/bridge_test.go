@@ -0,0 +1,398 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+type bridgeTestRelayers struct {
+	keys   []*bls.PrivateKey
+	config *storage.BridgeConfig
+}
+
+func newBridgeTestRelayers(t *testing.T, n int, quorum uint8) *bridgeTestRelayers {
+	r := &bridgeTestRelayers{config: &storage.BridgeConfig{Quorum: quorum}}
+	for i := 0; i < n; i++ {
+		key, err := bls.GeneratePrivateKey()
+		require.NoError(t, err)
+		r.keys = append(r.keys, key)
+		r.config.Relayers = append(r.config.Relayers, bls.PublicKeyToBytes(bls.PublicFromPrivateKey(key)))
+	}
+	return r
+}
+
+// sign returns the signatures of the relayers at [indices].
+func (r *bridgeTestRelayers) sign(m *BridgeMessage, indices ...int) []byte {
+	sigs := make([]*auth.BLS, len(indices))
+	for i, index := range indices {
+		sigs[i] = SignBridgeMessage(r.keys[index], m)
+	}
+	return MarshalBridgeSignatures(sigs)
+}
+
+func (r *bridgeTestRelayers) newStore(t *testing.T) *chaintest.InMemoryStore {
+	store := chaintest.NewInMemoryStore()
+	require.NoError(t, storage.SetBridgeConfig(context.Background(), store, r.config))
+	return store
+}
+
+func newBridgeTestRules(chainID ids.ID) *genesis.Rules {
+	rules := genesis.NewDefaultRules()
+	rules.ChainID = chainID
+	return rules
+}
+
+func TestBridgeLockAction(t *testing.T) {
+	var (
+		chainID   = ids.GenerateTestID()
+		dstID     = ids.GenerateTestID()
+		recipient = codectest.NewRandomAddress()
+		actionID  = ids.GenerateTestID()
+		rules     = newBridgeTestRules(chainID)
+	)
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:  "ZeroValue",
+			Actor: codec.EmptyAddress,
+			Rules: rules,
+			Action: &BridgeLock{
+				DestinationChainID: dstID,
+				Recipient:          recipient,
+			},
+			State:       chaintest.NewInMemoryStore(),
+			ExpectedErr: ErrOutputValueZero,
+		},
+		{
+			Name:  "SameChain",
+			Actor: codec.EmptyAddress,
+			Rules: rules,
+			Action: &BridgeLock{
+				DestinationChainID: chainID,
+				Recipient:          recipient,
+				Value:              1,
+			},
+			State:       chaintest.NewInMemoryStore(),
+			ExpectedErr: ErrInvalidBridgeChain,
+		},
+		{
+			Name:     "Lock",
+			Actor:    codec.EmptyAddress,
+			Rules:    rules,
+			ActionID: actionID,
+			Action: &BridgeLock{
+				DestinationChainID: dstID,
+				Recipient:          recipient,
+				Value:              4,
+			},
+			State: func() state.Mutable {
+				store := chaintest.NewInMemoryStore()
+				require.NoError(t, storage.SetBalance(context.Background(), store, codec.EmptyAddress, 10))
+				_, err := storage.AddBridgeEscrow(context.Background(), store, dstID, 2)
+				require.NoError(t, err)
+				return store
+			}(),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				locked, err := storage.GetBridgeEscrow(ctx, store, dstID)
+				require.NoError(t, err)
+				require.Equal(t, uint64(6), locked)
+			},
+			ExpectedOutputs: &BridgeLockResult{
+				TransferID:    actionID,
+				SenderBalance: 6,
+				Locked:        6,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+func TestBridgeMintAction(t *testing.T) {
+	var (
+		chainID    = ids.GenerateTestID()
+		srcID      = ids.GenerateTestID()
+		transferID = ids.GenerateTestID()
+		recipient  = codectest.NewRandomAddress()
+		rules      = newBridgeTestRules(chainID)
+		relayers   = newBridgeTestRelayers(t, 3, 2)
+		msg        = &BridgeMessage{
+			Kind:               BridgeMessageMint,
+			SourceChainID:      srcID,
+			DestinationChainID: chainID,
+			TransferID:         transferID,
+			Recipient:          recipient,
+			Value:              5,
+		}
+	)
+	newMint := func(signatures []byte) *BridgeMint {
+		return &BridgeMint{
+			SourceChainID: srcID,
+			TransferID:    transferID,
+			Recipient:     recipient,
+			Value:         5,
+			Signatures:    signatures,
+		}
+	}
+	outsider, err := bls.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:        "NotConfigured",
+			Rules:       rules,
+			Action:      newMint(relayers.sign(msg, 0, 1)),
+			State:       chaintest.NewInMemoryStore(),
+			ExpectedErr: ErrBridgeNotConfigured,
+		},
+		{
+			Name:        "BelowQuorum",
+			Rules:       rules,
+			Action:      newMint(relayers.sign(msg, 2)),
+			State:       relayers.newStore(t),
+			ExpectedErr: ErrInsufficientSigners,
+		},
+		{
+			Name:        "Malformed",
+			Rules:       rules,
+			Action:      newMint(relayers.sign(msg, 0, 1)[1:]),
+			State:       relayers.newStore(t),
+			ExpectedErr: ErrInvalidBridgeSigners,
+		},
+		{
+			Name:  "UnknownSigner",
+			Rules: rules,
+			Action: newMint(MarshalBridgeSignatures([]*auth.BLS{
+				SignBridgeMessage(relayers.keys[0], msg),
+				SignBridgeMessage(outsider, msg),
+			})),
+			State:       relayers.newStore(t),
+			ExpectedErr: ErrInvalidBridgeSigners,
+		},
+		{
+			Name:        "DuplicateSigner",
+			Rules:       rules,
+			Action:      newMint(relayers.sign(msg, 1, 1)),
+			State:       relayers.newStore(t),
+			ExpectedErr: ErrInvalidBridgeSigners,
+		},
+		{
+			Name:  "SignerMismatch",
+			Rules: rules,
+			Action: newMint(MarshalBridgeSignatures([]*auth.BLS{
+				SignBridgeMessage(relayers.keys[0], msg),
+				{
+					Signer:    bls.PublicFromPrivateKey(relayers.keys[1]),
+					Signature: bls.Sign(msg.Bytes(), relayers.keys[2]),
+				},
+			})),
+			State:       relayers.newStore(t),
+			ExpectedErr: ErrInvalidBridgeSignature,
+		},
+		{
+			Name:        "WrongDestination",
+			Rules:       newBridgeTestRules(ids.GenerateTestID()),
+			Action:      newMint(relayers.sign(msg, 0, 1)),
+			State:       relayers.newStore(t),
+			ExpectedErr: ErrInvalidBridgeSignature,
+		},
+		{
+			Name:   "AlreadyProcessed",
+			Rules:  rules,
+			Action: newMint(relayers.sign(msg, 0, 1)),
+			State: func() state.Mutable {
+				store := relayers.newStore(t)
+				require.NoError(t, storage.SetBridgeTransfer(context.Background(), store, srcID, transferID, 1))
+				return store
+			}(),
+			ExpectedErr: ErrBridgeTransferProcessed,
+		},
+		{
+			Name:      "Mint",
+			Rules:     rules,
+			Timestamp: 7,
+			Action:    newMint(relayers.sign(msg, 2, 0)),
+			State: func() state.Mutable {
+				store := relayers.newStore(t)
+				require.NoError(t, storage.SetBalance(context.Background(), store, recipient, 1))
+				return store
+			}(),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				processedAt, processed, err := storage.GetBridgeTransfer(ctx, store, srcID, transferID)
+				require.NoError(t, err)
+				require.True(t, processed)
+				require.Equal(t, int64(7), processedAt)
+				minted, err := storage.GetBridgeMinted(ctx, store, srcID)
+				require.NoError(t, err)
+				require.Equal(t, uint64(5), minted)
+			},
+			ExpectedOutputs: &BridgeMintResult{
+				ReceiverBalance: 6,
+				Minted:          5,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+func TestBridgeBurnAction(t *testing.T) {
+	var (
+		chainID   = ids.GenerateTestID()
+		dstID     = ids.GenerateTestID()
+		recipient = codectest.NewRandomAddress()
+		actionID  = ids.GenerateTestID()
+		rules     = newBridgeTestRules(chainID)
+	)
+	newStore := func(balance uint64, minted uint64) state.Mutable {
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, storage.SetBalance(context.Background(), store, codec.EmptyAddress, balance))
+		_, err := storage.AddBridgeMinted(context.Background(), store, dstID, minted)
+		require.NoError(t, err)
+		return store
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:  "MoreThanMinted",
+			Actor: codec.EmptyAddress,
+			Rules: rules,
+			Action: &BridgeBurn{
+				DestinationChainID: dstID,
+				Recipient:          recipient,
+				Value:              4,
+			},
+			State:       newStore(10, 3),
+			ExpectedErr: ErrInsufficientBridgeFunds,
+		},
+		{
+			Name:     "Burn",
+			Actor:    codec.EmptyAddress,
+			Rules:    rules,
+			ActionID: actionID,
+			Action: &BridgeBurn{
+				DestinationChainID: dstID,
+				Recipient:          recipient,
+				Value:              3,
+			},
+			State: newStore(10, 3),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				_, err := store.GetValue(ctx, storage.BridgeMintedKey(dstID))
+				require.ErrorIs(t, err, database.ErrNotFound)
+			},
+			ExpectedOutputs: &BridgeBurnResult{
+				TransferID:    actionID,
+				SenderBalance: 7,
+				Minted:        0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+func TestBridgeUnlockAction(t *testing.T) {
+	var (
+		chainID    = ids.GenerateTestID()
+		srcID      = ids.GenerateTestID()
+		transferID = ids.GenerateTestID()
+		recipient  = codectest.NewRandomAddress()
+		rules      = newBridgeTestRules(chainID)
+		relayers   = newBridgeTestRelayers(t, 1, 1)
+		msg        = &BridgeMessage{
+			Kind:               BridgeMessageUnlock,
+			SourceChainID:      srcID,
+			DestinationChainID: chainID,
+			TransferID:         transferID,
+			Recipient:          recipient,
+			Value:              5,
+		}
+		unlock = &BridgeUnlock{
+			SourceChainID: srcID,
+			TransferID:    transferID,
+			Recipient:     recipient,
+			Value:         5,
+			Signatures:    relayers.sign(msg, 0),
+		}
+	)
+	newStore := func(locked uint64) state.Mutable {
+		store := relayers.newStore(t)
+		_, err := storage.AddBridgeEscrow(context.Background(), store, srcID, locked)
+		require.NoError(t, err)
+		return store
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:        "MoreThanLocked",
+			Rules:       rules,
+			Action:      unlock,
+			State:       newStore(4),
+			ExpectedErr: ErrInsufficientBridgeFunds,
+		},
+		{
+			Name:  "MintSignature",
+			Rules: rules,
+			Action: &BridgeUnlock{
+				SourceChainID: srcID,
+				TransferID:    transferID,
+				Recipient:     recipient,
+				Value:         5,
+				Signatures: relayers.sign(&BridgeMessage{
+					Kind:               BridgeMessageMint,
+					SourceChainID:      srcID,
+					DestinationChainID: chainID,
+					TransferID:         transferID,
+					Recipient:          recipient,
+					Value:              5,
+				}, 0),
+			},
+			State:       newStore(5),
+			ExpectedErr: ErrInvalidBridgeSignature,
+		},
+		{
+			Name:   "Unlock",
+			Rules:  rules,
+			Action: unlock,
+			State:  newStore(8),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				balance, err := storage.GetBalance(ctx, store, recipient)
+				require.NoError(t, err)
+				require.Equal(t, uint64(5), balance)
+				_, processed, err := storage.GetBridgeTransfer(ctx, store, srcID, transferID)
+				require.NoError(t, err)
+				require.True(t, processed)
+			},
+			ExpectedOutputs: &BridgeUnlockResult{
+				ReceiverBalance: 5,
+				Locked:          3,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const BridgeUnlockComputeUnits = 1
+
+var _ chain.Action = (*BridgeUnlock)(nil)
+
+// BridgeUnlock releases escrowed value to [Recipient] after it was burned on
+// [SourceChainID] by the [BridgeBurn] action [TransferID]. It must carry the
+// signatures of a quorum of relayers. Anyone can submit it.
+type BridgeUnlock struct {
+	SourceChainID ids.ID        `serialize:"true" json:"sourceChainID"`
+	TransferID    ids.ID        `serialize:"true" json:"transferID"`
+	Recipient     codec.Address `serialize:"true" json:"recipient"`
+	Value         uint64        `serialize:"true" json:"value"`
+
+	// Signatures are the signatures of distinct relayers over the
+	// [BridgeMessage], encoded with [MarshalBridgeSignatures].
+	Signatures []byte `serialize:"true" json:"signatures"`
+}
+
+func (*BridgeUnlock) GetTypeID() uint8 {
+	return mconsts.BridgeUnlockID
+}
+
+func (b *BridgeUnlock) StateKeys(codec.Address, ids.ID) state.Keys {
+	return state.Keys{
+		string(storage.BridgeConfigKey()):                                state.Read,
+		string(storage.BridgeTransferKey(b.SourceChainID, b.TransferID)): state.All,
+		string(storage.BridgeEscrowKey(b.SourceChainID)):                 state.Read | state.Write,
+		string(storage.BalanceKey(b.Recipient)):                          state.All,
+	}
+}
+
+func (b *BridgeUnlock) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	_ codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if b.Value == 0 {
+		return nil, ErrOutputValueZero
+	}
+	if b.SourceChainID == rules.GetChainID() {
+		return nil, ErrInvalidBridgeChain
+	}
+	if err := verifyBridgeTransfer(ctx, mu, b.Signatures, &BridgeMessage{
+		Kind:               BridgeMessageUnlock,
+		SourceChainID:      b.SourceChainID,
+		DestinationChainID: rules.GetChainID(),
+		TransferID:         b.TransferID,
+		Recipient:          b.Recipient,
+		Value:              b.Value,
+	}); err != nil {
+		return nil, err
+	}
+
+	locked, err := storage.GetBridgeEscrow(ctx, mu, b.SourceChainID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Value > locked {
+		return nil, fmt.Errorf("%w: (locked=%d, value=%d)", ErrInsufficientBridgeFunds, locked, b.Value)
+	}
+	locked, err = storage.SubBridgeEscrow(ctx, mu, b.SourceChainID, b.Value)
+	if err != nil {
+		return nil, err
+	}
+	receiverBalance, err := storage.AddBalance(ctx, mu, b.Recipient, b.Value)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.SetBridgeTransfer(ctx, mu, b.SourceChainID, b.TransferID, timestamp); err != nil {
+		return nil, err
+	}
+
+	return &BridgeUnlockResult{
+		ReceiverBalance: receiverBalance,
+		Locked:          locked,
+	}, nil
+}
+
+func (b *BridgeUnlock) ComputeUnits(chain.Rules) uint64 {
+	return BridgeUnlockComputeUnits + bridgeVerifyComputeUnits(b.Signatures)
+}
+
+func (*BridgeUnlock) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// SupplyChange returns the amount minted and burned by [b] when it succeeds.
+// [BridgeUnlock] releases [Value] from escrow into the balances.
+func (b *BridgeUnlock) SupplyChange() (uint64, uint64) {
+	return b.Value, 0
+}
+
+var _ codec.Typed = (*BridgeUnlockResult)(nil)
+
+type BridgeUnlockResult struct {
+	ReceiverBalance uint64 `serialize:"true" json:"receiver_balance"`
+
+	// Locked is the total remaining in escrow for the source chain.
+	Locked uint64 `serialize:"true" json:"locked"`
+}
+
+func (*BridgeUnlockResult) GetTypeID() uint8 {
+	return mconsts.BridgeUnlockID // Common practice is to use the action ID
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const CancelSubscriptionComputeUnits = 1
+
+var (
+	ErrNotPayer              = errors.New("actor is not the payer")
+	_           chain.Action = (*CancelSubscription)(nil)
+)
+
+// CancelSubscription ends a subscription. Only the payer can cancel.
+type CancelSubscription struct {
+	SubscriptionID ids.ID `serialize:"true" json:"subscription_id"`
+
+	// Payee is the payee of the subscription, which is part of its key.
+	Payee codec.Address `serialize:"true" json:"payee"`
+}
+
+func (*CancelSubscription) GetTypeID() uint8 {
+	return mconsts.CancelSubscriptionID
+}
+
+func (c *CancelSubscription) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return state.Keys{
+		string(storage.SubscriptionKey(c.Payee, c.SubscriptionID)): state.Read | state.Write,
+		string(storage.PayerSubscriptionsKey(actor)):               state.Read | state.Write,
+	}
+}
+
+func (c *CancelSubscription) Execute(
+	ctx context.Context,
+	_ chain.Rules,
+	mu state.Mutable,
+	_ int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	s, exists, err := storage.GetSubscription(ctx, mu, c.Payee, c.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrSubscriptionNotFound
+	}
+	if s.Payer != actor {
+		return nil, ErrNotPayer
+	}
+	if err := storage.RemoveSubscription(ctx, mu, c.SubscriptionID, s); err != nil {
+		return nil, err
+	}
+	return &CancelSubscriptionResult{
+		Cycles: s.Cycles,
+	}, nil
+}
+
+func (*CancelSubscription) ComputeUnits(chain.Rules) uint64 {
+	return CancelSubscriptionComputeUnits
+}
+
+func (*CancelSubscription) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+var _ codec.Typed = (*CancelSubscriptionResult)(nil)
+
+type CancelSubscriptionResult struct {
+	// Cycles is the number of times the subscription was charged.
+	Cycles uint64 `serialize:"true" json:"cycles"`
+}
+
+func (*CancelSubscriptionResult) GetTypeID() uint8 {
+	return mconsts.CancelSubscriptionID // Common practice is to use the action ID
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const ChargeSubscriptionComputeUnits = 1
+
+var (
+	ErrSubscriptionNotFound              = errors.New("subscription not found")
+	ErrPayerMismatch                     = errors.New("payer does not match subscription")
+	ErrChargeTooEarly                    = errors.New("subscription was already charged this period")
+	_                       chain.Action = (*ChargeSubscription)(nil)
+)
+
+// ChargeSubscription pulls one payment from the payer of a subscription. Only
+// the payee can charge, and at most once per period. Subscriptions are keyed
+// by payee, so the actor only finds the subscriptions it is the payee of.
+type ChargeSubscription struct {
+	SubscriptionID ids.ID `serialize:"true" json:"subscription_id"`
+
+	// Payer must match the payer of the subscription. It is required to
+	// declare the payer's state keys up front.
+	Payer codec.Address `serialize:"true" json:"payer"`
+}
+
+func (*ChargeSubscription) GetTypeID() uint8 {
+	return mconsts.ChargeSubscriptionID
+}
+
+func (c *ChargeSubscription) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return state.Keys{
+		string(storage.SubscriptionKey(actor, c.SubscriptionID)): state.Read | state.Write,
+		string(storage.BalanceKey(c.Payer)):                      state.Read | state.Write,
+		string(storage.SpendingLimitKey(c.Payer)):                state.Read | state.Write,
+		string(storage.BalanceKey(actor)):                        state.All,
+		string(storage.PayerSubscriptionsKey(c.Payer)):           state.Read | state.Write,
+	}
+}
+
+func (c *ChargeSubscription) Execute(
+	ctx context.Context,
+	_ chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	s, exists, err := storage.GetSubscription(ctx, mu, actor, c.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrSubscriptionNotFound
+	}
+	if s.Payer != c.Payer {
+		return nil, ErrPayerMismatch
+	}
+	if timestamp < s.NextChargeAt {
+		return nil, ErrChargeTooEarly
+	}
+
+	payerBalance, err := storage.SubBalance(ctx, mu, timestamp, s.Payer, s.Amount)
+	if err != nil {
+		return nil, err
+	}
+	payeeBalance, err := storage.AddBalance(ctx, mu, actor, s.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Cycles++
+	s.NextChargeAt = timestamp + s.Period
+	if s.MaxCycles != 0 && s.Cycles >= s.MaxCycles {
+		err = storage.RemoveSubscription(ctx, mu, c.SubscriptionID, s)
+	} else {
+		err = storage.SetSubscription(ctx, mu, c.SubscriptionID, s)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChargeSubscriptionResult{
+		PayerBalance: payerBalance,
+		PayeeBalance: payeeBalance,
+		Cycles:       s.Cycles,
+		Amount:       s.Amount,
+	}, nil
+}
+
+func (*ChargeSubscription) ComputeUnits(chain.Rules) uint64 {
+	return ChargeSubscriptionComputeUnits
+}
+
+func (*ChargeSubscription) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+var _ codec.Typed = (*ChargeSubscriptionResult)(nil)
+
+type ChargeSubscriptionResult struct {
+	PayerBalance uint64 `serialize:"true" json:"payer_balance"`
+	PayeeBalance uint64 `serialize:"true" json:"payee_balance"`
+
+	// Cycles is the number of times the subscription has been charged,
+	// including this charge.
+	Cycles uint64 `serialize:"true" json:"cycles"`
+
+	// Amount is the value moved from the payer to the payee.
+	Amount uint64 `serialize:"true" json:"amount"`
+}
+
+func (*ChargeSubscriptionResult) GetTypeID() uint8 {
+	return mconsts.ChargeSubscriptionID // Common practice is to use the action ID
+}
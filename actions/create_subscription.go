@@ -0,0 +1,102 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const CreateSubscriptionComputeUnits = 1
+
+var (
+	ErrInvalidPeriod              = errors.New("period must be positive")
+	_                chain.Action = (*CreateSubscription)(nil)
+)
+
+// CreateSubscription authorizes [Payee] to pull [Amount] from the actor once
+// per [Period] using [ChargeSubscription]. The subscription is identified by
+// the ID of this action.
+type CreateSubscription struct {
+	// Payee is the only account that can charge the subscription.
+	Payee codec.Address `serialize:"true" json:"payee"`
+
+	// Amount is transferred to [Payee] on every charge.
+	Amount uint64 `serialize:"true" json:"amount"`
+
+	// Period is the minimum time between charges in milliseconds.
+	Period int64 `serialize:"true" json:"period"`
+
+	// MaxCycles is the number of charges after which the subscription ends.
+	// If 0, the subscription lasts until it is cancelled.
+	MaxCycles uint64 `serialize:"true" json:"max_cycles"`
+}
+
+func (*CreateSubscription) GetTypeID() uint8 {
+	return mconsts.CreateSubscriptionID
+}
+
+func (c *CreateSubscription) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	return state.Keys{
+		string(storage.SubscriptionKey(c.Payee, actionID)): state.All,
+		string(storage.PayerSubscriptionsKey(actor)):       state.All,
+	}
+}
+
+func (c *CreateSubscription) Execute(
+	ctx context.Context,
+	_ chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (codec.Typed, error) {
+	if c.Amount == 0 {
+		return nil, ErrOutputValueZero
+	}
+	if c.Period <= 0 {
+		return nil, ErrInvalidPeriod
+	}
+	if err := storage.AddSubscription(ctx, mu, actionID, &storage.Subscription{
+		Payer:        actor,
+		Payee:        c.Payee,
+		Amount:       c.Amount,
+		Period:       c.Period,
+		MaxCycles:    c.MaxCycles,
+		NextChargeAt: timestamp,
+	}); err != nil {
+		return nil, err
+	}
+	return &CreateSubscriptionResult{
+		SubscriptionID: actionID,
+	}, nil
+}
+
+func (*CreateSubscription) ComputeUnits(chain.Rules) uint64 {
+	return CreateSubscriptionComputeUnits
+}
+
+func (*CreateSubscription) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+var _ codec.Typed = (*CreateSubscriptionResult)(nil)
+
+type CreateSubscriptionResult struct {
+	SubscriptionID ids.ID `serialize:"true" json:"subscription_id"`
+}
+
+func (*CreateSubscriptionResult) GetTypeID() uint8 {
+	return mconsts.CreateSubscriptionID // Common practice is to use the action ID
+}
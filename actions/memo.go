@@ -0,0 +1,153 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"io"
+
+	"filippo.io/edwards25519"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+)
+
+// Encrypted memos are laid out as:
+//
+//	[version] + [ephemeral X25519 public key] + [nonce] + [ciphertext]
+//
+// The sender generates an ephemeral X25519 key and performs a Diffie-Hellman
+// exchange with the X25519 form of the recipient's ed25519 public key. The
+// shared secret is expanded with HKDF-SHA256 into a ChaCha20-Poly1305 key. The
+// version byte and ephemeral key are authenticated as additional data.
+const (
+	EncryptedMemoVersion byte = 1
+
+	encryptedMemoHeaderSize = 1 + curve25519.PointSize
+	encryptedMemoOverhead   = encryptedMemoHeaderSize + chacha20poly1305.NonceSize + chacha20poly1305.Overhead
+
+	// MaxEncryptedMemoSize is the largest plaintext that fits in a
+	// [Transfer] memo once encrypted.
+	MaxEncryptedMemoSize = MaxMemoSize - encryptedMemoOverhead
+)
+
+var memoKeyInfo = []byte("morpheusvm memo v1")
+
+var (
+	ErrEncryptedMemoTooLarge = errors.New("encrypted memo is too large")
+	ErrMemoNotEncrypted      = errors.New("memo is not encrypted")
+	ErrInvalidMemoKey        = errors.New("invalid memo key")
+	ErrMemoDecryptionFailed  = errors.New("memo decryption failed")
+)
+
+// IsEncryptedMemo reports whether [memo] is formatted as an encrypted memo. It
+// does not check that the memo can be decrypted.
+func IsEncryptedMemo(memo []byte) bool {
+	return len(memo) >= encryptedMemoOverhead && memo[0] == EncryptedMemoVersion
+}
+
+// SealMemo encrypts [plaintext] so that only the owner of [recipient] can read
+// it with [OpenMemo].
+func SealMemo(recipient ed25519.PublicKey, plaintext []byte) ([]byte, error) {
+	if len(plaintext) > MaxEncryptedMemoSize {
+		return nil, ErrEncryptedMemoTooLarge
+	}
+	recipientKey, err := x25519PublicKey(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralKey := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeralKey); err != nil {
+		return nil, err
+	}
+	ephemeralPublicKey, err := curve25519.X25519(ephemeralKey, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := curve25519.X25519(ephemeralKey, recipientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	memo := make([]byte, encryptedMemoHeaderSize+chacha20poly1305.NonceSize, len(plaintext)+encryptedMemoOverhead)
+	memo[0] = EncryptedMemoVersion
+	copy(memo[1:], ephemeralPublicKey)
+	nonce := memo[encryptedMemoHeaderSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	aead, err := newMemoAEAD(sharedSecret, ephemeralPublicKey, recipientKey)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(memo, nonce, plaintext, memo[:encryptedMemoHeaderSize]), nil
+}
+
+// OpenMemo decrypts a memo produced by [SealMemo] for the public key of [key].
+func OpenMemo(key ed25519.PrivateKey, memo []byte) ([]byte, error) {
+	if !IsEncryptedMemo(memo) {
+		return nil, ErrMemoNotEncrypted
+	}
+	privateKey := x25519PrivateKey(key)
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPublicKey := memo[1:encryptedMemoHeaderSize]
+	sharedSecret, err := curve25519.X25519(privateKey, ephemeralPublicKey)
+	if err != nil {
+		return nil, ErrMemoDecryptionFailed
+	}
+	aead, err := newMemoAEAD(sharedSecret, ephemeralPublicKey, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := memo[encryptedMemoHeaderSize : encryptedMemoHeaderSize+chacha20poly1305.NonceSize]
+	ciphertext := memo[encryptedMemoHeaderSize+chacha20poly1305.NonceSize:]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, memo[:encryptedMemoHeaderSize])
+	if err != nil {
+		return nil, ErrMemoDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func newMemoAEAD(sharedSecret []byte, ephemeralPublicKey []byte, recipientKey []byte) (cipher.AEAD, error) {
+	salt := make([]byte, 0, len(ephemeralPublicKey)+len(recipientKey))
+	salt = append(salt, ephemeralPublicKey...)
+	salt = append(salt, recipientKey...)
+
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, salt, memoKeyInfo), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+// x25519PublicKey converts an ed25519 public key to its birationally
+// equivalent X25519 public key.
+func x25519PublicKey(pk ed25519.PublicKey) ([]byte, error) {
+	p, err := new(edwards25519.Point).SetBytes(pk[:])
+	if err != nil {
+		return nil, ErrInvalidMemoKey
+	}
+	return p.BytesMontgomery(), nil
+}
+
+// x25519PrivateKey derives the X25519 scalar of an ed25519 private key the
+// same way ed25519 derives its signing scalar, so it matches
+// [x25519PublicKey] of the corresponding public key.
+func x25519PrivateKey(key ed25519.PrivateKey) []byte {
+	h := sha512.Sum512(key[:ed25519.PrivateKeySeedLen])
+	return h[:curve25519.ScalarSize]
+}
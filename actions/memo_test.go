@@ -0,0 +1,57 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+)
+
+func TestSealOpenMemo(t *testing.T) {
+	require := require.New(t)
+
+	recipient, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	other, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+
+	plaintext := []byte("invoice #42")
+	memo, err := SealMemo(recipient.PublicKey(), plaintext)
+	require.NoError(err)
+	require.True(IsEncryptedMemo(memo))
+	require.LessOrEqual(len(memo), MaxMemoSize)
+
+	opened, err := OpenMemo(recipient, memo)
+	require.NoError(err)
+	require.Equal(plaintext, opened)
+
+	_, err = OpenMemo(other, memo)
+	require.ErrorIs(err, ErrMemoDecryptionFailed)
+
+	tampered := bytes.Clone(memo)
+	tampered[len(tampered)-1] ^= 1
+	_, err = OpenMemo(recipient, tampered)
+	require.ErrorIs(err, ErrMemoDecryptionFailed)
+
+	_, err = OpenMemo(recipient, plaintext)
+	require.ErrorIs(err, ErrMemoNotEncrypted)
+}
+
+func TestSealMemoSize(t *testing.T) {
+	require := require.New(t)
+
+	recipient, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+
+	memo, err := SealMemo(recipient.PublicKey(), make([]byte, MaxEncryptedMemoSize))
+	require.NoError(err)
+	require.Len(memo, MaxMemoSize)
+
+	_, err = SealMemo(recipient.PublicKey(), make([]byte, MaxEncryptedMemoSize+1))
+	require.ErrorIs(err, ErrEncryptedMemoTooLarge)
+}
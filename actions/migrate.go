@@ -0,0 +1,92 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const MigrateComputeUnits = 1
+
+var (
+	ErrNoMigration = errors.New("no migration to apply")
+
+	_ chain.Action = (*Migrate)(nil)
+)
+
+// Migrate applies the state migrations that are active at the time of the
+// block, in order. Any account can submit it once a migration activates; it
+// fails if there is nothing to apply.
+type Migrate struct {
+	// registry holds the migrations to apply. It is not serialized, so a
+	// parsed action applies [storage.Migrations].
+	registry *storage.MigrationRegistry
+}
+
+func (*Migrate) GetTypeID() uint8 {
+	return mconsts.MigrateID
+}
+
+func (m *Migrate) StateKeys(codec.Address, ids.ID) state.Keys {
+	return m.migrations().StateKeys()
+}
+
+func (m *Migrate) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	_ codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	migrationRules, ok := rules.(storage.MigrationRules)
+	if !ok {
+		return nil, ErrNoMigration
+	}
+	applied, err := m.migrations().Apply(ctx, mu, migrationRules, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) == 0 {
+		return nil, ErrNoMigration
+	}
+	return &MigrateResult{Version: applied[len(applied)-1].Version}, nil
+}
+
+func (*Migrate) ComputeUnits(chain.Rules) uint64 {
+	return MigrateComputeUnits
+}
+
+func (*Migrate) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+func (m *Migrate) migrations() *storage.MigrationRegistry {
+	if m.registry == nil {
+		return storage.Migrations()
+	}
+	return m.registry
+}
+
+var _ codec.Typed = (*MigrateResult)(nil)
+
+type MigrateResult struct {
+	// Version is the schema version of the state after the migrations.
+	Version uint64 `serialize:"true" json:"version"`
+}
+
+func (*MigrateResult) GetTypeID() uint8 {
+	return mconsts.MigrateID // Common practice is to use the action ID
+}
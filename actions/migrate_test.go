@@ -0,0 +1,111 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+type migrationRules struct {
+	*genesis.Rules
+	migrations map[uint64]int64
+}
+
+func (r *migrationRules) GetMigrationTime(version uint64) (int64, bool) {
+	t, ok := r.migrations[version]
+	return t, ok
+}
+
+func TestMigrateAction(t *testing.T) {
+	addr := codectest.NewRandomAddress()
+	registry := storage.NewMigrationRegistry()
+	require.NoError(t, registry.Register(&storage.Migration{
+		Version: 1,
+		Name:    "double balance",
+		StateKeys: state.Keys{
+			string(storage.BalanceKey(addr)): state.Read | state.Write,
+		},
+		Migrate: func(ctx context.Context, mu state.Mutable) error {
+			balance, err := storage.GetBalance(ctx, mu, addr)
+			if err != nil {
+				return err
+			}
+			return storage.SetBalance(ctx, mu, addr, 2*balance)
+		},
+	}))
+	migrate := &Migrate{registry: registry}
+	require.Len(t, migrate.StateKeys(codec.EmptyAddress, ids.Empty), 2)
+
+	rules := &migrationRules{
+		Rules:      genesis.NewDefaultRules(),
+		migrations: map[uint64]int64{1: 10},
+	}
+	newState := func() state.Mutable {
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, storage.SetBalance(context.Background(), store, addr, 5))
+		return store
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:        "NotScheduled",
+			Action:      migrate,
+			Rules:       genesis.NewDefaultRules(),
+			State:       newState(),
+			Timestamp:   10,
+			ExpectedErr: ErrNoMigration,
+		},
+		{
+			Name:        "NotActive",
+			Action:      migrate,
+			Rules:       rules,
+			State:       newState(),
+			Timestamp:   9,
+			ExpectedErr: ErrNoMigration,
+		},
+		{
+			Name:      "Migrate",
+			Action:    migrate,
+			Rules:     rules,
+			State:     newState(),
+			Timestamp: 10,
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				balance, err := storage.GetBalance(ctx, store, addr)
+				require.NoError(t, err)
+				require.Equal(t, uint64(10), balance)
+				version, err := storage.GetSchemaVersion(ctx, store)
+				require.NoError(t, err)
+				require.Equal(t, uint64(1), version)
+			},
+			ExpectedOutputs: &MigrateResult{Version: 1},
+		},
+		{
+			Name:   "AlreadyApplied",
+			Action: migrate,
+			Rules:  rules,
+			State: func() state.Mutable {
+				store := newState()
+				require.NoError(t, storage.SetSchemaVersion(context.Background(), store, 1))
+				return store
+			}(),
+			Timestamp:   10,
+			ExpectedErr: ErrNoMigration,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const (
+	SetSpendingLimitComputeUnits = 1
+
+	// SpendingLimitDelay is how long (in ms) it takes for a raised or removed
+	// spending limit to take effect.
+	SpendingLimitDelay int64 = 24 * 60 * 60 * 1000
+)
+
+var (
+	ErrInvalidWindow                = errors.New("window is negative")
+	ErrNoSpendingLimit              = errors.New("no spending limit")
+	_                  chain.Action = (*SetSpendingLimit)(nil)
+)
+
+type SetSpendingLimit struct {
+	// Limit is the maximum amount that can be debited within [Window].
+	Limit uint64 `serialize:"true" json:"limit"`
+
+	// Window is the length of the spending window in milliseconds. A window
+	// of 0 removes the spending limit.
+	Window int64 `serialize:"true" json:"window"`
+}
+
+func (*SetSpendingLimit) GetTypeID() uint8 {
+	return mconsts.SetSpendingLimitID
+}
+
+func (*SetSpendingLimit) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return state.Keys{
+		string(storage.SpendingLimitKey(actor)): state.All,
+	}
+}
+
+func (s *SetSpendingLimit) Execute(
+	ctx context.Context,
+	_ chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if s.Window < 0 {
+		return nil, ErrInvalidWindow
+	}
+	current, exists, err := storage.GetSpendingLimit(ctx, mu, actor)
+	if err != nil {
+		return nil, err
+	}
+	if exists && !current.Advance(timestamp) {
+		// A previously scheduled removal has already taken effect.
+		if err := storage.RemoveSpendingLimit(ctx, mu, actor); err != nil {
+			return nil, err
+		}
+		exists = false
+	}
+
+	if !exists {
+		if s.Window == 0 {
+			return nil, ErrNoSpendingLimit
+		}
+		// Adding a limit can only restrict the account, so it applies immediately.
+		if err := storage.SetSpendingLimit(ctx, mu, actor, &storage.SpendingLimit{
+			Limit:       s.Limit,
+			Window:      s.Window,
+			WindowStart: timestamp,
+		}); err != nil {
+			return nil, err
+		}
+		return &SetSpendingLimitResult{
+			Limit:       s.Limit,
+			Window:      s.Window,
+			EffectiveAt: timestamp,
+		}, nil
+	}
+
+	// Any new request replaces a pending change, so lowering the limit also
+	// cancels a scheduled raise.
+	current.PendingLimit = 0
+	current.PendingWindow = 0
+	current.PendingAt = 0
+
+	effectiveAt := timestamp
+	if s.Window != 0 && s.Limit <= current.Limit && s.Window >= current.Window {
+		current.Limit = s.Limit
+		current.Window = s.Window
+	} else {
+		effectiveAt = timestamp + SpendingLimitDelay
+		current.PendingLimit = s.Limit
+		current.PendingWindow = s.Window
+		current.PendingAt = effectiveAt
+	}
+	if err := storage.SetSpendingLimit(ctx, mu, actor, current); err != nil {
+		return nil, err
+	}
+	return &SetSpendingLimitResult{
+		Limit:       s.Limit,
+		Window:      s.Window,
+		EffectiveAt: effectiveAt,
+	}, nil
+}
+
+func (*SetSpendingLimit) ComputeUnits(chain.Rules) uint64 {
+	return SetSpendingLimitComputeUnits
+}
+
+func (*SetSpendingLimit) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+var _ codec.Typed = (*SetSpendingLimitResult)(nil)
+
+type SetSpendingLimitResult struct {
+	Limit  uint64 `serialize:"true" json:"limit"`
+	Window int64  `serialize:"true" json:"window"`
+
+	// EffectiveAt is the timestamp (in ms) at which the new limit applies.
+	EffectiveAt int64 `serialize:"true" json:"effective_at"`
+}
+
+func (*SetSpendingLimitResult) GetTypeID() uint8 {
+	return mconsts.SetSpendingLimitID // Common practice is to use the action ID
+}
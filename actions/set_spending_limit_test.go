@@ -0,0 +1,264 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+func TestSetSpendingLimitAction(t *testing.T) {
+	withLimit := func() state.Mutable {
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, storage.SetSpendingLimit(context.Background(), store, codec.EmptyAddress, &storage.SpendingLimit{
+			Limit:  10,
+			Window: 1_000,
+		}))
+		return store
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:  "NegativeWindow",
+			Actor: codec.EmptyAddress,
+			Action: &SetSpendingLimit{
+				Limit:  1,
+				Window: -1,
+			},
+			State:       chaintest.NewInMemoryStore(),
+			ExpectedErr: ErrInvalidWindow,
+		},
+		{
+			Name:  "RemoveNonExistentLimit",
+			Actor: codec.EmptyAddress,
+			Action: &SetSpendingLimit{
+				Window: 0,
+			},
+			State:       chaintest.NewInMemoryStore(),
+			ExpectedErr: ErrNoSpendingLimit,
+		},
+		{
+			Name:  "NewLimit",
+			Actor: codec.EmptyAddress,
+			Action: &SetSpendingLimit{
+				Limit:  10,
+				Window: 1_000,
+			},
+			State:     chaintest.NewInMemoryStore(),
+			Timestamp: 5,
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				l, exists, err := storage.GetSpendingLimit(ctx, store, codec.EmptyAddress)
+				require.NoError(t, err)
+				require.True(t, exists)
+				require.Equal(t, &storage.SpendingLimit{
+					Limit:       10,
+					Window:      1_000,
+					WindowStart: 5,
+				}, l)
+			},
+			ExpectedOutputs: &SetSpendingLimitResult{
+				Limit:       10,
+				Window:      1_000,
+				EffectiveAt: 5,
+			},
+		},
+		{
+			Name:  "LowerLimit",
+			Actor: codec.EmptyAddress,
+			Action: &SetSpendingLimit{
+				Limit:  5,
+				Window: 1_000,
+			},
+			State: withLimit(),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				l, _, err := storage.GetSpendingLimit(ctx, store, codec.EmptyAddress)
+				require.NoError(t, err)
+				require.Equal(t, uint64(5), l.Limit)
+				require.Zero(t, l.PendingAt)
+			},
+			ExpectedOutputs: &SetSpendingLimitResult{
+				Limit:       5,
+				Window:      1_000,
+				EffectiveAt: 0,
+			},
+		},
+		{
+			Name:  "RaiseLimit",
+			Actor: codec.EmptyAddress,
+			Action: &SetSpendingLimit{
+				Limit:  20,
+				Window: 1_000,
+			},
+			State: withLimit(),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				l, _, err := storage.GetSpendingLimit(ctx, store, codec.EmptyAddress)
+				require.NoError(t, err)
+				require.Equal(t, uint64(10), l.Limit)
+				require.Equal(t, uint64(20), l.PendingLimit)
+				require.Equal(t, SpendingLimitDelay, l.PendingAt)
+			},
+			ExpectedOutputs: &SetSpendingLimitResult{
+				Limit:       20,
+				Window:      1_000,
+				EffectiveAt: SpendingLimitDelay,
+			},
+		},
+		{
+			Name:  "ShortenWindow",
+			Actor: codec.EmptyAddress,
+			Action: &SetSpendingLimit{
+				Limit:  10,
+				Window: 500,
+			},
+			State: withLimit(),
+			ExpectedOutputs: &SetSpendingLimitResult{
+				Limit:       10,
+				Window:      500,
+				EffectiveAt: SpendingLimitDelay,
+			},
+		},
+		{
+			Name:  "RemoveLimit",
+			Actor: codec.EmptyAddress,
+			Action: &SetSpendingLimit{
+				Window: 0,
+			},
+			State: withLimit(),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				l, exists, err := storage.GetSpendingLimit(ctx, store, codec.EmptyAddress)
+				require.NoError(t, err)
+				require.True(t, exists)
+				require.True(t, l.Advance(SpendingLimitDelay-1))
+				require.False(t, l.Advance(SpendingLimitDelay))
+			},
+			ExpectedOutputs: &SetSpendingLimitResult{
+				EffectiveAt: SpendingLimitDelay,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+// TestSpendingLimitTransfers shows how a spending limit is enforced across
+// transfers and windows.
+func TestSpendingLimitTransfers(t *testing.T) {
+	addrAlice := codectest.NewRandomAddress()
+	addrBob := codectest.NewRandomAddress()
+
+	store := chaintest.NewInMemoryStore()
+	require.NoError(t, storage.SetBalance(context.Background(), store, addrAlice, 100))
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:  "SetLimit",
+			Actor: addrAlice,
+			Action: &SetSpendingLimit{
+				Limit:  10,
+				Window: 1_000,
+			},
+			State: store,
+			ExpectedOutputs: &SetSpendingLimitResult{
+				Limit:       10,
+				Window:      1_000,
+				EffectiveAt: 0,
+			},
+		},
+		{
+			Name:  "TransferWithinLimit",
+			Actor: addrAlice,
+			Action: &Transfer{
+				To:    addrBob,
+				Value: 10,
+			},
+			State:     store,
+			Timestamp: 1,
+			ExpectedOutputs: &TransferResult{
+				SenderBalance:   90,
+				ReceiverBalance: 10,
+			},
+		},
+		{
+			Name:  "TransferOverLimit",
+			Actor: addrAlice,
+			Action: &Transfer{
+				To:    addrBob,
+				Value: 1,
+			},
+			State:       store,
+			Timestamp:   999,
+			ExpectedErr: storage.ErrSpendingLimitExceeded,
+		},
+		{
+			Name:  "TransferInNextWindow",
+			Actor: addrAlice,
+			Action: &Transfer{
+				To:    addrBob,
+				Value: 1,
+			},
+			State:     store,
+			Timestamp: 1_000,
+			ExpectedOutputs: &TransferResult{
+				SenderBalance:   89,
+				ReceiverBalance: 11,
+			},
+		},
+		{
+			Name:  "RemoveLimit",
+			Actor: addrAlice,
+			Action: &SetSpendingLimit{
+				Window: 0,
+			},
+			State:     store,
+			Timestamp: 1_000,
+			ExpectedOutputs: &SetSpendingLimitResult{
+				EffectiveAt: 1_000 + SpendingLimitDelay,
+			},
+		},
+		{
+			Name:  "TransferBeforeRemoval",
+			Actor: addrAlice,
+			Action: &Transfer{
+				To:    addrBob,
+				Value: 10,
+			},
+			State:       store,
+			Timestamp:   1_500,
+			ExpectedErr: storage.ErrSpendingLimitExceeded,
+		},
+		{
+			Name:  "TransferAfterRemoval",
+			Actor: addrAlice,
+			Action: &Transfer{
+				To:    addrBob,
+				Value: 50,
+			},
+			State:     store,
+			Timestamp: 1_000 + SpendingLimitDelay,
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				_, exists, err := storage.GetSpendingLimit(ctx, store, addrAlice)
+				require.NoError(t, err)
+				require.False(t, exists)
+			},
+			ExpectedOutputs: &TransferResult{
+				SenderBalance:   39,
+				ReceiverBalance: 61,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
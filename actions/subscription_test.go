@@ -0,0 +1,306 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+func TestCreateSubscriptionAction(t *testing.T) {
+	payer := codectest.NewRandomAddress()
+	payee := codectest.NewRandomAddress()
+	subscriptionID := ids.GenerateTestID()
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:  "ZeroAmount",
+			Actor: payer,
+			Action: &CreateSubscription{
+				Payee:  payee,
+				Period: 1,
+			},
+			ExpectedErr: ErrOutputValueZero,
+		},
+		{
+			Name:  "ZeroPeriod",
+			Actor: payer,
+			Action: &CreateSubscription{
+				Payee:  payee,
+				Amount: 1,
+			},
+			ExpectedErr: ErrInvalidPeriod,
+		},
+		{
+			Name:  "TooManySubscriptions",
+			Actor: payer,
+			Action: &CreateSubscription{
+				Payee:  payee,
+				Amount: 1,
+				Period: 1,
+			},
+			ActionID: subscriptionID,
+			State: func() state.Mutable {
+				store := chaintest.NewInMemoryStore()
+				for i := 0; i < storage.MaxSubscriptionsPerAccount; i++ {
+					require.NoError(t, storage.AddSubscription(context.Background(), store, ids.GenerateTestID(), &storage.Subscription{
+						Payer:  payer,
+						Payee:  codectest.NewRandomAddress(),
+						Amount: 1,
+						Period: 1,
+					}))
+				}
+				return store
+			}(),
+			ExpectedErr: storage.ErrTooManySubscriptions,
+		},
+		{
+			// Subscribing to a payee does not use up anything the payee or
+			// its other subscribers need.
+			Name:  "PayeeWithManySubscribers",
+			Actor: payer,
+			Action: &CreateSubscription{
+				Payee:  payee,
+				Amount: 1,
+				Period: 1,
+			},
+			ActionID: subscriptionID,
+			State: func() state.Mutable {
+				store := chaintest.NewInMemoryStore()
+				for i := 0; i < 2*storage.MaxSubscriptionsPerAccount; i++ {
+					require.NoError(t, storage.AddSubscription(context.Background(), store, ids.GenerateTestID(), &storage.Subscription{
+						Payer:  codectest.NewRandomAddress(),
+						Payee:  payee,
+						Amount: 1,
+						Period: 1,
+					}))
+				}
+				return store
+			}(),
+			ExpectedOutputs: &CreateSubscriptionResult{
+				SubscriptionID: subscriptionID,
+			},
+		},
+		{
+			Name:  "CreateSubscription",
+			Actor: payer,
+			Action: &CreateSubscription{
+				Payee:     payee,
+				Amount:    10,
+				Period:    1_000,
+				MaxCycles: 2,
+			},
+			State:     chaintest.NewInMemoryStore(),
+			Timestamp: 5,
+			ActionID:  subscriptionID,
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				s, exists, err := storage.GetSubscription(ctx, store, payee, subscriptionID)
+				require.NoError(t, err)
+				require.True(t, exists)
+				require.Equal(t, &storage.Subscription{
+					Payer:        payer,
+					Payee:        payee,
+					Amount:       10,
+					Period:       1_000,
+					MaxCycles:    2,
+					NextChargeAt: 5,
+				}, s)
+
+				payerSubscriptions, err := storage.GetPayerSubscriptions(ctx, store, payer)
+				require.NoError(t, err)
+				require.Equal(t, []storage.SubscriptionRef{{Payee: payee, ID: subscriptionID}}, payerSubscriptions)
+			},
+			ExpectedOutputs: &CreateSubscriptionResult{
+				SubscriptionID: subscriptionID,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+// TestSubscriptionLifecycle charges a subscription until it runs out of cycles.
+func TestSubscriptionLifecycle(t *testing.T) {
+	payer := codectest.NewRandomAddress()
+	payee := codectest.NewRandomAddress()
+	subscriptionID := ids.GenerateTestID()
+
+	store := chaintest.NewInMemoryStore()
+	require.NoError(t, storage.SetBalance(context.Background(), store, payer, 100))
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:  "Create",
+			Actor: payer,
+			Action: &CreateSubscription{
+				Payee:     payee,
+				Amount:    10,
+				Period:    1_000,
+				MaxCycles: 2,
+			},
+			State:    store,
+			ActionID: subscriptionID,
+			ExpectedOutputs: &CreateSubscriptionResult{
+				SubscriptionID: subscriptionID,
+			},
+		},
+		{
+			Name:  "ChargeByPayer",
+			Actor: payer,
+			Action: &ChargeSubscription{
+				SubscriptionID: subscriptionID,
+				Payer:          payer,
+			},
+			State:       store,
+			ExpectedErr: ErrSubscriptionNotFound,
+		},
+		{
+			Name:  "ChargeWrongPayer",
+			Actor: payee,
+			Action: &ChargeSubscription{
+				SubscriptionID: subscriptionID,
+				Payer:          payee,
+			},
+			State:       store,
+			ExpectedErr: ErrPayerMismatch,
+		},
+		{
+			Name:  "FirstCharge",
+			Actor: payee,
+			Action: &ChargeSubscription{
+				SubscriptionID: subscriptionID,
+				Payer:          payer,
+			},
+			State: store,
+			ExpectedOutputs: &ChargeSubscriptionResult{
+				PayerBalance: 90,
+				PayeeBalance: 10,
+				Cycles:       1,
+				Amount:       10,
+			},
+		},
+		{
+			Name:  "ChargeTooEarly",
+			Actor: payee,
+			Action: &ChargeSubscription{
+				SubscriptionID: subscriptionID,
+				Payer:          payer,
+			},
+			State:       store,
+			Timestamp:   999,
+			ExpectedErr: ErrChargeTooEarly,
+		},
+		{
+			Name:  "LastCharge",
+			Actor: payee,
+			Action: &ChargeSubscription{
+				SubscriptionID: subscriptionID,
+				Payer:          payer,
+			},
+			State:     store,
+			Timestamp: 1_000,
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				_, exists, err := storage.GetSubscription(ctx, store, payee, subscriptionID)
+				require.NoError(t, err)
+				require.False(t, exists)
+				payerSubscriptions, err := storage.GetPayerSubscriptions(ctx, store, payer)
+				require.NoError(t, err)
+				require.Empty(t, payerSubscriptions)
+			},
+			ExpectedOutputs: &ChargeSubscriptionResult{
+				PayerBalance: 80,
+				PayeeBalance: 20,
+				Cycles:       2,
+				Amount:       10,
+			},
+		},
+		{
+			Name:  "ChargeEnded",
+			Actor: payee,
+			Action: &ChargeSubscription{
+				SubscriptionID: subscriptionID,
+				Payer:          payer,
+			},
+			State:       store,
+			Timestamp:   2_000,
+			ExpectedErr: ErrSubscriptionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+func TestCancelSubscriptionAction(t *testing.T) {
+	payer := codectest.NewRandomAddress()
+	payee := codectest.NewRandomAddress()
+	subscriptionID := ids.GenerateTestID()
+
+	store := chaintest.NewInMemoryStore()
+	require.NoError(t, storage.AddSubscription(context.Background(), store, subscriptionID, &storage.Subscription{
+		Payer:  payer,
+		Payee:  payee,
+		Amount: 1,
+		Period: 1,
+		Cycles: 3,
+	}))
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:  "NonExistentSubscription",
+			Actor: payer,
+			Action: &CancelSubscription{
+				SubscriptionID: ids.GenerateTestID(),
+				Payee:          payee,
+			},
+			State:       store,
+			ExpectedErr: ErrSubscriptionNotFound,
+		},
+		{
+			Name:  "CancelByPayee",
+			Actor: payee,
+			Action: &CancelSubscription{
+				SubscriptionID: subscriptionID,
+				Payee:          payee,
+			},
+			State:       store,
+			ExpectedErr: ErrNotPayer,
+		},
+		{
+			Name:  "Cancel",
+			Actor: payer,
+			Action: &CancelSubscription{
+				SubscriptionID: subscriptionID,
+				Payee:          payee,
+			},
+			State: store,
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				_, exists, err := storage.GetSubscription(ctx, store, payee, subscriptionID)
+				require.NoError(t, err)
+				require.False(t, exists)
+				payerSubscriptions, err := storage.GetPayerSubscriptions(ctx, store, payer)
+				require.NoError(t, err)
+				require.Empty(t, payerSubscriptions)
+			},
+			ExpectedOutputs: &CancelSubscriptionResult{
+				Cycles: 3,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
+)
+
+const SweepComputeUnits = 1
+
+var _ chain.Action = (*Sweep)(nil)
+
+// Sweep transfers the actor's entire remaining balance, after fees have been
+// paid, to [To]. The actor's balance is removed from state.
+type Sweep struct {
+	// To is the recipient of the swept balance.
+	To codec.Address `serialize:"true" json:"to"`
+}
+
+func (*Sweep) GetTypeID() uint8 {
+	return mconsts.SweepID
+}
+
+func (s *Sweep) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return state.Keys{
+		string(storage.BalanceKey(actor)):       state.Read | state.Write,
+		string(storage.BalanceKey(s.To)):        state.All,
+		string(storage.SpendingLimitKey(actor)): state.Read | state.Write,
+	}
+}
+
+func (s *Sweep) Execute(
+	ctx context.Context,
+	_ chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	value, err := storage.GetBalance(ctx, mu, actor)
+	if err != nil {
+		return nil, err
+	}
+	if value == 0 {
+		return nil, ErrOutputValueZero
+	}
+	if _, err := storage.SubBalance(ctx, mu, timestamp, actor, value); err != nil {
+		return nil, err
+	}
+	receiverBalance, err := storage.AddBalance(ctx, mu, s.To, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SweepResult{
+		Value:           value,
+		ReceiverBalance: receiverBalance,
+	}, nil
+}
+
+func (*Sweep) ComputeUnits(chain.Rules) uint64 {
+	return SweepComputeUnits
+}
+
+func (*Sweep) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+var _ codec.Typed = (*SweepResult)(nil)
+
+type SweepResult struct {
+	// Value is the amount swept to the recipient.
+	Value           uint64 `serialize:"true" json:"value"`
+	ReceiverBalance uint64 `serialize:"true" json:"receiver_balance"`
+}
+
+func (*SweepResult) GetTypeID() uint8 {
+	return mconsts.SweepID // Common practice is to use the action ID
+}
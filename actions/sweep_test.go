@@ -0,0 +1,100 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+func TestSweepAction(t *testing.T) {
+	addr := codectest.NewRandomAddress()
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:  "NonExistentAddress",
+			Actor: codec.EmptyAddress,
+			Action: &Sweep{
+				To: addr,
+			},
+			State:       chaintest.NewInMemoryStore(),
+			ExpectedErr: ErrOutputValueZero,
+		},
+		{
+			Name:  "SelfSweep",
+			Actor: codec.EmptyAddress,
+			Action: &Sweep{
+				To: codec.EmptyAddress,
+			},
+			State: func() state.Mutable {
+				store := chaintest.NewInMemoryStore()
+				require.NoError(t, storage.SetBalance(context.Background(), store, codec.EmptyAddress, 7))
+				return store
+			}(),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				balance, err := storage.GetBalance(ctx, store, codec.EmptyAddress)
+				require.NoError(t, err)
+				require.Equal(t, uint64(7), balance)
+			},
+			ExpectedOutputs: &SweepResult{
+				Value:           7,
+				ReceiverBalance: 7,
+			},
+		},
+		{
+			Name:  "OverLimit",
+			Actor: codec.EmptyAddress,
+			Action: &Sweep{
+				To: addr,
+			},
+			State: func() state.Mutable {
+				store := chaintest.NewInMemoryStore()
+				require.NoError(t, storage.SetBalance(context.Background(), store, codec.EmptyAddress, 7))
+				require.NoError(t, storage.SetSpendingLimit(context.Background(), store, codec.EmptyAddress, &storage.SpendingLimit{
+					Limit:  6,
+					Window: 1_000,
+				}))
+				return store
+			}(),
+			ExpectedErr: storage.ErrSpendingLimitExceeded,
+		},
+		{
+			Name:  "Sweep",
+			Actor: codec.EmptyAddress,
+			Action: &Sweep{
+				To: addr,
+			},
+			State: func() state.Mutable {
+				store := chaintest.NewInMemoryStore()
+				require.NoError(t, storage.SetBalance(context.Background(), store, codec.EmptyAddress, 7))
+				require.NoError(t, storage.SetBalance(context.Background(), store, addr, 3))
+				return store
+			}(),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				_, err := store.GetValue(ctx, storage.BalanceKey(codec.EmptyAddress))
+				require.ErrorIs(t, err, database.ErrNotFound)
+				balance, err := storage.GetBalance(ctx, store, addr)
+				require.NoError(t, err)
+				require.Equal(t, uint64(10), balance)
+			},
+			ExpectedOutputs: &SweepResult{
+				Value:           7,
+				ReceiverBalance: 10,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"errors"
 
 	"github.com/ava-labs/avalanchego/ids"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk-starter-kit/tracing"
 	"github.com/ava-labs/hypersdk/chain"
 	"github.com/ava-labs/hypersdk/codec"
 	"github.com/ava-labs/hypersdk/state"
@@ -45,8 +47,9 @@ func (*Transfer) GetTypeID() uint8 {
 
 func (t *Transfer) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
 	return state.Keys{
-		string(storage.BalanceKey(actor)): state.Read | state.Write,
-		string(storage.BalanceKey(t.To)):  state.All,
+		string(storage.BalanceKey(actor)):       state.Read | state.Write,
+		string(storage.BalanceKey(t.To)):        state.All,
+		string(storage.SpendingLimitKey(actor)): state.Read | state.Write,
 	}
 }
 
@@ -54,17 +57,28 @@ func (t *Transfer) Execute(
 	ctx context.Context,
 	_ chain.Rules,
 	mu state.Mutable,
-	_ int64,
+	timestamp int64,
 	actor codec.Address,
 	_ ids.ID,
 ) (codec.Typed, error) {
+	ctx, span := tracing.Start(ctx, "Transfer.Execute")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(
+			tracing.Address("actor", actor),
+			tracing.Address("to", t.To),
+			tracing.Amount("value", t.Value),
+			attribute.Int("memo_size", len(t.Memo)),
+		)
+	}
+
 	if t.Value == 0 {
 		return nil, ErrOutputValueZero
 	}
 	if len(t.Memo) > MaxMemoSize {
 		return nil, ErrOutputMemoTooLarge
 	}
-	senderBalance, err := storage.SubBalance(ctx, mu, actor, t.Value)
+	senderBalance, err := storage.SubBalance(ctx, mu, timestamp, actor, t.Value)
 	if err != nil {
 		return nil, err
 	}
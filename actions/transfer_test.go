@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk-starter-kit/tracing"
 	"github.com/ava-labs/hypersdk/chain/chaintest"
 	"github.com/ava-labs/hypersdk/codec"
 	"github.com/ava-labs/hypersdk/codec/codectest"
@@ -130,6 +131,44 @@ func TestTransferAction(t *testing.T) {
 }
 
 // TestMultiTransfer shows an example of reusing the same store for multiple sequential action invocations.
+func TestTransferTracing(t *testing.T) {
+	require := require.New(t)
+
+	exporter := tracing.NewTestExporter()
+	ctx := exporter.Context(context.Background())
+	from := codectest.NewRandomAddress()
+	to := codectest.NewRandomAddress()
+	store := chaintest.NewInMemoryStore()
+	require.NoError(storage.SetBalance(ctx, store, from, 10))
+	exporter.Reset()
+
+	transfer := &Transfer{To: to, Value: 4, Memo: []byte("hi")}
+	_, err := transfer.Execute(ctx, nil, store, 0, from, ids.Empty)
+	require.NoError(err)
+
+	// Spans end before their parents.
+	require.Equal([]string{
+		"storage.SubBalance",
+		"storage.AddBalance",
+		"Transfer.Execute",
+	}, exporter.Names())
+	spans := exporter.Spans()
+	require.Equal(spans[2].SpanContext.SpanID(), spans[0].Parent.SpanID())
+	require.Equal(spans[2].SpanContext.SpanID(), spans[1].Parent.SpanID())
+
+	attrs, ok := exporter.Attributes("Transfer.Execute")
+	require.True(ok)
+	require.Equal(from.String(), attrs["actor"].AsString())
+	require.Equal(to.String(), attrs["to"].AsString())
+	require.Equal(int64(4), attrs["value"].AsInt64())
+	require.Equal(int64(2), attrs["memo_size"].AsInt64())
+
+	attrs, ok = exporter.Attributes("storage.AddBalance")
+	require.True(ok)
+	require.Equal(to.String(), attrs["address"].AsString())
+	require.Equal(int64(4), attrs["amount"].AsInt64())
+}
+
 func TestMultiTransfer(t *testing.T) {
 	addrAlice := codectest.NewRandomAddress()
 	addrBob := codectest.NewRandomAddress()
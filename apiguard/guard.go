@@ -0,0 +1,442 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apiguard
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultMaxBatchSize = 32
+	DefaultMaxBodySize  = 4 * 1024 * 1024
+
+	// minTrackedIPs is the number of per-IP limiters kept before the
+	// limiters that have refilled are pruned.
+	minTrackedIPs = 1024
+)
+
+// JSON-RPC 2.0 error codes of the rejected calls.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeServerError    = -32000
+)
+
+var (
+	ErrUnknownMethod = errors.New("unknown method")
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrEmptyToken    = errors.New("empty auth token")
+)
+
+// Config controls access to the endpoints of a VM.
+type Config struct {
+	// Methods enables or disables methods by their full name, such as
+	// "morpheusvm.balance". Methods that are not listed are enabled. The
+	// endpoints that are not JSON-RPC are enabled by the method each of
+	// their requests counts as.
+	Methods map[string]bool `json:"methods"`
+
+	// IPRateLimit is the number of calls per second each IP can make, up to
+	// IPRateBurst at once. GlobalRateLimit and GlobalRateBurst limit the
+	// calls of every IP together. A limit of 0 disables the limiter. Every
+	// call of a batch counts.
+	IPRateLimit     float64 `json:"ipRateLimit"`
+	IPRateBurst     int     `json:"ipRateBurst"`
+	GlobalRateLimit float64 `json:"globalRateLimit"`
+	GlobalRateBurst int     `json:"globalRateBurst"`
+
+	// AuthTokens, if not empty, requires every request to carry one of them
+	// in an "Authorization: Bearer <token>" header.
+	AuthTokens []string `json:"authTokens"`
+
+	// MaxBatchSize is the number of calls a batch can hold. Batches are
+	// rejected if it is 0.
+	MaxBatchSize int `json:"maxBatchSize"`
+
+	// MaxBodySize is the size in bytes of the largest request body.
+	MaxBodySize int64 `json:"maxBodySize"`
+}
+
+func NewDefaultConfig() Config {
+	return Config{
+		MaxBatchSize: DefaultMaxBatchSize,
+		MaxBodySize:  DefaultMaxBodySize,
+	}
+}
+
+// Verify returns an error if [c] is invalid. [methods] are the names of the
+// methods served by the guarded endpoints.
+func (c Config) Verify(methods []string) error {
+	served := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		served[method] = true
+	}
+	for method := range c.Methods {
+		if !served[method] {
+			return fmt.Errorf("%w: %q", ErrUnknownMethod, method)
+		}
+	}
+	if err := verifyRate("ipRateLimit", "ipRateBurst", c.IPRateLimit, c.IPRateBurst, c.MaxBatchSize); err != nil {
+		return err
+	}
+	if err := verifyRate("globalRateLimit", "globalRateBurst", c.GlobalRateLimit, c.GlobalRateBurst, c.MaxBatchSize); err != nil {
+		return err
+	}
+	for i, token := range c.AuthTokens {
+		if token == "" {
+			return fmt.Errorf("%w: authTokens[%d]", ErrEmptyToken, i)
+		}
+	}
+	if c.MaxBatchSize < 0 {
+		return fmt.Errorf("%w: maxBatchSize %d is negative", ErrInvalidLimit, c.MaxBatchSize)
+	}
+	if c.MaxBodySize <= 0 {
+		return fmt.Errorf("%w: maxBodySize %d is not positive", ErrInvalidLimit, c.MaxBodySize)
+	}
+	return nil
+}
+
+func verifyRate(limitName, burstName string, limit float64, burst int, maxBatchSize int) error {
+	switch {
+	case math.IsNaN(limit) || math.IsInf(limit, 0) || limit < 0:
+		return fmt.Errorf("%w: %s %v is not a non-negative number", ErrInvalidLimit, limitName, limit)
+	case limit == 0:
+		return nil
+	case burst < 1:
+		return fmt.Errorf("%w: %s must be positive when %s is set", ErrInvalidLimit, burstName, limitName)
+	case burst < maxBatchSize:
+		// A batch takes one token per call, so a larger batch could never
+		// be served.
+		return fmt.Errorf("%w: %s %d is less than maxBatchSize %d", ErrInvalidLimit, burstName, burst, maxBatchSize)
+	default:
+		return nil
+	}
+}
+
+// Guard serves the requests to the handlers it wraps that [Config] allows.
+// The rate limits are shared by every handler.
+type Guard struct {
+	config  Config
+	observe func(method string, d time.Duration)
+	tokens  [][]byte
+
+	// global and ips are nil if their limit is disabled.
+	global *rate.Limiter
+	ips    *ipLimiters
+}
+
+// New returns a guard enforcing [config], which must be verified. If it is
+// not nil, [observe] is called with the method and duration of every call
+// served by a guarded handler.
+func New(config Config, observe func(method string, d time.Duration)) *Guard {
+	g := &Guard{
+		config:  config,
+		observe: observe,
+		tokens:  make([][]byte, len(config.AuthTokens)),
+	}
+	for i, token := range config.AuthTokens {
+		g.tokens[i] = []byte(token)
+	}
+	if config.GlobalRateLimit > 0 {
+		g.global = rate.NewLimiter(rate.Limit(config.GlobalRateLimit), config.GlobalRateBurst)
+	}
+	if config.IPRateLimit > 0 {
+		g.ips = &ipLimiters{
+			limit:    rate.Limit(config.IPRateLimit),
+			burst:    config.IPRateBurst,
+			limiters: make(map[string]*rate.Limiter),
+			pruneAt:  minTrackedIPs,
+		}
+	}
+	return g
+}
+
+// call is the part of a JSON-RPC call the guard reads.
+type call struct {
+	Method string          `json:"method"`
+	ID     json.RawMessage `json:"id"`
+}
+
+// JSONRPC returns a handler passing the JSON-RPC calls allowed by the guard
+// to [next]. Every call of a batch counts.
+func (g *Guard) JSONRPC(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.serveJSONRPC(w, r, next)
+	})
+}
+
+// Method returns a handler passing the requests allowed by the guard to
+// [next]. Every request counts as one call to [method]; for a WebSocket,
+// the duration observed is the one of the connection.
+func (g *Guard) Method(method string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case !g.authorized(r):
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeHTTPError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		case !g.allow(r, 1):
+			writeHTTPError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		case !g.enabled(method):
+			writeHTTPError(w, http.StatusForbidden, "method "+method+" is disabled")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, g.config.MaxBodySize)
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		if g.observe != nil {
+			g.observe(method, time.Since(start))
+		}
+	})
+}
+
+func (g *Guard) serveJSONRPC(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if !g.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, nil, CodeServerError, "unauthorized")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, g.config.MaxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, nil, CodeInvalidRequest,
+				"request body exceeds "+strconv.FormatInt(g.config.MaxBodySize, 10)+" bytes")
+			return
+		}
+		writeError(w, http.StatusBadRequest, nil, CodeParseError, err.Error())
+		return
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		var c call
+		if err := json.Unmarshal(trimmed, &c); err != nil {
+			writeError(w, http.StatusBadRequest, nil, CodeParseError, err.Error())
+			return
+		}
+		if !g.allow(r, 1) {
+			writeError(w, http.StatusTooManyRequests, c.ID, CodeServerError, "rate limit exceeded")
+			return
+		}
+		if !g.enabled(c.Method) {
+			writeError(w, http.StatusForbidden, c.ID, CodeMethodNotFound, "method "+c.Method+" is disabled")
+			return
+		}
+		g.serve(w, r, next, c.Method, body)
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(trimmed, &batch); err != nil {
+		writeError(w, http.StatusBadRequest, nil, CodeParseError, err.Error())
+		return
+	}
+	switch {
+	case len(batch) == 0:
+		writeError(w, http.StatusBadRequest, nil, CodeInvalidRequest, "empty batch")
+		return
+	case len(batch) > g.config.MaxBatchSize:
+		writeError(w, http.StatusBadRequest, nil, CodeInvalidRequest,
+			"batch of "+strconv.Itoa(len(batch))+" calls exceeds "+strconv.Itoa(g.config.MaxBatchSize))
+		return
+	}
+	if !g.allow(r, len(batch)) {
+		writeError(w, http.StatusTooManyRequests, nil, CodeServerError, "rate limit exceeded")
+		return
+	}
+	g.serveBatch(w, r, next, batch)
+}
+
+// authorized returns true if [r] carries one of the tokens, or if no token
+// is required.
+func (g *Guard) authorized(r *http.Request) bool {
+	if len(g.tokens) == 0 {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	authorized := 0
+	for _, t := range g.tokens {
+		// Compare against every token so that the time taken does not
+		// reveal which one matched.
+		authorized |= subtle.ConstantTimeCompare([]byte(token), t)
+	}
+	return authorized == 1
+}
+
+// allow takes [n] calls from the limiters of [r], and returns false if any
+// of them is exhausted.
+func (g *Guard) allow(r *http.Request, n int) bool {
+	now := time.Now()
+	if g.ips != nil && !g.ips.allow(remoteIP(r), now, n) {
+		return false
+	}
+	return g.global == nil || g.global.AllowN(now, n)
+}
+
+func (g *Guard) enabled(method string) bool {
+	enabled, ok := g.config.Methods[method]
+	return !ok || enabled
+}
+
+// serve passes [r] with [body], a call to [method], to [next].
+func (g *Guard) serve(w http.ResponseWriter, r *http.Request, next http.Handler, method string, body []byte) {
+	r = r.Clone(r.Context())
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	start := time.Now()
+	next.ServeHTTP(w, r)
+	if g.observe != nil {
+		g.observe(method, time.Since(start))
+	}
+}
+
+// serveBatch passes every call of [batch] to [next] on its own, and writes
+// their responses as a batch.
+func (g *Guard) serveBatch(w http.ResponseWriter, r *http.Request, next http.Handler, batch []json.RawMessage) {
+	responses := make([]json.RawMessage, 0, len(batch))
+	for _, raw := range batch {
+		var c call
+		if err := json.Unmarshal(raw, &c); err != nil {
+			responses = append(responses, errorResponse(nil, CodeInvalidRequest, err.Error()))
+			continue
+		}
+		if !g.enabled(c.Method) {
+			responses = append(responses, errorResponse(c.ID, CodeMethodNotFound, "method "+c.Method+" is disabled"))
+			continue
+		}
+		rec := &recorder{header: make(http.Header)}
+		g.serve(rec, r, next, c.Method, raw)
+		if response := bytes.TrimSpace(rec.body.Bytes()); json.Valid(response) {
+			responses = append(responses, response)
+		} else {
+			responses = append(responses, errorResponse(c.ID, CodeServerError, string(response)))
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(responses)
+}
+
+// recorder buffers the response to a call of a batch.
+type recorder struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (*recorder) WriteHeader(int) {}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonRPCResponse struct {
+	Version string          `json:"jsonrpc"`
+	Error   *jsonRPCError   `json:"error"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func errorResponse(id json.RawMessage, code int, message string) json.RawMessage {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	b, _ := json.Marshal(&jsonRPCResponse{
+		Version: "2.0",
+		Error:   &jsonRPCError{Code: code, Message: message},
+		ID:      id,
+	})
+	return b
+}
+
+func writeError(w http.ResponseWriter, status int, id json.RawMessage, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(errorResponse(id, code, message))
+}
+
+type httpError struct {
+	Error string `json:"error"`
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&httpError{Error: message})
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ipLimiters holds a limiter per IP.
+type ipLimiters struct {
+	limit rate.Limit
+	burst int
+
+	lock     sync.Mutex
+	limiters map[string]*rate.Limiter
+
+	// pruneAt is the number of limiters above which the next new IP prunes
+	// them.
+	pruneAt int
+}
+
+func (l *ipLimiters) allow(ip string, now time.Time, n int) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	limiter, ok := l.limiters[ip]
+	if !ok {
+		if len(l.limiters) >= l.pruneAt {
+			l.prune(now)
+		}
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[ip] = limiter
+	}
+	return limiter.AllowN(now, n)
+}
+
+// prune drops the limiters that have refilled, as they are equivalent to
+// new ones.
+func (l *ipLimiters) prune(now time.Time) {
+	for ip, limiter := range l.limiters {
+		if limiter.TokensAt(now) >= float64(l.burst) {
+			delete(l.limiters, ip)
+		}
+	}
+	l.pruneAt = max(minTrackedIPs, 2*len(l.limiters))
+}
@@ -0,0 +1,252 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apiguard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// echo replies to every call with its method.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	var c call
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "result": c.Method, "id": c.ID})
+})
+
+func do(h http.Handler, ip string, token string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.RemoteAddr = ip + ":1234"
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestVerify(t *testing.T) {
+	methods := []string{"vm.balance", "vm.submit"}
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		err    error
+	}{
+		{
+			name:   "default",
+			modify: func(*Config) {},
+		},
+		{
+			name:   "known method",
+			modify: func(c *Config) { c.Methods = map[string]bool{"vm.submit": false} },
+		},
+		{
+			name:   "unknown method",
+			modify: func(c *Config) { c.Methods = map[string]bool{"vm.mint": false} },
+			err:    ErrUnknownMethod,
+		},
+		{
+			name:   "negative rate",
+			modify: func(c *Config) { c.IPRateLimit = -1 },
+			err:    ErrInvalidLimit,
+		},
+		{
+			name:   "missing burst",
+			modify: func(c *Config) { c.GlobalRateLimit = 10 },
+			err:    ErrInvalidLimit,
+		},
+		{
+			name: "burst below batch size",
+			modify: func(c *Config) {
+				c.IPRateLimit = 10
+				c.IPRateBurst = DefaultMaxBatchSize - 1
+			},
+			err: ErrInvalidLimit,
+		},
+		{
+			name:   "empty token",
+			modify: func(c *Config) { c.AuthTokens = []string{"a", ""} },
+			err:    ErrEmptyToken,
+		},
+		{
+			name:   "no body",
+			modify: func(c *Config) { c.MaxBodySize = 0 },
+			err:    ErrInvalidLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewDefaultConfig()
+			tt.modify(&c)
+			require.ErrorIs(t, c.Verify(methods), tt.err)
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	require := require.New(t)
+
+	config := NewDefaultConfig()
+	config.Methods = map[string]bool{"vm.submit": false, "vm.balance": true}
+	config.AuthTokens = []string{"secret", "other"}
+	config.IPRateLimit = 0.001
+	config.IPRateBurst = 4
+	config.MaxBatchSize = 3
+	config.MaxBodySize = 256
+	observed := []string{}
+	g := New(config, func(method string, _ time.Duration) {
+		observed = append(observed, method)
+	}).JSONRPC(echo)
+
+	// Tokens
+	w := do(g, "10.0.0.1", "", `{"jsonrpc":"2.0","method":"vm.balance","id":1}`)
+	require.Equal(http.StatusUnauthorized, w.Code)
+	require.Equal("Bearer", w.Header().Get("WWW-Authenticate"))
+	w = do(g, "10.0.0.1", "wrong", `{"jsonrpc":"2.0","method":"vm.balance","id":1}`)
+	require.Equal(http.StatusUnauthorized, w.Code)
+
+	// Methods
+	w = do(g, "10.0.0.1", "secret", `{"jsonrpc":"2.0","method":"vm.balance","id":1}`)
+	require.Equal(http.StatusOK, w.Code)
+	require.JSONEq(`{"jsonrpc":"2.0","result":"vm.balance","id":1}`, w.Body.String())
+	w = do(g, "10.0.0.1", "other", `{"jsonrpc":"2.0","method":"vm.submit","id":2}`)
+	require.Equal(http.StatusForbidden, w.Code)
+	require.JSONEq(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"method vm.submit is disabled"},"id":2}`, w.Body.String())
+
+	// Batches
+	w = do(g, "10.0.0.2", "secret", `[{"jsonrpc":"2.0","method":"vm.balance","id":1},{"jsonrpc":"2.0","method":"vm.submit","id":2}]`)
+	require.Equal(http.StatusOK, w.Code)
+	require.JSONEq(`[
+		{"jsonrpc":"2.0","result":"vm.balance","id":1},
+		{"jsonrpc":"2.0","error":{"code":-32601,"message":"method vm.submit is disabled"},"id":2}
+	]`, w.Body.String())
+	w = do(g, "10.0.0.3", "secret", `[{"id":1},{"id":2},{"id":3},{"id":4}]`)
+	require.Equal(http.StatusBadRequest, w.Code)
+	w = do(g, "10.0.0.3", "secret", `[]`)
+	require.Equal(http.StatusBadRequest, w.Code)
+
+	// Body size
+	w = do(g, "10.0.0.3", "secret", `{"method":"`+strings.Repeat("a", 256)+`"}`)
+	require.Equal(http.StatusRequestEntityTooLarge, w.Code)
+
+	// Rate limits: 10.0.0.2 used 2 of its 4 calls.
+	w = do(g, "10.0.0.2", "secret", `[{"method":"vm.balance","id":1},{"method":"vm.balance","id":2},{"method":"vm.balance","id":3}]`)
+	require.Equal(http.StatusTooManyRequests, w.Code)
+	w = do(g, "10.0.0.2", "secret", `[{"method":"vm.balance","id":1},{"method":"vm.balance","id":2}]`)
+	require.Equal(http.StatusOK, w.Code)
+	w = do(g, "10.0.0.2", "secret", `{"method":"vm.balance","id":1}`)
+	require.Equal(http.StatusTooManyRequests, w.Code)
+	w = do(g, "10.0.0.4", "secret", `{"method":"vm.balance","id":1}`)
+	require.Equal(http.StatusOK, w.Code)
+
+	// Only the calls that were served are observed.
+	require.Equal([]string{"vm.balance", "vm.balance", "vm.balance", "vm.balance", "vm.balance"}, observed)
+}
+
+func TestMethod(t *testing.T) {
+	require := require.New(t)
+
+	config := NewDefaultConfig()
+	config.Methods = map[string]bool{"vm.graphql": false}
+	config.AuthTokens = []string{"secret"}
+	config.IPRateLimit = 0.001
+	config.IPRateBurst = 4
+	config.MaxBatchSize = 2
+	config.MaxBodySize = 32
+	observed := []string{}
+	g := New(config, func(method string, _ time.Duration) {
+		observed = append(observed, method)
+	})
+	body := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	})
+	balance := g.Method("vm.balance", body)
+
+	w := do(balance, "10.0.0.1", "", "")
+	require.Equal(http.StatusUnauthorized, w.Code)
+	require.JSONEq(`{"error":"unauthorized"}`, w.Body.String())
+	w = do(balance, "10.0.0.1", "secret", strings.Repeat("a", 32))
+	require.Equal(http.StatusOK, w.Code)
+	w = do(balance, "10.0.0.1", "secret", strings.Repeat("a", 33))
+	require.Equal(http.StatusRequestEntityTooLarge, w.Code)
+	w = do(g.Method("vm.graphql", body), "10.0.0.1", "secret", "")
+	require.Equal(http.StatusForbidden, w.Code)
+	require.JSONEq(`{"error":"method vm.graphql is disabled"}`, w.Body.String())
+
+	// The limits are shared with the JSON-RPC handler: 10.0.0.1 used 3 of
+	// its 4 calls.
+	w = do(g.JSONRPC(echo), "10.0.0.1", "secret", `{"method":"vm.balance","id":1}`)
+	require.Equal(http.StatusOK, w.Code)
+	w = do(balance, "10.0.0.1", "secret", "")
+	require.Equal(http.StatusTooManyRequests, w.Code)
+	require.JSONEq(`{"error":"rate limit exceeded"}`, w.Body.String())
+
+	require.Equal([]string{"vm.balance", "vm.balance", "vm.balance"}, observed)
+}
+
+func TestGlobalRateLimit(t *testing.T) {
+	require := require.New(t)
+
+	config := NewDefaultConfig()
+	config.GlobalRateLimit = 0.001
+	config.GlobalRateBurst = 2
+	config.MaxBatchSize = 2
+	g := New(config, nil).JSONRPC(echo)
+
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		w := do(g, ip, "", `{"method":"vm.balance","id":1}`)
+		if i < 2 {
+			require.Equal(http.StatusOK, w.Code)
+			continue
+		}
+		require.Equal(http.StatusTooManyRequests, w.Code)
+		body, err := io.ReadAll(w.Body)
+		require.NoError(err)
+		require.Contains(string(body), "rate limit exceeded")
+	}
+}
+
+func TestPruneIPLimiters(t *testing.T) {
+	require := require.New(t)
+
+	config := NewDefaultConfig()
+	config.IPRateLimit = 1
+	config.IPRateBurst = DefaultMaxBatchSize
+	l := New(config, nil).ips
+
+	now := time.Now()
+	for i := 0; i < minTrackedIPs; i++ {
+		require.True(l.allow(fmt.Sprintf("10.0.%d.%d", i/256, i%256), now, DefaultMaxBatchSize))
+	}
+	require.Len(l.limiters, minTrackedIPs)
+
+	// Every limiter is empty, so none is pruned.
+	require.True(l.allow("10.1.0.0", now, 1))
+	require.Len(l.limiters, minTrackedIPs+1)
+	require.Equal(2*minTrackedIPs, l.pruneAt)
+
+	// Once they refill, the first limiters are dropped, but not the ones
+	// used since.
+	later := now.Add(DefaultMaxBatchSize * time.Second)
+	for i := 0; len(l.limiters) < l.pruneAt; i++ {
+		require.True(l.allow(fmt.Sprintf("10.2.%d.%d", i/256, i%256), later, 1))
+	}
+	used := len(l.limiters) - (minTrackedIPs + 1)
+	require.True(l.allow("10.3.0.0", later, 1))
+	require.Len(l.limiters, used+1)
+	require.Equal(2*used, l.pruneAt)
+}
@@ -0,0 +1,488 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package archive
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/pebbledb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/maybe"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/event"
+)
+
+const (
+	// Namespace is the directory, under the VM's data directory, that holds
+	// the archive.
+	Namespace = "archive"
+
+	recordPrefix byte = 0x0 // address + inverted height -> balance
+	heightPrefix byte = 0x1 // height + address -> height of the previous record
+	metaPrefix   byte = 0x2 // first height, last height, state root of the last height
+	gapsPrefix   byte = 0x3 // first and last height of every range of heights not archived
+
+	metaLen = 2*consts.Uint64Len + ids.IDLen
+
+	// Balances are read from state in pages of this many keys.
+	proofLength = 1024
+)
+
+var (
+	ErrHeightPruned      = errors.New("height has been pruned")
+	ErrHeightNotArchived = errors.New("height has not been archived")
+)
+
+var _ event.Subscription[*chain.ExecutedBlock] = (*Archive)(nil)
+
+// State is the VM state the archive reads balance changes from.
+type State interface {
+	GetRangeProofAtRoot(
+		ctx context.Context,
+		rootID ids.ID,
+		start maybe.Maybe[[]byte],
+		end maybe.Maybe[[]byte],
+		maxLength int,
+	) (*merkledb.RangeProof, error)
+	GetChangeProof(
+		ctx context.Context,
+		startRootID ids.ID,
+		endRootID ids.ID,
+		start maybe.Maybe[[]byte],
+		end maybe.Maybe[[]byte],
+		maxLength int,
+	) (*merkledb.ChangeProof, error)
+}
+
+// Archive records the balances changed by every accepted block, so balances
+// can be queried as of a past height.
+//
+// A block only carries the state root of its parent, so the archive trails
+// the last accepted block by one height. It reads the changes between two
+// roots from the state history, which the VM only keeps for recent roots. If
+// the archive falls further behind, or blocks are skipped, it resumes from a
+// snapshot of the next block. The heights archived so far are kept, and the
+// heights that could not be archived are recorded as a gap.
+type Archive struct {
+	log       logging.Logger
+	db        database.Database
+	state     func() (State, error)
+	retention uint64
+
+	l        sync.RWMutex
+	started  bool
+	first    uint64
+	last     uint64
+	lastRoot ids.ID
+	gaps     []gap
+}
+
+// gap is a range of heights that were not archived.
+type gap struct {
+	first uint64
+	last  uint64
+}
+
+// New opens the archive stored at [path]. [state] returns the VM state, or
+// an error if it is not available yet. Heights more than [retention] below
+// the last archived height are pruned. A retention of 0 keeps every height.
+func New(log logging.Logger, path string, state func() (State, error), retention uint64) (*Archive, error) {
+	db, err := pebbledb.New(path, nil, logging.NoLog{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newArchive(log, db, state, retention)
+}
+
+func newArchive(log logging.Logger, db database.Database, state func() (State, error), retention uint64) (*Archive, error) {
+	a := &Archive{
+		log:       log,
+		db:        db,
+		state:     state,
+		retention: retention,
+	}
+	b, err := db.Get([]byte{metaPrefix})
+	if errors.Is(err, database.ErrNotFound) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := codec.NewReader(b, metaLen)
+	a.first = p.UnpackUint64(false)
+	a.last = p.UnpackUint64(false)
+	p.UnpackID(true, &a.lastRoot)
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+
+	b, err = db.Get([]byte{gapsPrefix})
+	switch {
+	case err == nil:
+		p = codec.NewReader(b, len(b))
+		for i := 0; i < len(b)/(2*consts.Uint64Len); i++ {
+			a.gaps = append(a.gaps, gap{
+				first: p.UnpackUint64(false),
+				last:  p.UnpackUint64(false),
+			})
+		}
+		if err := p.Err(); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, database.ErrNotFound):
+		return nil, err
+	}
+	a.started = true
+	return a, nil
+}
+
+// Heights returns the first and last heights that can be queried. It
+// returns false if nothing has been archived yet.
+func (a *Archive) Heights() (uint64, uint64, bool) {
+	a.l.RLock()
+	defer a.l.RUnlock()
+
+	return a.first, a.last, a.started
+}
+
+// Balance returns the balance of [addr] after the block at [height] was
+// accepted.
+func (a *Archive) Balance(addr codec.Address, height uint64) (uint64, error) {
+	a.l.RLock()
+	defer a.l.RUnlock()
+
+	switch {
+	case !a.started || height > a.last:
+		return 0, ErrHeightNotArchived
+	case height < a.first:
+		return 0, ErrHeightPruned
+	}
+	for _, g := range a.gaps {
+		if height >= g.first && height <= g.last {
+			return 0, fmt.Errorf("%w: heights %d to %d were skipped", ErrHeightNotArchived, g.first, g.last)
+		}
+	}
+
+	prefix := addressPrefix(addr)
+	it := a.db.NewIteratorWithStartAndPrefix(recordKey(addr, height), prefix)
+	defer it.Release()
+
+	if !it.Next() {
+		return 0, it.Error()
+	}
+	return database.ParseUInt64(it.Value())
+}
+
+func (a *Archive) Accept(blk *chain.ExecutedBlock) error {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	s, err := a.state()
+	if err != nil {
+		return err
+	}
+
+	// [blk] carries the root of the state after its parent.
+	ctx := context.Background()
+	height := blk.Block.Hght - 1
+	root := blk.Block.StateRoot
+	switch {
+	case !a.started:
+		err = a.snapshot(ctx, s, height, root)
+	case height <= a.last:
+		// Blocks are delivered at least once.
+		return nil
+	case height == a.last+1:
+		err = a.update(ctx, s, height, root)
+		if errors.Is(err, merkledb.ErrInsufficientHistory) {
+			// The root of [a.last] is no longer in the state history, so the
+			// changes since then cannot be read.
+			a.log.Warn("archive fell behind the state history",
+				zap.Uint64("lastHeight", a.last),
+				zap.Uint64("height", height),
+			)
+			err = a.resume(ctx, s, height, root)
+		}
+	default:
+		// Blocks that were not processed, such as the ones skipped by state
+		// sync, are not delivered.
+		a.log.Warn("archive skipped heights",
+			zap.Uint64("firstSkipped", a.last+1),
+			zap.Uint64("lastSkipped", height-1),
+		)
+		err = a.resume(ctx, s, height, root)
+	}
+	if errors.Is(err, merkledb.ErrInsufficientHistory) {
+		// [root] left the state history before [blk] was delivered. The
+		// archive resumes from a later block.
+		a.log.Warn("archive could not read the state at height",
+			zap.Uint64("height", height),
+			zap.Stringer("root", root),
+		)
+		return nil
+	}
+	return err
+}
+
+func (a *Archive) Close() error {
+	return a.db.Close()
+}
+
+// snapshot starts the archive at [height] with every balance in the state
+// at [root].
+func (a *Archive) snapshot(ctx context.Context, s State, height uint64, root ids.ID) error {
+	start, end := balanceRange()
+	batch := a.db.NewBatch()
+	for {
+		proof, err := s.GetRangeProofAtRoot(ctx, root, start, end, proofLength)
+		if err != nil {
+			return err
+		}
+		for _, kv := range proof.KeyValues {
+			addr, ok := storage.ParseBalanceKey(kv.Key)
+			if !ok {
+				continue
+			}
+			if err := batch.Put(recordKey(addr, height), kv.Value); err != nil {
+				return err
+			}
+		}
+		if len(proof.KeyValues) < proofLength {
+			break
+		}
+		start = maybe.Some(append(proof.KeyValues[len(proof.KeyValues)-1].Key, 0))
+	}
+	return a.commit(batch, height, height, root, nil)
+}
+
+// resume archives [height] from a snapshot of the state at [root] when the
+// changes since [a.last] cannot be read. The heights in between are recorded
+// as a gap.
+func (a *Archive) resume(ctx context.Context, s State, height uint64, root ids.ID) error {
+	start, end := balanceRange()
+	batch := a.db.NewBatch()
+	present := set.Set[codec.Address]{}
+	for {
+		proof, err := s.GetRangeProofAtRoot(ctx, root, start, end, proofLength)
+		if err != nil {
+			return err
+		}
+		for _, kv := range proof.KeyValues {
+			addr, ok := storage.ParseBalanceKey(kv.Key)
+			if !ok {
+				continue
+			}
+			present.Add(addr)
+			if err := a.put(batch, addr, height, kv.Value); err != nil {
+				return err
+			}
+		}
+		if len(proof.KeyValues) < proofLength {
+			break
+		}
+		start = maybe.Some(append(proof.KeyValues[len(proof.KeyValues)-1].Key, 0))
+	}
+
+	// The balances removed since [a.last] are recorded as zero.
+	it := a.db.NewIteratorWithPrefix([]byte{recordPrefix})
+	defer it.Release()
+
+	var last codec.Address
+	for it.Next() {
+		var addr codec.Address
+		copy(addr[:], it.Key()[1:])
+		if addr == last {
+			// Only the newest record of an address is checked.
+			continue
+		}
+		last = addr
+		if present.Contains(addr) {
+			continue
+		}
+		balance, err := database.ParseUInt64(it.Value())
+		if err != nil {
+			return err
+		}
+		if balance == 0 {
+			continue
+		}
+		if err := a.put(batch, addr, height, nil); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	gaps := a.gaps
+	if height > a.last+1 {
+		gaps = append(gaps, gap{first: a.last + 1, last: height - 1})
+	}
+	return a.commit(batch, a.first, height, root, gaps)
+}
+
+// update records the balances changed between [a.lastRoot] and [root] at
+// [height].
+func (a *Archive) update(ctx context.Context, s State, height uint64, root ids.ID) error {
+	start, end := balanceRange()
+	batch := a.db.NewBatch()
+	for root != a.lastRoot {
+		proof, err := s.GetChangeProof(ctx, a.lastRoot, root, start, end, proofLength)
+		if err != nil {
+			return err
+		}
+		for _, change := range proof.KeyChanges {
+			addr, ok := storage.ParseBalanceKey(change.Key)
+			if !ok {
+				continue
+			}
+			if err := a.put(batch, addr, height, change.Value.Value()); err != nil {
+				return err
+			}
+		}
+		if len(proof.KeyChanges) < proofLength {
+			break
+		}
+		start = maybe.Some(append(proof.KeyChanges[len(proof.KeyChanges)-1].Key, 0))
+	}
+
+	first := a.first
+	gaps := a.gaps
+	if a.retention > 0 && height-first > a.retention {
+		first = height - a.retention
+		if err := a.prune(batch, first); err != nil {
+			return err
+		}
+		gaps = []gap{}
+		for _, g := range a.gaps {
+			if g.last >= first {
+				gaps = append(gaps, g)
+			}
+		}
+	}
+	return a.commit(batch, first, height, root, gaps)
+}
+
+// put records [balance] as the balance of [addr] at [height]. A nil
+// [balance] means the balance was removed.
+func (a *Archive) put(batch database.Batch, addr codec.Address, height uint64, balance []byte) error {
+	prev := []byte{}
+	it := a.db.NewIteratorWithPrefix(addressPrefix(addr))
+	if it.Next() {
+		prev = binary.BigEndian.AppendUint64(prev, recordHeight(it.Key()))
+	}
+	err := it.Error()
+	it.Release()
+	if err != nil {
+		return err
+	}
+
+	if balance == nil {
+		balance = database.PackUInt64(0)
+	}
+	if err := batch.Put(recordKey(addr, height), balance); err != nil {
+		return err
+	}
+	return batch.Put(heightKey(height, addr), prev)
+}
+
+// prune removes the records that are not needed to serve heights starting
+// at [first]. For every address, the newest record at or below [first] is
+// kept.
+func (a *Archive) prune(batch database.Batch, first uint64) error {
+	it := a.db.NewIteratorWithPrefix([]byte{heightPrefix})
+	defer it.Release()
+
+	for it.Next() {
+		k := it.Key()
+		height := binary.BigEndian.Uint64(k[1:])
+		if height > first {
+			break
+		}
+		// The record at [height] supersedes the previous record of the
+		// address, which can no longer be queried.
+		if prev := it.Value(); len(prev) > 0 {
+			var addr codec.Address
+			copy(addr[:], k[1+consts.Uint64Len:])
+			if err := batch.Delete(recordKey(addr, binary.BigEndian.Uint64(prev))); err != nil {
+				return err
+			}
+		}
+		if err := batch.Delete(k); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func (a *Archive) commit(batch database.Batch, first uint64, last uint64, root ids.ID, gaps []gap) error {
+	p := codec.NewWriter(metaLen, metaLen)
+	p.PackUint64(first)
+	p.PackUint64(last)
+	p.PackID(root)
+	if err := batch.Put([]byte{metaPrefix}, p.Bytes()); err != nil {
+		return err
+	}
+	gapsLen := len(gaps) * 2 * consts.Uint64Len
+	p = codec.NewWriter(gapsLen, gapsLen)
+	for _, g := range gaps {
+		p.PackUint64(g.first)
+		p.PackUint64(g.last)
+	}
+	if err := batch.Put([]byte{gapsPrefix}, p.Bytes()); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	a.started = true
+	a.first = first
+	a.last = last
+	a.lastRoot = root
+	a.gaps = gaps
+	return nil
+}
+
+// balanceRange returns the range of the balance keys in state.
+func balanceRange() (maybe.Maybe[[]byte], maybe.Maybe[[]byte]) {
+	prefix := storage.BalancePrefix()
+	return maybe.Some([]byte{prefix}), maybe.Some([]byte{prefix + 1})
+}
+
+func addressPrefix(addr codec.Address) []byte {
+	k := make([]byte, 0, 1+codec.AddressLen+consts.Uint64Len)
+	k = append(k, recordPrefix)
+	return append(k, addr[:]...)
+}
+
+// recordKey inverts [height] so that the first record of an address at or
+// after this key is its newest record at or below [height].
+func recordKey(addr codec.Address, height uint64) []byte {
+	return binary.BigEndian.AppendUint64(addressPrefix(addr), math.MaxUint64-height)
+}
+
+func recordHeight(k []byte) uint64 {
+	return math.MaxUint64 - binary.BigEndian.Uint64(k[1+codec.AddressLen:])
+}
+
+func heightKey(height uint64, addr codec.Address) []byte {
+	k := make([]byte, 0, 1+consts.Uint64Len+codec.AddressLen)
+	k = append(k, heightPrefix)
+	k = binary.BigEndian.AppendUint64(k, height)
+	return append(k, addr[:]...)
+}
@@ -0,0 +1,193 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package archive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+)
+
+type testChain struct {
+	t     *testing.T
+	state merkledb.MerkleDB
+	roots []ids.ID // state root after every height
+}
+
+func newTestChain(t *testing.T, balances map[codec.Address]uint64) *testChain {
+	state, err := merkledb.New(context.Background(), memdb.New(), merkledb.Config{
+		BranchFactor:                merkledb.BranchFactor16,
+		HistoryLength:               16,
+		ValueNodeCacheSize:          units.MiB,
+		IntermediateNodeCacheSize:   units.MiB,
+		IntermediateWriteBufferSize: units.MiB,
+		IntermediateWriteBatchSize:  units.KiB,
+		TraceLevel:                  merkledb.NoTrace,
+	})
+	require.NoError(t, err)
+	c := &testChain{t: t, state: state}
+	c.commit(balances)
+	return c
+}
+
+// commit applies the balance changes of the next height. A zero balance is
+// removed.
+func (c *testChain) commit(balances map[codec.Address]uint64) {
+	ctx := context.Background()
+	ops := []database.BatchOp{{Key: []byte("height"), Value: database.PackUInt64(uint64(len(c.roots)))}}
+	for addr, balance := range balances {
+		ops = append(ops, database.BatchOp{
+			Key:    storage.BalanceKey(addr),
+			Value:  database.PackUInt64(balance),
+			Delete: balance == 0,
+		})
+	}
+	view, err := c.state.NewView(ctx, merkledb.ViewChanges{BatchOps: ops})
+	require.NoError(c.t, err)
+	require.NoError(c.t, view.CommitToDB(ctx))
+	root, err := c.state.GetMerkleRoot(ctx)
+	require.NoError(c.t, err)
+	c.roots = append(c.roots, root)
+}
+
+// block returns the block accepted after [height], which carries the root of
+// the state at [height].
+func (c *testChain) block(height uint64) *chain.ExecutedBlock {
+	return &chain.ExecutedBlock{
+		Block: &chain.StatelessBlock{
+			Hght:      height + 1,
+			StateRoot: c.roots[height],
+		},
+	}
+}
+
+func TestArchive(t *testing.T) {
+	require := require.New(t)
+
+	a := codectest.NewRandomAddress()
+	b := codectest.NewRandomAddress()
+	c := newTestChain(t, map[codec.Address]uint64{a: 100})
+	c.commit(map[codec.Address]uint64{a: 60, b: 40})
+	c.commit(map[codec.Address]uint64{a: 0, b: 100})
+	c.commit(map[codec.Address]uint64{b: 90})
+
+	db := memdb.New()
+	stateFunc := func() (State, error) { return c.state, nil }
+	archive, err := newArchive(logging.NoLog{}, db, stateFunc, 0)
+	require.NoError(err)
+	_, err = archive.Balance(a, 0)
+	require.ErrorIs(err, ErrHeightNotArchived)
+
+	// The archive starts from a snapshot of the first height it sees.
+	for height := uint64(0); height <= 3; height++ {
+		require.NoError(archive.Accept(c.block(height)))
+	}
+	require.NoError(archive.Accept(c.block(2))) // Replayed blocks are ignored.
+
+	for _, tc := range []struct {
+		height uint64
+		a, b   uint64
+	}{
+		{0, 100, 0},
+		{1, 60, 40},
+		{2, 0, 100},
+		{3, 0, 90},
+	} {
+		balance, err := archive.Balance(a, tc.height)
+		require.NoError(err)
+		require.Equal(tc.a, balance, "height %d", tc.height)
+		balance, err = archive.Balance(b, tc.height)
+		require.NoError(err)
+		require.Equal(tc.b, balance, "height %d", tc.height)
+	}
+	_, err = archive.Balance(a, 4)
+	require.ErrorIs(err, ErrHeightNotArchived)
+
+	// The archive is reloaded after a restart, and heights outside of the
+	// retention are pruned.
+	archive, err = newArchive(logging.NoLog{}, db, stateFunc, 1)
+	require.NoError(err)
+	c.commit(map[codec.Address]uint64{a: 5})
+	require.NoError(archive.Accept(c.block(4)))
+
+	first, last, ok := archive.Heights()
+	require.True(ok)
+	require.Equal(uint64(3), first)
+	require.Equal(uint64(4), last)
+	_, err = archive.Balance(a, 2)
+	require.ErrorIs(err, ErrHeightPruned)
+	balance, err := archive.Balance(b, 3)
+	require.NoError(err)
+	require.Equal(uint64(90), balance)
+	balance, err = archive.Balance(a, 4)
+	require.NoError(err)
+	require.Equal(uint64(5), balance)
+
+	// Only the records needed for the retained heights are kept.
+	it := db.NewIteratorWithPrefix([]byte{recordPrefix})
+	defer it.Release()
+	records := 0
+	for it.Next() {
+		records++
+	}
+	require.NoError(it.Error())
+	require.Equal(3, records) // a at 2 and 4, b at 3
+}
+
+func TestArchiveResumesAfterSkippedHeights(t *testing.T) {
+	require := require.New(t)
+
+	a := codectest.NewRandomAddress()
+	b := codectest.NewRandomAddress()
+	c := newTestChain(t, map[codec.Address]uint64{a: 100, b: 1})
+	c.commit(map[codec.Address]uint64{a: 50})
+	c.commit(map[codec.Address]uint64{b: 0})
+	c.commit(map[codec.Address]uint64{a: 10})
+
+	db := memdb.New()
+	archive, err := newArchive(logging.NoLog{}, db, func() (State, error) { return c.state, nil }, 0)
+	require.NoError(err)
+	require.NoError(archive.Accept(c.block(0)))
+
+	// Heights 1 and 2 are skipped. The heights archived before are kept.
+	require.NoError(archive.Accept(c.block(3)))
+	first, last, ok := archive.Heights()
+	require.True(ok)
+	require.Equal(uint64(0), first)
+	require.Equal(uint64(3), last)
+
+	balance, err := archive.Balance(a, 0)
+	require.NoError(err)
+	require.Equal(uint64(100), balance)
+	_, err = archive.Balance(a, 2)
+	require.ErrorIs(err, ErrHeightNotArchived)
+	balance, err = archive.Balance(a, 3)
+	require.NoError(err)
+	require.Equal(uint64(10), balance)
+	// [b] was removed while the heights were skipped.
+	balance, err = archive.Balance(b, 3)
+	require.NoError(err)
+	require.Zero(balance)
+
+	// The gap is reloaded after a restart.
+	archive, err = newArchive(logging.NoLog{}, db, func() (State, error) { return c.state, nil }, 0)
+	require.NoError(err)
+	_, err = archive.Balance(b, 1)
+	require.ErrorIs(err, ErrHeightNotArchived)
+	balance, err = archive.Balance(b, 0)
+	require.NoError(err)
+	require.Equal(uint64(1), balance)
+}
@@ -0,0 +1,317 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package balancefeed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/pubsub"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// MaxAddresses is the number of addresses a connection can subscribe to.
+const MaxAddresses = 1024
+
+var ErrTooManyAddresses = errors.New("too many addresses")
+
+var (
+	_ event.Subscription[*chain.ExecutedBlock]        = (*Feed)(nil)
+	_ event.SubscriptionFactory[*chain.ExecutedBlock] = (*Feed)(nil)
+)
+
+// State is the VM state the feed reads balances from.
+type State interface {
+	storage.RootState
+}
+
+// Feed pushes the new balance of the subscribed addresses changed by every
+// accepted block to the connections subscribed to them.
+//
+// Balances are read as of the block that changed them. If the state has
+// already moved past that block, they are read at the state root carried by
+// the next block, so the updates of a block are sent when the next one is
+// accepted.
+type Feed struct {
+	log   logging.Logger
+	state func() (State, error)
+	bh    chain.BalanceHandler
+	s     *pubsub.Server
+
+	l           sync.Mutex
+	subscribers map[codec.Address]*pubsub.Connections
+	conns       map[*pubsub.Connection]set.Set[codec.Address]
+
+	// balances holds the last balance sent for every subscribed address, so
+	// that a block that declared a write without changing a balance is not
+	// reported.
+	balances map[codec.Address]uint64
+
+	// pending holds the balances written by the last accepted block if they
+	// could not be read yet.
+	pending *pendingBlock
+}
+
+// pendingBlock is an accepted block whose updates were not sent yet.
+type pendingBlock struct {
+	height uint64
+	txIDs  map[codec.Address]ids.ID
+}
+
+// New returns a feed reading balances from [state]. [bh] returns the
+// balance keys written by a transaction.
+func New(log logging.Logger, state func() (State, error), bh chain.BalanceHandler) *Feed {
+	f := &Feed{
+		log:         log,
+		state:       state,
+		bh:          bh,
+		subscribers: map[codec.Address]*pubsub.Connections{},
+		conns:       map[*pubsub.Connection]set.Set[codec.Address]{},
+		balances:    map[codec.Address]uint64{},
+	}
+	f.s = pubsub.New(log, pubsub.NewDefaultServerConfig(), f.callback)
+	return f
+}
+
+// Handler serves the WebSocket connections of the subscribers.
+func (f *Feed) Handler() http.Handler {
+	return f.s
+}
+
+func (f *Feed) New() (event.Subscription[*chain.ExecutedBlock], error) {
+	return f, nil
+}
+
+func (f *Feed) callback(msg []byte, c *pubsub.Connection) {
+	if len(msg) == 0 {
+		f.log.Debug("empty balance feed message")
+		return
+	}
+	addrs, err := unpackAddresses(msg[1:])
+	if err == nil {
+		switch msg[0] {
+		case SubscribeMode:
+			err = f.subscribe(c, addrs)
+		case UnsubscribeMode:
+			f.unsubscribe(c, addrs)
+		default:
+			err = ErrInvalidMessage
+		}
+	}
+	if err != nil {
+		f.log.Debug("rejected balance feed message", zap.Error(err))
+		c.Send(packError(err))
+	}
+}
+
+func (f *Feed) subscribe(c *pubsub.Connection, addrs []codec.Address) error {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	subscribed := f.conns[c]
+	added := set.Set[codec.Address]{}
+	for _, addr := range addrs {
+		if !subscribed.Contains(addr) {
+			added.Add(addr)
+		}
+	}
+	if subscribed.Len()+added.Len() > MaxAddresses {
+		return ErrTooManyAddresses
+	}
+
+	// Read the balances of the addresses no one subscribed to yet.
+	unknown := []codec.Address{}
+	for addr := range added {
+		if _, ok := f.subscribers[addr]; !ok {
+			unknown = append(unknown, addr)
+		}
+	}
+	if len(unknown) > 0 {
+		s, err := f.state()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		root, err := s.GetMerkleRoot(ctx)
+		if err != nil {
+			return err
+		}
+		balances, errs := storage.GetBalancesFromState(ctx, storage.ReadStateAtRoot(s, root), unknown)
+		for i, addr := range unknown {
+			if errs[i] != nil {
+				return errs[i]
+			}
+			f.balances[addr] = balances[i]
+		}
+	}
+
+	if subscribed == nil {
+		subscribed = set.Set[codec.Address]{}
+		f.conns[c] = subscribed
+	}
+	for addr := range added {
+		subscribed.Add(addr)
+		conns, ok := f.subscribers[addr]
+		if !ok {
+			conns = pubsub.NewConnections()
+			f.subscribers[addr] = conns
+		}
+		conns.Add(c)
+	}
+	return nil
+}
+
+func (f *Feed) unsubscribe(c *pubsub.Connection, addrs []codec.Address) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	for _, addr := range addrs {
+		f.remove(c, addr)
+	}
+}
+
+// remove unsubscribes [c] from [addr]. The caller must hold [f.l].
+func (f *Feed) remove(c *pubsub.Connection, addr codec.Address) {
+	subscribed, ok := f.conns[c]
+	if !ok || !subscribed.Contains(addr) {
+		return
+	}
+	subscribed.Remove(addr)
+	if subscribed.Len() == 0 {
+		delete(f.conns, c)
+	}
+	conns := f.subscribers[addr]
+	conns.Remove(c)
+	if conns.Len() == 0 {
+		delete(f.subscribers, addr)
+		delete(f.balances, addr)
+	}
+}
+
+// removeClosed unsubscribes the connections that are closed. The caller must
+// hold [f.l].
+func (f *Feed) removeClosed() {
+	active := f.s.Connections()
+	for c, subscribed := range f.conns {
+		if active.Has(c) {
+			continue
+		}
+		for addr := range subscribed {
+			f.remove(c, addr)
+		}
+	}
+}
+
+func (f *Feed) Accept(blk *chain.ExecutedBlock) error {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	f.removeClosed()
+	ctx := context.Background()
+	pending := f.pending
+	f.pending = nil
+	if pending != nil && pending.height+1 != blk.Block.Hght {
+		// The balances after the pending block are no longer in [blk].
+		f.log.Debug("dropped balance feed updates",
+			zap.Uint64("height", pending.height),
+			zap.Uint64("nextHeight", blk.Block.Hght),
+		)
+		pending = nil
+	}
+	if len(f.subscribers) == 0 {
+		return nil
+	}
+
+	s, err := f.state()
+	if err != nil {
+		return err
+	}
+	if pending != nil {
+		// [blk] carries the root of the state after the pending block.
+		if err := f.publish(ctx, storage.ReadStateAtRoot(s, blk.Block.StateRoot), pending.txIDs); err != nil {
+			return err
+		}
+	}
+
+	// Find the last transaction that wrote each subscribed balance.
+	txIDs := map[codec.Address]ids.ID{}
+	for i, tx := range blk.Block.Txs {
+		txID := tx.ID()
+		if !blk.Results[i].Success {
+			// Only the fee was charged.
+			if sponsor := tx.Auth.Sponsor(); f.subscribers[sponsor] != nil {
+				txIDs[sponsor] = txID
+			}
+			continue
+		}
+		stateKeys, err := tx.StateKeys(f.bh)
+		if err != nil {
+			return err
+		}
+		for k, perm := range stateKeys {
+			if !perm.Has(state.Write) {
+				continue
+			}
+			addr, ok := storage.ParseBalanceKey([]byte(k))
+			if ok && f.subscribers[addr] != nil {
+				txIDs[addr] = txID
+			}
+		}
+	}
+	if len(txIDs) == 0 {
+		return nil
+	}
+
+	root, ok, err := storage.RootAfter(ctx, s, blk.Block.Hght)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		f.pending = &pendingBlock{height: blk.Block.Hght, txIDs: txIDs}
+		return nil
+	}
+	return f.publish(ctx, storage.ReadStateAtRoot(s, root), txIDs)
+}
+
+// publish sends the balances [rs] reads for the subscribed addresses of
+// [txIDs] that changed. The caller must hold [f.l].
+func (f *Feed) publish(ctx context.Context, rs storage.ReadState, txIDs map[codec.Address]ids.ID) error {
+	addrs := make([]codec.Address, 0, len(txIDs))
+	for addr := range txIDs {
+		if f.subscribers[addr] != nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	balances, errs := storage.GetBalancesFromState(ctx, rs, addrs)
+	for i, addr := range addrs {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		if balances[i] == f.balances[addr] {
+			continue
+		}
+		f.balances[addr] = balances[i]
+		f.s.Publish(packUpdate(&Update{
+			Address: addr,
+			Balance: balances[i],
+			TxID:    txIDs[addr],
+		}), f.subscribers[addr])
+	}
+	return nil
+}
+
+func (*Feed) Close() error {
+	return nil
+}
@@ -0,0 +1,201 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package balancefeed
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/pubsub"
+	"github.com/ava-labs/hypersdk/state/metadata"
+)
+
+func newState(t *testing.T) merkledb.MerkleDB {
+	db, err := merkledb.New(context.Background(), memdb.New(), merkledb.Config{
+		BranchFactor:                merkledb.BranchFactor16,
+		HistoryLength:               16,
+		ValueNodeCacheSize:          units.MiB,
+		IntermediateNodeCacheSize:   units.MiB,
+		IntermediateWriteBufferSize: units.KiB,
+		IntermediateWriteBatchSize:  units.KiB,
+		TraceLevel:                  merkledb.NoTrace,
+	})
+	require.NoError(t, err)
+	return db
+}
+
+// commit writes [balances] to [s] as the block at [height] would and returns
+// the root of [s] before the block.
+func commit(t *testing.T, s merkledb.MerkleDB, height uint64, balances map[codec.Address]uint64) ids.ID {
+	require := require.New(t)
+	ctx := context.Background()
+
+	parentRoot, err := s.GetMerkleRoot(ctx)
+	require.NoError(err)
+	ops := []database.BatchOp{{
+		Key:   chain.HeightKey(metadata.NewDefaultManager().HeightPrefix()),
+		Value: database.PackUInt64(height),
+	}}
+	for addr, balance := range balances {
+		ops = append(ops, database.BatchOp{
+			Key:   storage.BalanceKey(addr),
+			Value: database.PackUInt64(balance),
+		})
+	}
+	view, err := s.NewView(ctx, merkledb.ViewChanges{BatchOps: ops})
+	require.NoError(err)
+	require.NoError(view.CommitToDB(ctx))
+	return parentRoot
+}
+
+func transfer(t *testing.T, key ed25519.PrivateKey, to codec.Address, value uint64) *chain.Transaction {
+	tx, err := chain.NewTxData(
+		&chain.Base{ChainID: ids.Empty, Timestamp: 1, MaxFee: 10},
+		[]chain.Action{&actions.Transfer{To: to, Value: value}},
+	).Sign(auth.NewED25519Factory(key))
+	require.NoError(t, err)
+	return tx
+}
+
+func block(height uint64, root ids.ID, tx *chain.Transaction, success bool) *chain.ExecutedBlock {
+	return &chain.ExecutedBlock{
+		Block:   &chain.StatelessBlock{Hght: height, StateRoot: root, Txs: []*chain.Transaction{tx}},
+		Results: []*chain.Result{{Success: success, Outputs: [][]byte{{}}, Fee: 10}},
+	}
+}
+
+func send(t *testing.T, conn *websocket.Conn, msg []byte) {
+	batch, err := pubsub.CreateBatchMessage(pubsub.MaxWriteMessageSize, [][]byte{msg})
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, batch))
+}
+
+// receive returns the next [n] messages sent by the feed.
+func receive(t *testing.T, conn *websocket.Conn, n int) ([]*Update, []error) {
+	require := require.New(t)
+	updates := []*Update{}
+	errs := []error{}
+	require.NoError(conn.SetReadDeadline(time.Now().Add(10 * time.Second)))
+	for len(updates)+len(errs) < n {
+		_, batch, err := conn.ReadMessage()
+		require.NoError(err)
+		msgs, err := pubsub.ParseBatchMessage(pubsub.MaxReadMessageSize, batch)
+		require.NoError(err)
+		for _, msg := range msgs {
+			update, feedErr, err := UnpackMessage(msg)
+			require.NoError(err)
+			if feedErr != nil {
+				errs = append(errs, feedErr)
+			} else {
+				updates = append(updates, update)
+			}
+		}
+	}
+	return updates, errs
+}
+
+func TestFeed(t *testing.T) {
+	require := require.New(t)
+
+	key, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	sender := auth.NewED25519Address(key.PublicKey())
+	a := codectest.NewRandomAddress()
+	b := codectest.NewRandomAddress()
+
+	s := newState(t)
+	commit(t, s, 0, map[codec.Address]uint64{sender: 1_000, a: 5})
+	f := New(logging.NoLog{}, func() (State, error) { return s, nil }, &storage.BalanceHandler{})
+
+	server := httptest.NewServer(f.Handler())
+	defer server.Close()
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(err)
+	resp.Body.Close()
+	defer conn.Close()
+
+	send(t, conn, PackSubscribe([]codec.Address{sender, b}))
+	require.Eventually(func() bool {
+		f.l.Lock()
+		defer f.l.Unlock()
+		return len(f.subscribers) == 2
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// [sender] sends 990 to [b] and pays a fee of 10. [a] is not subscribed.
+	tx1 := transfer(t, key, b, 990)
+	root := commit(t, s, 1, map[codec.Address]uint64{sender: 0, b: 990, a: 6})
+	blk1 := block(1, root, tx1, true)
+	require.NoError(f.Accept(blk1))
+	updates, _ := receive(t, conn, 2)
+	require.ElementsMatch([]*Update{
+		{Address: sender, Balance: 0, TxID: tx1.ID()},
+		{Address: b, Balance: 990, TxID: tx1.ID()},
+	}, updates)
+
+	// Balances that did not change are not sent again.
+	require.NoError(f.Accept(blk1))
+
+	// The state moved past block 2 before it was accepted, so its updates
+	// are read at the root carried by block 3. Failed transactions only
+	// charge their sponsor.
+	tx2 := transfer(t, key, b, 1)
+	tx3 := transfer(t, key, b, 2)
+	root2 := commit(t, s, 2, map[codec.Address]uint64{b: 991})
+	root3 := commit(t, s, 3, map[codec.Address]uint64{sender: 1, b: 995})
+	require.NoError(f.Accept(block(2, root2, tx2, true)))
+	require.NoError(f.Accept(block(3, root3, tx3, false)))
+	updates, _ = receive(t, conn, 2)
+	require.ElementsMatch([]*Update{
+		{Address: b, Balance: 991, TxID: tx2.ID()},
+		{Address: sender, Balance: 1, TxID: tx3.ID()},
+	}, updates)
+
+	// The updates of a pending block are dropped if the next block is not
+	// accepted.
+	tx4 := transfer(t, key, b, 3)
+	tx6 := transfer(t, key, b, 4)
+	root4 := commit(t, s, 4, map[codec.Address]uint64{b: 1_000})
+	commit(t, s, 5, map[codec.Address]uint64{b: 1_001})
+	root6 := commit(t, s, 6, map[codec.Address]uint64{b: 1_002})
+	require.NoError(f.Accept(block(4, root4, tx4, true)))
+	require.NoError(f.Accept(block(6, root6, tx6, true)))
+	updates, _ = receive(t, conn, 1)
+	require.Equal([]*Update{{Address: b, Balance: 1_002, TxID: tx6.ID()}}, updates)
+
+	// Subscriptions are limited.
+	addrs := make([]codec.Address, MaxAddresses)
+	for i := range addrs {
+		addrs[i] = codectest.NewRandomAddress()
+	}
+	send(t, conn, PackSubscribe(addrs))
+	_, errs := receive(t, conn, 1)
+	require.Len(errs, 1)
+	require.EqualError(errs[0], ErrTooManyAddresses.Error())
+
+	send(t, conn, PackUnsubscribe([]codec.Address{sender, b}))
+	require.Eventually(func() bool {
+		f.l.Lock()
+		defer f.l.Unlock()
+		return len(f.subscribers) == 0 && len(f.conns) == 0 && len(f.balances) == 0
+	}, 10*time.Second, 10*time.Millisecond)
+}
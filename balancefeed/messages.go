@@ -0,0 +1,120 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package balancefeed
+
+import (
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+)
+
+// Messages start with their mode. Clients send [SubscribeMode] and
+// [UnsubscribeMode] messages, and the feed sends [UpdateMode] and
+// [ErrorMode] messages.
+const (
+	SubscribeMode   byte = 0x0
+	UnsubscribeMode byte = 0x1
+
+	UpdateMode byte = 0x0
+	ErrorMode  byte = 0x1
+
+	updateLen = 1 + codec.AddressLen + consts.Uint64Len + ids.IDLen
+)
+
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Update is the balance of a subscribed address after the block that
+// changed it. TxID is the last transaction of the block that wrote the
+// balance.
+type Update struct {
+	Address codec.Address `json:"address"`
+	Balance uint64        `json:"balance"`
+	TxID    ids.ID        `json:"txID"`
+}
+
+// PackSubscribe returns the message subscribing to [addrs].
+func PackSubscribe(addrs []codec.Address) []byte {
+	return packAddresses(SubscribeMode, addrs)
+}
+
+// PackUnsubscribe returns the message unsubscribing from [addrs].
+func PackUnsubscribe(addrs []codec.Address) []byte {
+	return packAddresses(UnsubscribeMode, addrs)
+}
+
+func packAddresses(mode byte, addrs []codec.Address) []byte {
+	p := codec.NewWriter(1+consts.IntLen+len(addrs)*codec.AddressLen, consts.NetworkSizeLimit)
+	p.PackByte(mode)
+	p.PackInt(uint32(len(addrs)))
+	for _, addr := range addrs {
+		p.PackAddress(addr)
+	}
+	return p.Bytes()
+}
+
+// unpackAddresses returns the addresses of a [SubscribeMode] or
+// [UnsubscribeMode] message, without its mode.
+func unpackAddresses(msg []byte) ([]codec.Address, error) {
+	p := codec.NewReader(msg, consts.NetworkSizeLimit)
+	count := p.UnpackInt(true)
+	if int(count)*codec.AddressLen != len(msg)-consts.IntLen {
+		return nil, ErrInvalidMessage
+	}
+	addrs := make([]codec.Address, count)
+	for i := range addrs {
+		p.UnpackAddress(&addrs[i])
+	}
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+func packUpdate(u *Update) []byte {
+	p := codec.NewWriter(updateLen, updateLen)
+	p.PackByte(UpdateMode)
+	p.PackAddress(u.Address)
+	p.PackUint64(u.Balance)
+	p.PackID(u.TxID)
+	return p.Bytes()
+}
+
+func packError(err error) []byte {
+	msg := err.Error()
+	p := codec.NewWriter(1+consts.Uint16Len+len(msg), consts.NetworkSizeLimit)
+	p.PackByte(ErrorMode)
+	p.PackString(msg)
+	return p.Bytes()
+}
+
+// UnpackMessage parses a message sent by the feed. It returns the update of
+// an [UpdateMode] message, or the error of an [ErrorMode] message.
+func UnpackMessage(msg []byte) (*Update, error, error) {
+	if len(msg) == 0 {
+		return nil, nil, ErrInvalidMessage
+	}
+	p := codec.NewReader(msg[1:], consts.NetworkSizeLimit)
+	switch msg[0] {
+	case UpdateMode:
+		if len(msg) != updateLen {
+			return nil, nil, ErrInvalidMessage
+		}
+		u := &Update{}
+		p.UnpackAddress(&u.Address)
+		u.Balance = p.UnpackUint64(false)
+		p.UnpackID(false, &u.TxID)
+		return u, nil, p.Err()
+	case ErrorMode:
+		msg := p.UnpackString(true)
+		if err := p.Err(); err != nil {
+			return nil, nil, err
+		}
+		return nil, errors.New(msg), nil
+	default:
+		return nil, nil, ErrInvalidMessage
+	}
+}
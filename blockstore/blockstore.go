@@ -0,0 +1,306 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package blockstore
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/pebbledb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/event"
+)
+
+const (
+	// Namespace is the directory, under the VM's data directory, that holds
+	// the store.
+	Namespace = "blocks"
+
+	DefaultLimit = 10
+	MaxLimit     = 100
+
+	blockPrefix byte = 0x0 // inverted height -> executed block
+	idPrefix    byte = 0x1 // block ID -> height
+	txPrefix    byte = 0x2 // tx ID -> height + tx index
+	metaPrefix  byte = 0x3 // first height, last height
+
+	metaLen   = 2 * consts.Uint64Len
+	txLen     = consts.Uint64Len + consts.Uint32Len
+	cursorLen = consts.Uint64Len
+)
+
+var (
+	ErrBlockNotFound = errors.New("block not found")
+	ErrTxNotFound    = errors.New("transaction not found")
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+var _ event.Subscription[*chain.ExecutedBlock] = (*Store)(nil)
+
+// Store keeps the accepted blocks, with their results, by height, ID and
+// transaction ID.
+type Store struct {
+	db        database.Database
+	parser    chain.Parser
+	retention uint64
+
+	l       sync.RWMutex
+	started bool
+	first   uint64
+	last    uint64
+}
+
+// New opens the store at [path]. [parser] decodes the stored blocks. Heights
+// more than [retention] below the last stored height are pruned. A retention
+// of 0 keeps every height.
+func New(path string, parser chain.Parser, retention uint64) (*Store, error) {
+	db, err := pebbledb.New(path, nil, logging.NoLog{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newStore(db, parser, retention)
+}
+
+func newStore(db database.Database, parser chain.Parser, retention uint64) (*Store, error) {
+	s := &Store{
+		db:        db,
+		parser:    parser,
+		retention: retention,
+	}
+	b, err := db.Get([]byte{metaPrefix})
+	if errors.Is(err, database.ErrNotFound) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := codec.NewReader(b, metaLen)
+	s.first = p.UnpackUint64(false)
+	s.last = p.UnpackUint64(false)
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	s.started = true
+	return s, nil
+}
+
+// Heights returns the first and last stored heights. It returns false if no
+// block has been stored yet. Heights between them may be missing if blocks
+// were skipped, such as by state sync.
+func (s *Store) Heights() (uint64, uint64, bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.first, s.last, s.started
+}
+
+func (s *Store) Accept(blk *chain.ExecutedBlock) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	height := blk.Block.Hght
+	if s.started && height <= s.last {
+		// Blocks are delivered at least once.
+		return nil
+	}
+	b, err := blk.Marshal()
+	if err != nil {
+		return err
+	}
+	batch := s.db.NewBatch()
+	if err := batch.Put(blockKey(height), b); err != nil {
+		return err
+	}
+	if err := batch.Put(idKey(blk.BlockID), database.PackUInt64(height)); err != nil {
+		return err
+	}
+	for i, tx := range blk.Block.Txs {
+		p := codec.NewWriter(txLen, txLen)
+		p.PackUint64(height)
+		p.PackInt(uint32(i))
+		if err := batch.Put(txKey(tx.ID()), p.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	first := s.first
+	if !s.started {
+		first = height
+	}
+	if s.retention > 0 && height-first > s.retention {
+		newFirst := height - s.retention
+		// Only heights up to the previous last height can be stored.
+		for h := first; h < newFirst && h <= s.last; h++ {
+			if err := s.delete(batch, h); err != nil {
+				return err
+			}
+		}
+		first = newFirst
+	}
+
+	p := codec.NewWriter(metaLen, metaLen)
+	p.PackUint64(first)
+	p.PackUint64(height)
+	if err := batch.Put([]byte{metaPrefix}, p.Bytes()); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	s.started = true
+	s.first = first
+	s.last = height
+	return nil
+}
+
+// delete removes the block at [height], if it is stored.
+func (s *Store) delete(batch database.Batch, height uint64) error {
+	blk, err := s.block(height)
+	if errors.Is(err, ErrBlockNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, tx := range blk.Block.Txs {
+		if err := batch.Delete(txKey(tx.ID())); err != nil {
+			return err
+		}
+	}
+	if err := batch.Delete(idKey(blk.BlockID)); err != nil {
+		return err
+	}
+	return batch.Delete(blockKey(height))
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Block returns the block at [height].
+func (s *Store) Block(height uint64) (*chain.ExecutedBlock, error) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.block(height)
+}
+
+func (s *Store) block(height uint64) (*chain.ExecutedBlock, error) {
+	b, err := s.db.Get(blockKey(height))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return chain.UnmarshalExecutedBlock(b, s.parser)
+}
+
+// BlockByID returns the block with ID [blkID].
+func (s *Store) BlockByID(blkID ids.ID) (*chain.ExecutedBlock, error) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	b, err := s.db.Get(idKey(blkID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	height, err := database.ParseUInt64(b)
+	if err != nil {
+		return nil, err
+	}
+	return s.block(height)
+}
+
+// Tx returns the block that includes the transaction [txID], and the index
+// of the transaction in the block.
+func (s *Store) Tx(txID ids.ID) (*chain.ExecutedBlock, int, error) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	v, err := s.db.Get(txKey(txID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, 0, ErrTxNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	p := codec.NewReader(v, txLen)
+	height := p.UnpackUint64(false)
+	index := p.UnpackInt(false)
+	if err := p.Err(); err != nil {
+		return nil, 0, err
+	}
+	blk, err := s.block(height)
+	if err != nil {
+		return nil, 0, err
+	}
+	return blk, int(index), nil
+}
+
+// Blocks returns up to [limit] blocks, newest first, starting at [cursor].
+// An empty cursor starts at the newest block. The returned cursor is empty
+// once there are no more blocks.
+func (s *Store) Blocks(cursor []byte, limit int) ([]*chain.ExecutedBlock, []byte, error) {
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if limit < 0 || limit > MaxLimit {
+		return nil, nil, ErrInvalidLimit
+	}
+	start := []byte{blockPrefix}
+	if len(cursor) > 0 {
+		if len(cursor) != cursorLen {
+			return nil, nil, ErrInvalidCursor
+		}
+		start = append(start, cursor...)
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	it := s.db.NewIteratorWithStartAndPrefix(start, []byte{blockPrefix})
+	defer it.Release()
+
+	blks := []*chain.ExecutedBlock{}
+	for it.Next() {
+		if len(blks) == limit {
+			return blks, it.Key()[1:], nil
+		}
+		blk, err := chain.UnmarshalExecutedBlock(it.Value(), s.parser)
+		if err != nil {
+			return nil, nil, err
+		}
+		blks = append(blks, blk)
+	}
+	return blks, nil, it.Error()
+}
+
+// blockKey inverts [height] so that iterating over the blocks yields the
+// newest first.
+func blockKey(height uint64) []byte {
+	k := make([]byte, 0, 1+consts.Uint64Len)
+	k = append(k, blockPrefix)
+	return binary.BigEndian.AppendUint64(k, math.MaxUint64-height)
+}
+
+func idKey(blkID ids.ID) []byte {
+	return append([]byte{idPrefix}, blkID[:]...)
+}
+
+func txKey(txID ids.ID) []byte {
+	return append([]byte{txPrefix}, txID[:]...)
+}
@@ -0,0 +1,147 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package blockstore
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/genesis"
+)
+
+var _ chain.Parser = (*testParser)(nil)
+
+type testParser struct {
+	actions *codec.TypeParser[chain.Action]
+	auth    *codec.TypeParser[chain.Auth]
+}
+
+func (*testParser) Rules(int64) chain.Rules {
+	return genesis.NewDefaultRules()
+}
+
+func (p *testParser) ActionCodec() *codec.TypeParser[chain.Action] {
+	return p.actions
+}
+
+func (*testParser) OutputCodec() *codec.TypeParser[codec.Typed] {
+	return codec.NewTypeParser[codec.Typed]()
+}
+
+func (p *testParser) AuthCodec() *codec.TypeParser[chain.Auth] {
+	return p.auth
+}
+
+func newTestParser(t *testing.T) chain.Parser {
+	p := &testParser{
+		actions: codec.NewTypeParser[chain.Action](),
+		auth:    codec.NewTypeParser[chain.Auth](),
+	}
+	require.NoError(t, p.actions.Register(&actions.Transfer{}, nil))
+	require.NoError(t, p.auth.Register(&auth.ED25519{}, auth.UnmarshalED25519))
+	return p
+}
+
+func newBlock(t *testing.T, key ed25519.PrivateKey, height uint64) *chain.ExecutedBlock {
+	tx, err := chain.NewTxData(
+		&chain.Base{ChainID: ids.GenerateTestID(), Timestamp: int64(height) * 1000, MaxFee: 1},
+		[]chain.Action{&actions.Transfer{To: codectest.NewRandomAddress(), Value: height}},
+	).Sign(auth.NewED25519Factory(key))
+	require.NoError(t, err)
+	blk, err := chain.NewExecutedBlock(
+		&chain.StatelessBlock{Hght: height, Tmstmp: int64(height) * 1000, Txs: []*chain.Transaction{tx}},
+		[]*chain.Result{{Success: true, Error: []byte{}, Outputs: [][]byte{{}}, Units: fees.Dimensions{1}, Fee: 1}},
+		fees.Dimensions{},
+	)
+	require.NoError(t, err)
+	return blk
+}
+
+func TestStore(t *testing.T) {
+	require := require.New(t)
+
+	key, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	db := memdb.New()
+	s, err := newStore(db, newTestParser(t), 2)
+	require.NoError(err)
+	_, _, ok := s.Heights()
+	require.False(ok)
+
+	blks := []*chain.ExecutedBlock{}
+	for height := uint64(1); height <= 4; height++ {
+		blk := newBlock(t, key, height)
+		blks = append(blks, blk)
+		require.NoError(s.Accept(blk))
+	}
+	// Blocks are delivered at least once.
+	require.NoError(s.Accept(blks[3]))
+
+	// Heights more than 2 below the last height are pruned.
+	first, last, ok := s.Heights()
+	require.True(ok)
+	require.Equal(uint64(2), first)
+	require.Equal(uint64(4), last)
+	_, err = s.Block(1)
+	require.ErrorIs(err, ErrBlockNotFound)
+	_, err = s.BlockByID(blks[0].BlockID)
+	require.ErrorIs(err, ErrBlockNotFound)
+	_, _, err = s.Tx(blks[0].Block.Txs[0].ID())
+	require.ErrorIs(err, ErrTxNotFound)
+
+	blk, err := s.Block(3)
+	require.NoError(err)
+	require.Equal(blks[2].BlockID, blk.BlockID)
+	require.Equal(blks[2].Results, blk.Results)
+	blk, err = s.BlockByID(blks[3].BlockID)
+	require.NoError(err)
+	require.Equal(uint64(4), blk.Block.Hght)
+	blk, index, err := s.Tx(blks[1].Block.Txs[0].ID())
+	require.NoError(err)
+	require.Equal(uint64(2), blk.Block.Hght)
+	require.Zero(index)
+
+	// Blocks are listed newest first.
+	page, cursor, err := s.Blocks(nil, 2)
+	require.NoError(err)
+	require.Len(page, 2)
+	require.Equal(uint64(4), page[0].Block.Hght)
+	require.Equal(uint64(3), page[1].Block.Hght)
+	page, cursor, err = s.Blocks(cursor, 2)
+	require.NoError(err)
+	require.Len(page, 1)
+	require.Equal(uint64(2), page[0].Block.Hght)
+	require.Empty(cursor)
+	_, _, err = s.Blocks([]byte{1}, 2)
+	require.ErrorIs(err, ErrInvalidCursor)
+	_, _, err = s.Blocks(nil, MaxLimit+1)
+	require.ErrorIs(err, ErrInvalidLimit)
+
+	// The store picks up where it left off.
+	s, err = newStore(db, newTestParser(t), 2)
+	require.NoError(err)
+	first, last, ok = s.Heights()
+	require.True(ok)
+	require.Equal(uint64(2), first)
+	require.Equal(uint64(4), last)
+
+	// Skipped heights are not pruned one by one.
+	require.NoError(s.Accept(newBlock(t, key, 100)))
+	first, last, _ = s.Heights()
+	require.Equal(uint64(98), first)
+	require.Equal(uint64(100), last)
+	page, _, err = s.Blocks(nil, MaxLimit)
+	require.NoError(err)
+	require.Len(page, 1)
+}
@@ -0,0 +1,187 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package genesis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/spf13/cobra"
+
+	"github.com/ava-labs/hypersdk-starter-kit/cmd/morpheusvm/state"
+	"github.com/ava-labs/hypersdk-starter-kit/vm"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/fees"
+
+	hgenesis "github.com/ava-labs/hypersdk/genesis"
+)
+
+var (
+	ErrNoAllocations  = errors.New("no allocations")
+	ErrInvalidAddress = errors.New("invalid address")
+)
+
+var (
+	allocationsPath   string
+	format            string
+	basePath          string
+	outputPath        string
+	networkID         uint32
+	chainID           string
+	minBlockGap       int64
+	minEmptyBlockGap  int64
+	validityWindow    int64
+	maxBlockUnits     []string
+	windowTargetUnits []string
+	minUnitPrice      []string
+)
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+// NewCommand implements "morpheusvm genesis" command.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "genesis",
+		Short: "Generates a genesis file from an allocation list",
+		RunE:  genesisFunc,
+	}
+	cmd.Flags().StringVar(&allocationsPath, "allocations", "", "csv or json allocation list")
+	cmd.Flags().StringVar(&format, "format", "", "allocation list format, json or csv (defaults to the file extension)")
+	cmd.Flags().StringVar(&basePath, "base", "", "genesis to take unset fields from (defaults to the default genesis)")
+	cmd.Flags().StringVar(&outputPath, "output", "", "genesis file (defaults to stdout)")
+	cmd.Flags().Uint32Var(&networkID, "network-id", 0, "network ID")
+	cmd.Flags().StringVar(&chainID, "chain-id", "", "chain ID")
+	cmd.Flags().Int64Var(&minBlockGap, "min-block-gap", 0, "minimum time between blocks in ms")
+	cmd.Flags().Int64Var(&minEmptyBlockGap, "min-empty-block-gap", 0, "minimum time between empty blocks in ms")
+	cmd.Flags().Int64Var(&validityWindow, "validity-window", 0, "validity window of transactions in ms")
+	cmd.Flags().StringSliceVar(&maxBlockUnits, "max-block-units", nil, "maximum units per block, one value per fee dimension")
+	cmd.Flags().StringSliceVar(&windowTargetUnits, "window-target-units", nil, "target units per window, one value per fee dimension")
+	cmd.Flags().StringSliceVar(&minUnitPrice, "min-unit-price", nil, "minimum unit price, one value per fee dimension")
+	return cmd
+}
+
+func genesisFunc(cmd *cobra.Command, _ []string) error {
+	allocs, err := state.ReadAllocations(allocationsPath, format)
+	if err != nil {
+		return err
+	}
+	if len(allocs) == 0 {
+		return ErrNoAllocations
+	}
+	for _, alloc := range allocs {
+		if err := validateAddress(alloc.Address); err != nil {
+			return err
+		}
+	}
+	supply, err := state.TotalBalance(allocs)
+	if err != nil {
+		return err
+	}
+
+	g := &vm.Genesis{DefaultGenesis: hgenesis.NewDefaultGenesis(nil)}
+	if len(basePath) > 0 {
+		b, err := os.ReadFile(basePath)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, g); err != nil {
+			return fmt.Errorf("%w: failed to parse base genesis", err)
+		}
+	}
+	g.CustomAllocation = allocs
+	if err := applyRules(cmd, g.Rules); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if len(outputPath) == 0 {
+		_, err = cmd.OutOrStdout().Write(b)
+	} else {
+		err = os.WriteFile(outputPath, b, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Stats go to stderr so the genesis can be piped from stdout.
+	printStats(cmd.ErrOrStderr(), allocs, supply)
+	return nil
+}
+
+// applyRules overrides the rules with the flags that were set.
+func applyRules(cmd *cobra.Command, r *hgenesis.Rules) error {
+	flags := cmd.Flags()
+	if flags.Changed("network-id") {
+		r.NetworkID = networkID
+	}
+	if flags.Changed("chain-id") {
+		id, err := ids.FromString(chainID)
+		if err != nil {
+			return fmt.Errorf("%w: invalid chain ID", err)
+		}
+		r.ChainID = id
+	}
+	if flags.Changed("min-block-gap") {
+		r.MinBlockGap = minBlockGap
+	}
+	if flags.Changed("min-empty-block-gap") {
+		r.MinEmptyBlockGap = minEmptyBlockGap
+	}
+	if flags.Changed("validity-window") {
+		r.ValidityWindow = validityWindow
+	}
+	for _, d := range []struct {
+		flag  string
+		raw   []string
+		value *fees.Dimensions
+	}{
+		{"max-block-units", maxBlockUnits, &r.MaxBlockUnits},
+		{"window-target-units", windowTargetUnits, &r.WindowTargetUnits},
+		{"min-unit-price", minUnitPrice, &r.MinUnitPrice},
+	} {
+		if !flags.Changed(d.flag) {
+			continue
+		}
+		v, err := fees.ParseDimensions(d.raw)
+		if err != nil {
+			return fmt.Errorf("%w: invalid --%s", err, d.flag)
+		}
+		*d.value = v
+	}
+	return nil
+}
+
+// validateAddress checks that [addr] belongs to an auth type the VM accepts.
+func validateAddress(addr codec.Address) error {
+	if addr == codec.EmptyAddress {
+		return fmt.Errorf("%w: empty address", ErrInvalidAddress)
+	}
+	if _, ok := vm.AuthType(addr); !ok {
+		return fmt.Errorf("%w: %s has unknown auth type %d", ErrInvalidAddress, addr, addr[0])
+	}
+	return nil
+}
+
+func printStats(w io.Writer, allocs []*hgenesis.CustomAllocation, supply uint64) {
+	minBalance, maxBalance := allocs[0].Balance, allocs[0].Balance
+	for _, alloc := range allocs {
+		minBalance = min(minBalance, alloc.Balance)
+		maxBalance = max(maxBalance, alloc.Balance)
+	}
+	fmt.Fprintf(w, "accounts: %d\n", len(allocs))
+	fmt.Fprintf(w, "total supply: %d\n", supply)
+	fmt.Fprintf(w, "min balance: %d\n", minBalance)
+	fmt.Fprintf(w, "max balance: %d\n", maxBalance)
+	fmt.Fprintf(w, "mean balance: %d\n", supply/uint64(len(allocs)))
+}
@@ -0,0 +1,182 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package genesis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/cb58"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk-starter-kit/vm"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/fees"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+	hgenesis "github.com/ava-labs/hypersdk/genesis"
+)
+
+func TestGenesisCommand(t *testing.T) {
+	var (
+		addr0   = codec.CreateAddress(auth.ED25519ID, ids.GenerateTestID())
+		addr1   = codec.CreateAddress(auth.BLSID, ids.GenerateTestID())
+		chainID = ids.GenerateTestID()
+		allocs  = []*hgenesis.CustomAllocation{
+			{Address: addr0, Balance: 1},
+			{Address: addr1, Balance: 2},
+		}
+		csv = fmt.Sprintf("address,balance\n%s,1\n%s,2\n", addr0, addr1)
+	)
+	base := &vm.Genesis{
+		DefaultGenesis: hgenesis.NewDefaultGenesis([]*hgenesis.CustomAllocation{{Address: addr1, Balance: 9}}),
+		Bridge: &storage.BridgeConfig{
+			Quorum:   1,
+			Relayers: []codec.Bytes{make([]byte, 48)},
+		},
+	}
+	base.Rules.MinBlockGap = 500
+	base.Rules.ValidityWindow = 30_000
+	baseBytes, err := json.Marshal(base)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		base        []byte
+		args        []string
+		expectedErr error
+		// check is called with the generated genesis if [expectedErr] is nil.
+		check func(*require.Assertions, *vm.Genesis)
+	}{
+		{
+			name:    "CSV",
+			file:    "allocs.csv",
+			content: csv,
+			check: func(require *require.Assertions, g *vm.Genesis) {
+				require.Equal(allocs, g.CustomAllocation)
+				require.Equal(hgenesis.NewDefaultRules(), g.Rules)
+				require.Equal(hgenesis.NewDefaultGenesis(nil).StateBranchFactor, g.StateBranchFactor)
+				require.Nil(g.Bridge)
+			},
+		},
+		{
+			name:    "JSON",
+			file:    "allocs.json",
+			content: fmt.Sprintf(`[{"address":%q,"balance":1},{"address":%q,"balance":2}]`, addr0, addr1),
+			check: func(require *require.Assertions, g *vm.Genesis) {
+				require.Equal(allocs, g.CustomAllocation)
+			},
+		},
+		{
+			name:    "Base",
+			file:    "allocs.csv",
+			content: csv,
+			base:    baseBytes,
+			args:    []string{"--min-block-gap", "250"},
+			check: func(require *require.Assertions, g *vm.Genesis) {
+				// The allocations are replaced, the rest is kept unless a
+				// flag overrides it.
+				require.Equal(allocs, g.CustomAllocation)
+				require.Equal(base.Bridge, g.Bridge)
+				require.Equal(int64(250), g.Rules.MinBlockGap)
+				require.Equal(int64(30_000), g.Rules.ValidityWindow)
+			},
+		},
+		{
+			name:    "RuleFlags",
+			file:    "allocs.csv",
+			content: csv,
+			args: []string{
+				"--network-id", "5",
+				"--chain-id", chainID.String(),
+				"--min-block-gap", "250",
+				"--min-empty-block-gap", "1000",
+				"--validity-window", "20000",
+				"--max-block-units", "1,2,3,4,5",
+				"--window-target-units", "6,7,8,9,10",
+				"--min-unit-price", "11,12,13,14,15",
+			},
+			check: func(require *require.Assertions, g *vm.Genesis) {
+				require.Equal(uint32(5), g.Rules.NetworkID)
+				require.Equal(chainID, g.Rules.ChainID)
+				require.Equal(int64(250), g.Rules.MinBlockGap)
+				require.Equal(int64(1000), g.Rules.MinEmptyBlockGap)
+				require.Equal(int64(20000), g.Rules.ValidityWindow)
+				require.Equal(fees.Dimensions{1, 2, 3, 4, 5}, g.Rules.MaxBlockUnits)
+				require.Equal(fees.Dimensions{6, 7, 8, 9, 10}, g.Rules.WindowTargetUnits)
+				require.Equal(fees.Dimensions{11, 12, 13, 14, 15}, g.Rules.MinUnitPrice)
+				// Rules without a flag keep their default.
+				require.Equal(hgenesis.NewDefaultRules().MaxActionsPerTx, g.Rules.MaxActionsPerTx)
+			},
+		},
+		{
+			name:        "InvalidChainID",
+			file:        "allocs.csv",
+			content:     csv,
+			args:        []string{"--chain-id", "not-an-id"},
+			expectedErr: cb58.ErrBase58Decoding,
+		},
+		{
+			name:        "NoAllocations",
+			file:        "allocs.csv",
+			content:     "address,balance\n",
+			expectedErr: ErrNoAllocations,
+		},
+		{
+			name:        "UnknownAuthType",
+			file:        "allocs.csv",
+			content:     fmt.Sprintf("%s,1\n", codec.CreateAddress(math.MaxUint8, ids.GenerateTestID())),
+			expectedErr: ErrInvalidAddress,
+		},
+		{
+			name:        "SupplyOverflow",
+			file:        "allocs.csv",
+			content:     fmt.Sprintf("%s,%d\n%s,1\n", addr0, uint64(math.MaxUint64), addr1),
+			expectedErr: smath.ErrOverflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.file)
+			require.NoError(os.WriteFile(path, []byte(tt.content), 0o600))
+			args := append([]string{"--allocations", path}, tt.args...)
+			if tt.base != nil {
+				basePath := filepath.Join(dir, "base.json")
+				require.NoError(os.WriteFile(basePath, tt.base, 0o600))
+				args = append(args, "--base", basePath)
+			}
+
+			var stdout, stderr bytes.Buffer
+			cmd := NewCommand()
+			cmd.SetArgs(args)
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := cmd.Execute()
+			require.ErrorIs(err, tt.expectedErr)
+			if tt.expectedErr != nil {
+				return
+			}
+
+			var g vm.Genesis
+			require.NoError(json.Unmarshal(stdout.Bytes(), &g))
+			tt.check(require, &g)
+			require.Contains(stderr.String(), fmt.Sprintf("accounts: %d\n", len(g.CustomAllocation)))
+		})
+	}
+}
@@ -13,6 +13,10 @@ import (
 	"github.com/ava-labs/avalanchego/vms/rpcchainvm"
 	"github.com/spf13/cobra"
 
+	"github.com/ava-labs/hypersdk-starter-kit/cmd/morpheusvm/genesis"
+	"github.com/ava-labs/hypersdk-starter-kit/cmd/morpheusvm/memo"
+	"github.com/ava-labs/hypersdk-starter-kit/cmd/morpheusvm/schema"
+	"github.com/ava-labs/hypersdk-starter-kit/cmd/morpheusvm/state"
 	"github.com/ava-labs/hypersdk-starter-kit/cmd/morpheusvm/version"
 	"github.com/ava-labs/hypersdk-starter-kit/vm"
 )
@@ -31,6 +35,10 @@ func init() {
 func init() {
 	rootCmd.AddCommand(
 		version.NewCommand(),
+		memo.NewCommand(),
+		state.NewCommand(),
+		genesis.NewCommand(),
+		schema.NewCommand(),
 	)
 }
 
@@ -47,7 +55,7 @@ func runFunc(*cobra.Command, []string) error {
 		return fmt.Errorf("%w: failed to set fd limit correctly", err)
 	}
 
-	vm, err := vm.New()
+	vm, err := vm.NewChainVM()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,142 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package memo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/vm"
+	"github.com/ava-labs/hypersdk/api/jsonrpc"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+)
+
+var (
+	recipientKey string
+	message      string
+
+	uri        string
+	keyPath    string
+	rawMemo    string
+	fromHeight uint64
+	toHeight   uint64
+)
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+// NewCommand implements "morpheusvm memo" command.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "memo",
+		Short: "Seals and opens encrypted transfer memos",
+	}
+
+	sealCmd := &cobra.Command{
+		Use:   "seal",
+		Short: "Encrypts a memo for the owner of an ed25519 public key",
+		RunE:  sealFunc,
+	}
+	sealCmd.Flags().StringVar(&recipientKey, "to-key", "", "hex encoded ed25519 public key of the recipient")
+	sealCmd.Flags().StringVar(&message, "message", "", "memo to encrypt")
+
+	openCmd := &cobra.Command{
+		Use:   "open",
+		Short: "Decrypts a memo or the memos received in a range of blocks",
+		RunE:  openFunc,
+	}
+	openCmd.Flags().StringVar(&keyPath, "key", "", "path to the recipient's ed25519 private key")
+	openCmd.Flags().StringVar(&rawMemo, "memo", "", "hex encoded memo to decrypt")
+	openCmd.Flags().StringVar(&uri, "uri", "", "chain URI to read blocks from")
+	openCmd.Flags().Uint64Var(&fromHeight, "from", 0, "first block height to scan (defaults to the last accepted block)")
+	openCmd.Flags().Uint64Var(&toHeight, "to", 0, "last block height to scan (defaults to the last accepted block)")
+
+	cmd.AddCommand(sealCmd, openCmd)
+	return cmd
+}
+
+func sealFunc(*cobra.Command, []string) error {
+	pk, err := codec.LoadHex(recipientKey, ed25519.PublicKeyLen)
+	if err != nil {
+		return fmt.Errorf("%w: failed to load recipient key", err)
+	}
+	memo, err := actions.SealMemo(ed25519.PublicKey(pk), []byte(message))
+	if err != nil {
+		return err
+	}
+	fmt.Println(codec.ToHex(memo))
+	return nil
+}
+
+func openFunc(cmd *cobra.Command, _ []string) error {
+	key, err := loadKey(keyPath)
+	if err != nil {
+		return err
+	}
+
+	if len(rawMemo) > 0 {
+		memo, err := codec.LoadHex(rawMemo, -1)
+		if err != nil {
+			return err
+		}
+		plaintext, err := actions.OpenMemo(key, memo)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(plaintext))
+		return nil
+	}
+
+	if len(uri) == 0 {
+		return errors.New("either --memo or --uri must be provided")
+	}
+	ctx := context.Background()
+	if !cmd.Flags().Changed("from") || !cmd.Flags().Changed("to") {
+		_, height, _, err := jsonrpc.NewJSONRPCClient(uri).Accepted(ctx)
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("from") {
+			fromHeight = height
+		}
+		if !cmd.Flags().Changed("to") {
+			toHeight = height
+		}
+	}
+
+	cli := vm.NewJSONRPCClient(uri)
+	for height := fromHeight; height <= toHeight; height++ {
+		memos, err := cli.ReceivedMemos(ctx, key, height)
+		if err != nil {
+			return fmt.Errorf("%w: failed to read block %d", err, height)
+		}
+		for _, m := range memos {
+			fmt.Printf("height=%d tx=%s sender=%s value=%d memo=%q\n", height, m.TxID, m.Sender, m.Value, m.Memo)
+		}
+	}
+	return nil
+}
+
+// loadKey reads a private key stored either as raw bytes or as hex.
+func loadKey(path string) (ed25519.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ed25519.EmptyPrivateKey, err
+	}
+	if len(b) != ed25519.PrivateKeyLen {
+		b, err = codec.LoadHex(strings.TrimSpace(string(b)), ed25519.PrivateKeyLen)
+		if err != nil {
+			return ed25519.EmptyPrivateKey, fmt.Errorf("%w: failed to load private key", err)
+		}
+	}
+	return ed25519.PrivateKey(b), nil
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package schema
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ava-labs/hypersdk-starter-kit/vm"
+)
+
+var (
+	format     string
+	outputPath string
+)
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+// NewCommand implements "morpheusvm schema" command.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Prints the JSON Schema or OpenAPI document of the actions, outputs and JSON-RPC API",
+		RunE:  schemaFunc,
+	}
+	cmd.Flags().StringVar(&format, "format", vm.FormatJSONSchema, "document format, jsonschema or openapi")
+	cmd.Flags().StringVar(&outputPath, "output", "", "document file (defaults to stdout)")
+	return cmd
+}
+
+func schemaFunc(*cobra.Command, []string) error {
+	doc, err := vm.Schema(format)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if len(outputPath) == 0 {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(outputPath, b, 0o644)
+}
@@ -0,0 +1,172 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/genesis"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+var (
+	ErrUnknownFormat  = errors.New("unknown format")
+	ErrInvalidCSV     = errors.New("invalid csv")
+	ErrDuplicateAlloc = errors.New("duplicate allocation")
+	ErrZeroBalance    = errors.New("zero balance")
+)
+
+var csvHeader = []string{"address", "balance"}
+
+// Snapshot is the set of balances of a chain at [Height].
+type Snapshot struct {
+	Height    uint64                      `json:"height"`
+	StateRoot ids.ID                      `json:"stateRoot"`
+	Balances  []*genesis.CustomAllocation `json:"balances"`
+}
+
+// FormatOf returns the format to use for [path] when [format] is not set.
+func FormatOf(path string, format string) string {
+	if len(format) > 0 {
+		return format
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+// WriteSnapshot writes [s] to [w]. The CSV format only holds the balances.
+func WriteSnapshot(w io.Writer, format string, s *Snapshot) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, alloc := range s.Balances {
+			if err := cw.Write([]string{alloc.Address.String(), strconv.FormatUint(alloc.Balance, 10)}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// ReadAllocations reads the allocations stored at [path]. JSON input is
+// either a [Snapshot] or a list of allocations. CSV input has an
+// "address,balance" header followed by one allocation per row. Every address
+// must appear once, with a non-zero balance.
+func ReadAllocations(path string, format string) ([]*genesis.CustomAllocation, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var allocs []*genesis.CustomAllocation
+	switch FormatOf(path, format) {
+	case FormatJSON:
+		allocs, err = parseJSONAllocations(b)
+	case FormatCSV:
+		allocs, err = parseCSVAllocations(b)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s", err, path)
+	}
+
+	seen := make(map[codec.Address]struct{}, len(allocs))
+	for _, alloc := range allocs {
+		if alloc.Balance == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrZeroBalance, alloc.Address)
+		}
+		if _, ok := seen[alloc.Address]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateAlloc, alloc.Address)
+		}
+		seen[alloc.Address] = struct{}{}
+	}
+	return allocs, nil
+}
+
+// TotalBalance returns the sum of the balances of [allocs].
+func TotalBalance(allocs []*genesis.CustomAllocation) (uint64, error) {
+	var total uint64
+	for _, alloc := range allocs {
+		var err error
+		total, err = smath.Add(total, alloc.Balance)
+		if err != nil {
+			return 0, fmt.Errorf("%w: total balance overflows", err)
+		}
+	}
+	return total, nil
+}
+
+func parseJSONAllocations(b []byte) ([]*genesis.CustomAllocation, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		var allocs []*genesis.CustomAllocation
+		if err := json.Unmarshal(b, &allocs); err != nil {
+			return nil, err
+		}
+		return allocs, nil
+	}
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return s.Balances, nil
+}
+
+func parseCSVAllocations(b []byte) ([]*genesis.CustomAllocation, error) {
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && strings.EqualFold(records[0][0], csvHeader[0]) {
+		records = records[1:]
+	}
+	allocs := make([]*genesis.CustomAllocation, 0, len(records))
+	for i, record := range records {
+		if len(record) != len(csvHeader) {
+			return nil, fmt.Errorf("%w: row %d has %d fields", ErrInvalidCSV, i+1, len(record))
+		}
+		addr, err := codec.StringToAddress(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %w", ErrInvalidCSV, i+1, err)
+		}
+		balance, err := strconv.ParseUint(strings.TrimSpace(record[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %w", ErrInvalidCSV, i+1, err)
+		}
+		allocs = append(allocs, &genesis.CustomAllocation{
+			Address: addr,
+			Balance: balance,
+		})
+	}
+	return allocs, nil
+}
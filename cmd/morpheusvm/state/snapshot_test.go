@@ -0,0 +1,156 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/genesis"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+func TestReadAllocations(t *testing.T) {
+	addr0, addr1 := codectest.NewRandomAddress(), codectest.NewRandomAddress()
+	allocs := []*genesis.CustomAllocation{
+		{Address: addr0, Balance: 1},
+		{Address: addr1, Balance: 2},
+	}
+
+	tests := []struct {
+		name        string
+		file        string
+		format      string
+		content     string
+		expected    []*genesis.CustomAllocation
+		expectedErr error
+	}{
+		{
+			name:     "JSONList",
+			file:     "allocs.json",
+			content:  fmt.Sprintf(`[{"address":%q,"balance":1},{"address":%q,"balance":2}]`, addr0, addr1),
+			expected: allocs,
+		},
+		{
+			name:     "JSONSnapshot",
+			file:     "snapshot.json",
+			content:  fmt.Sprintf(`{"height":3,"balances":[{"address":%q,"balance":1},{"address":%q,"balance":2}]}`, addr0, addr1),
+			expected: allocs,
+		},
+		{
+			name:     "CSVWithHeader",
+			file:     "allocs.csv",
+			content:  fmt.Sprintf("address,balance\n%s,1\n%s, 2\n", addr0, addr1),
+			expected: allocs,
+		},
+		{
+			name:     "CSVWithoutHeader",
+			file:     "allocs.csv",
+			content:  fmt.Sprintf("%s,1\n%s,2\n", addr0, addr1),
+			expected: allocs,
+		},
+		{
+			name:     "FormatOverridesExtension",
+			file:     "allocs.txt",
+			format:   FormatCSV,
+			content:  fmt.Sprintf("%s,1\n%s,2\n", addr0, addr1),
+			expected: allocs,
+		},
+		{
+			name:        "CSVInvalidAddress",
+			file:        "allocs.csv",
+			content:     "address,balance\nnot-an-address,1\n",
+			expectedErr: ErrInvalidCSV,
+		},
+		{
+			name:        "CSVInvalidBalance",
+			file:        "allocs.csv",
+			content:     fmt.Sprintf("%s,-1\n", addr0),
+			expectedErr: ErrInvalidCSV,
+		},
+		{
+			name:        "DuplicateAddress",
+			file:        "allocs.csv",
+			content:     fmt.Sprintf("%s,1\n%s,2\n", addr0, addr0),
+			expectedErr: ErrDuplicateAlloc,
+		},
+		{
+			name:        "ZeroBalance",
+			file:        "allocs.json",
+			content:     fmt.Sprintf(`[{"address":%q,"balance":0}]`, addr0),
+			expectedErr: ErrZeroBalance,
+		},
+		{
+			name:        "UnknownFormat",
+			file:        "allocs.json",
+			format:      "xml",
+			content:     "[]",
+			expectedErr: ErrUnknownFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(os.WriteFile(path, []byte(tt.content), 0o600))
+			got, err := ReadAllocations(path, tt.format)
+			require.ErrorIs(err, tt.expectedErr)
+			if tt.expectedErr == nil {
+				require.Equal(tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	s := &Snapshot{
+		Height: 1,
+		Balances: []*genesis.CustomAllocation{
+			{Address: codectest.NewRandomAddress(), Balance: 1},
+			{Address: codectest.NewRandomAddress(), Balance: math.MaxUint64},
+		},
+	}
+	for _, format := range []string{FormatJSON, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			require := require.New(t)
+
+			var b bytes.Buffer
+			require.NoError(WriteSnapshot(&b, format, s))
+			path := filepath.Join(t.TempDir(), "snapshot."+format)
+			require.NoError(os.WriteFile(path, b.Bytes(), 0o600))
+			got, err := ReadAllocations(path, "")
+			require.NoError(err)
+			require.Equal(s.Balances, got)
+		})
+	}
+}
+
+func TestTotalBalance(t *testing.T) {
+	require := require.New(t)
+
+	total, err := TotalBalance(nil)
+	require.NoError(err)
+	require.Zero(total)
+
+	allocs := []*genesis.CustomAllocation{
+		{Address: codectest.NewRandomAddress(), Balance: math.MaxUint64 - 1},
+		{Address: codectest.NewRandomAddress(), Balance: 1},
+	}
+	total, err = TotalBalance(allocs)
+	require.NoError(err)
+	require.Equal(uint64(math.MaxUint64), total)
+
+	allocs = append(allocs, &genesis.CustomAllocation{Address: codectest.NewRandomAddress(), Balance: 1})
+	_, err = TotalBalance(allocs)
+	require.ErrorIs(err, smath.ErrOverflow)
+}
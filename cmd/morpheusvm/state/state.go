@@ -0,0 +1,234 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/pebbledb"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/spf13/cobra"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk-starter-kit/vm"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state/metadata"
+
+	hvm "github.com/ava-labs/hypersdk/vm"
+)
+
+// stateDBDir is the directory, under the chain data directory, in which the
+// hypersdk stores the VM's state.
+const stateDBDir = "statedb"
+
+var (
+	dataDir         string
+	chainConfigPath string
+
+	snapshotPath string
+	genesisPath  string
+
+	format     string
+	outputPath string
+)
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+// NewCommand implements "morpheusvm state" command.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Exports and imports balance snapshots",
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Writes the balances stored in a stopped node's database",
+		Long: `Writes the balances stored in a stopped node's database.
+
+The snapshot is taken at the last height the node committed to its state
+database, which the snapshot records. Older heights cannot be exported: the
+state database only keeps the latest state on disk. The node must be stopped,
+as it holds a lock on the database while running.`,
+		RunE: exportFunc,
+	}
+	exportCmd.Flags().StringVar(&dataDir, "data-dir", "", "chain data directory of the node")
+	exportCmd.Flags().StringVar(&genesisPath, "genesis", "", "genesis of the chain (defaults to the default genesis)")
+	exportCmd.Flags().StringVar(&chainConfigPath, "chain-config", "", "chain config of the node (defaults to the default config)")
+	exportCmd.Flags().StringVar(&format, "format", "", "snapshot format, json or csv (defaults to the output extension)")
+	exportCmd.Flags().StringVar(&outputPath, "output", "", "snapshot file (defaults to stdout)")
+
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Writes a genesis allocating the balances of a snapshot",
+		RunE:  importFunc,
+	}
+	importCmd.Flags().StringVar(&snapshotPath, "snapshot", "", "snapshot file")
+	importCmd.Flags().StringVar(&format, "format", "", "snapshot format, json or csv (defaults to the snapshot extension)")
+	importCmd.Flags().StringVar(&genesisPath, "genesis", "", "genesis to take everything but the allocations from (defaults to the default genesis)")
+	importCmd.Flags().StringVar(&outputPath, "output", "", "genesis file (defaults to stdout)")
+
+	cmd.AddCommand(exportCmd, importCmd)
+	return cmd
+}
+
+func exportFunc(*cobra.Command, []string) error {
+	g, err := readGenesis(genesisPath)
+	if err != nil {
+		return err
+	}
+	config := hvm.NewConfig()
+	if len(chainConfigPath) > 0 {
+		b, err := os.ReadFile(chainConfigPath)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return fmt.Errorf("%w: failed to parse chain config", err)
+		}
+	}
+
+	db, err := pebbledb.New(filepath.Join(dataDir, stateDBDir), nil, logging.NoLog{}, nil)
+	if err != nil {
+		return fmt.Errorf("%w: failed to open state database", err)
+	}
+	defer db.Close()
+	snapshot, err := Export(context.Background(), db, StateConfig(g, config))
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(outputPath, func(w io.Writer) error {
+		return WriteSnapshot(w, FormatOf(outputPath, format), snapshot)
+	})
+}
+
+// StateConfig returns the config the hypersdk opens the state database of a
+// chain with [g] and [config] with. The state database must be opened with
+// the branch factor it was written with.
+func StateConfig(g genesis.Genesis, config hvm.Config) merkledb.Config {
+	return merkledb.Config{
+		BranchFactor:                g.GetStateBranchFactor(),
+		RootGenConcurrency:          uint(config.RootGenerationCores),
+		HistoryLength:               uint(config.StateHistoryLength),
+		ValueNodeCacheSize:          uint(config.ValueNodeCacheSize),
+		IntermediateNodeCacheSize:   uint(config.IntermediateNodeCacheSize),
+		IntermediateWriteBufferSize: uint(config.StateIntermediateWriteBufferSize),
+		IntermediateWriteBatchSize:  uint(config.StateIntermediateWriteBatchSize),
+		TraceLevel:                  merkledb.NoTrace,
+	}
+}
+
+// Export returns the balances stored in [db], the state database of a
+// stopped node, at the last height the node committed.
+func Export(ctx context.Context, db database.Database, config merkledb.Config) (*Snapshot, error) {
+	stateDB, err := merkledb.New(ctx, db, config)
+	if err != nil {
+		return nil, err
+	}
+	defer stateDB.Close()
+
+	heightBytes, err := stateDB.Get(chain.HeightKey(metadata.NewDefaultManager().HeightPrefix()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read state height", err)
+	}
+	height, err := database.ParseUInt64(heightBytes)
+	if err != nil {
+		return nil, err
+	}
+	root, err := stateDB.GetMerkleRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Height:    height,
+		StateRoot: root,
+		Balances:  []*genesis.CustomAllocation{},
+	}
+	if err := storage.IterateBalances(stateDB, func(addr codec.Address, balance uint64) error {
+		snapshot.Balances = append(snapshot.Balances, &genesis.CustomAllocation{
+			Address: addr,
+			Balance: balance,
+		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func importFunc(*cobra.Command, []string) error {
+	allocs, err := ReadAllocations(snapshotPath, format)
+	if err != nil {
+		return err
+	}
+	g, err := readGenesis(genesisPath)
+	if err != nil {
+		return err
+	}
+	if err := Import(g, allocs); err != nil {
+		return err
+	}
+
+	return writeOutput(outputPath, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	})
+}
+
+// Import replaces the allocations of [g] with [allocs].
+func Import(g *vm.Genesis, allocs []*genesis.CustomAllocation) error {
+	if _, err := TotalBalance(allocs); err != nil {
+		return err
+	}
+	g.CustomAllocation = allocs
+	return nil
+}
+
+// readGenesis reads the genesis at [path], or returns the default genesis if
+// [path] is empty.
+func readGenesis(path string) (*vm.Genesis, error) {
+	g := &vm.Genesis{DefaultGenesis: genesis.NewDefaultGenesis(nil)}
+	if len(path) == 0 {
+		return g, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, g); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse genesis", err)
+	}
+	return g, nil
+}
+
+// writeOutput calls [write] with the file at [path], or with stdout if
+// [path] is empty.
+func writeOutput(path string, write func(io.Writer) error) error {
+	if len(path) == 0 {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state/metadata"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+	hvm "github.com/ava-labs/hypersdk/vm"
+)
+
+// batchState collects the writes of the storage helpers.
+type batchState struct {
+	ops []database.BatchOp
+}
+
+func (*batchState) GetValue(context.Context, []byte) ([]byte, error) {
+	return nil, database.ErrNotFound
+}
+
+func (b *batchState) Insert(_ context.Context, key []byte, value []byte) error {
+	b.ops = append(b.ops, database.BatchOp{Key: key, Value: value})
+	return nil
+}
+
+func (b *batchState) Remove(_ context.Context, key []byte) error {
+	b.ops = append(b.ops, database.BatchOp{Key: key, Delete: true})
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	g, err := readGenesis("")
+	require.NoError(err)
+	config := StateConfig(g, hvm.NewConfig())
+
+	// Write the state a node would leave behind at height 7.
+	db := memdb.New()
+	stateDB, err := merkledb.New(ctx, db, config)
+	require.NoError(err)
+	s := &batchState{}
+	require.NoError(s.Insert(ctx, chain.HeightKey(metadata.NewDefaultManager().HeightPrefix()), database.PackUInt64(7)))
+	balances := map[codec.Address]uint64{
+		codectest.NewRandomAddress(): 1,
+		codectest.NewRandomAddress(): 2,
+		codectest.NewRandomAddress(): 3,
+	}
+	for addr, balance := range balances {
+		require.NoError(storage.SetBalance(ctx, s, addr, balance))
+	}
+	view, err := stateDB.NewView(ctx, merkledb.ViewChanges{BatchOps: s.ops})
+	require.NoError(err)
+	require.NoError(view.CommitToDB(ctx))
+	root, err := stateDB.GetMerkleRoot(ctx)
+	require.NoError(err)
+	require.NoError(stateDB.Close())
+
+	snapshot, err := Export(ctx, db, config)
+	require.NoError(err)
+	require.Equal(uint64(7), snapshot.Height)
+	require.Equal(root, snapshot.StateRoot)
+	require.Len(snapshot.Balances, len(balances))
+	for _, alloc := range snapshot.Balances {
+		require.Equal(balances[alloc.Address], alloc.Balance)
+	}
+}
+
+func TestExportWithoutHeight(t *testing.T) {
+	g, err := readGenesis("")
+	require.NoError(t, err)
+
+	_, err = Export(context.Background(), memdb.New(), StateConfig(g, hvm.NewConfig()))
+	require.ErrorIs(t, err, database.ErrNotFound)
+}
+
+func TestImport(t *testing.T) {
+	require := require.New(t)
+
+	g, err := readGenesis("")
+	require.NoError(err)
+	g.StateBranchFactor = merkledb.BranchFactor4
+	allocs := []*genesis.CustomAllocation{
+		{Address: codectest.NewRandomAddress(), Balance: 1},
+		{Address: codectest.NewRandomAddress(), Balance: 2},
+	}
+	require.NoError(Import(g, allocs))
+	require.Equal(allocs, g.CustomAllocation)
+	// Everything but the allocations is kept.
+	require.Equal(merkledb.BranchFactor4, g.StateBranchFactor)
+
+	allocs = append(allocs, &genesis.CustomAllocation{Address: codectest.NewRandomAddress(), Balance: ^uint64(0)})
+	require.ErrorIs(Import(g, allocs), smath.ErrOverflow)
+}
@@ -0,0 +1,253 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Relayer watches two MorpheusVM chains and completes bridged transfers
+// between them: every successful BridgeLock on one chain is minted on the
+// other with BridgeMint, and every successful BridgeBurn is released with
+// BridgeUnlock.
+//
+// It is meant for local testing and signs with every relayer key it is
+// given, so a single process can reach the quorum on its own. It is
+// configured with the following environment variables:
+//
+//	CHAIN_A_URI    - URI of the first chain (e.g. http://127.0.0.1:9650/ext/bc/<chainID>)
+//	CHAIN_B_URI    - URI of the second chain
+//	RELAYER_KEYS   - comma separated hex encoded BLS private keys of relayers
+//	                 listed in the bridge config of both chains. The first key
+//	                 pays the fees and must be funded on both chains.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/vm"
+	"github.com/ava-labs/hypersdk/api/jsonrpc"
+	"github.com/ava-labs/hypersdk/api/ws"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/pubsub"
+)
+
+type chainClient struct {
+	uri         string
+	chainID     ids.ID
+	parser      chain.Parser
+	hyperSDKRPC *jsonrpc.JSONRPCClient
+	hyperVMRPC  *vm.JSONRPCClient
+}
+
+func newChainClient(ctx context.Context, uri string) (*chainClient, error) {
+	c := &chainClient{
+		uri:         uri,
+		hyperSDKRPC: jsonrpc.NewJSONRPCClient(uri),
+		hyperVMRPC:  vm.NewJSONRPCClient(uri),
+	}
+	_, _, chainID, err := c.hyperSDKRPC.Network(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network of %s: %w", uri, err)
+	}
+	c.chainID = chainID
+	c.parser, err = c.hyperVMRPC.Parser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parser of %s: %w", uri, err)
+	}
+	return c, nil
+}
+
+type relayer struct {
+	keys    []*bls.PrivateKey
+	factory chain.AuthFactory
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	r, err := loadRelayer(os.Getenv("RELAYER_KEYS"))
+	if err != nil {
+		log.Fatalf("failed to load relayer keys: %v", err)
+	}
+	log.Printf("Relayer address: %s\n", r.factory.Address())
+
+	chainA, err := newChainClient(ctx, os.Getenv("CHAIN_A_URI"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	chainB, err := newChainClient(ctx, os.Getenv("CHAIN_B_URI"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Relaying between %s and %s\n", chainA.chainID, chainB.chainID)
+
+	errs := make(chan error, 2)
+	go func() { errs <- r.relay(ctx, chainA, chainB) }()
+	go func() { errs <- r.relay(ctx, chainB, chainA) }()
+	if err := <-errs; err != nil && ctx.Err() == nil {
+		log.Fatal(err)
+	}
+}
+
+func loadRelayer(keys string) (*relayer, error) {
+	r := &relayer{}
+	for _, key := range strings.Split(keys, ",") {
+		b, err := codec.LoadHex(strings.TrimSpace(key), bls.PrivateKeyLen)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := bls.PrivateKeyFromBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		r.keys = append(r.keys, priv)
+	}
+	r.factory = auth.NewBLSFactory(r.keys[0])
+	return r, nil
+}
+
+// relay completes on [dst] the bridged transfers accepted on [src].
+func (r *relayer) relay(ctx context.Context, src *chainClient, dst *chainClient) error {
+	wsClient, err := ws.NewWebSocketClient(src.uri, ws.DefaultHandshakeTimeout, pubsub.MaxPendingMessages, pubsub.MaxReadMessageSize)
+	if err != nil {
+		return fmt.Errorf("failed to create WebSocket client: %w", err)
+	}
+	defer wsClient.Close()
+	if err := wsClient.RegisterBlocks(); err != nil {
+		return fmt.Errorf("failed to register for blocks: %w", err)
+	}
+
+	for {
+		blk, results, _, err := wsClient.ListenBlock(ctx, src.parser)
+		if err != nil {
+			return fmt.Errorf("failed to listen for blocks: %w", err)
+		}
+		for i, tx := range blk.Txs {
+			if !results[i].Success {
+				continue
+			}
+			for j, action := range tx.Actions {
+				msg := bridgeMessage(src.chainID, dst.chainID, chain.CreateActionID(tx.ID(), uint8(j)), action)
+				if msg == nil {
+					continue
+				}
+				if err := r.submit(ctx, dst, msg); err != nil {
+					log.Printf("Failed to relay transfer %s from %s: %v\n", msg.TransferID, src.chainID, err)
+				}
+			}
+		}
+	}
+}
+
+// bridgeMessage returns the message to relay to [dst] for [action], or nil if
+// [action] is not a bridged transfer to [dst].
+func bridgeMessage(src ids.ID, dst ids.ID, transferID ids.ID, action chain.Action) *actions.BridgeMessage {
+	switch a := action.(type) {
+	case *actions.BridgeLock:
+		if a.DestinationChainID != dst {
+			return nil
+		}
+		return &actions.BridgeMessage{
+			Kind:               actions.BridgeMessageMint,
+			SourceChainID:      src,
+			DestinationChainID: dst,
+			TransferID:         transferID,
+			Recipient:          a.Recipient,
+			Value:              a.Value,
+		}
+	case *actions.BridgeBurn:
+		if a.DestinationChainID != dst {
+			return nil
+		}
+		return &actions.BridgeMessage{
+			Kind:               actions.BridgeMessageUnlock,
+			SourceChainID:      src,
+			DestinationChainID: dst,
+			TransferID:         transferID,
+			Recipient:          a.Recipient,
+			Value:              a.Value,
+		}
+	default:
+		return nil
+	}
+}
+
+func (r *relayer) submit(ctx context.Context, dst *chainClient, msg *actions.BridgeMessage) error {
+	processed, err := dst.hyperVMRPC.BridgeTransfer(ctx, msg.SourceChainID, msg.TransferID)
+	if err != nil {
+		return err
+	}
+	if processed {
+		return nil
+	}
+	signatures, err := r.sign(ctx, dst, msg)
+	if err != nil {
+		return err
+	}
+
+	var action chain.Action
+	switch msg.Kind {
+	case actions.BridgeMessageMint:
+		action = &actions.BridgeMint{
+			SourceChainID: msg.SourceChainID,
+			TransferID:    msg.TransferID,
+			Recipient:     msg.Recipient,
+			Value:         msg.Value,
+			Signatures:    signatures,
+		}
+	case actions.BridgeMessageUnlock:
+		action = &actions.BridgeUnlock{
+			SourceChainID: msg.SourceChainID,
+			TransferID:    msg.TransferID,
+			Recipient:     msg.Recipient,
+			Value:         msg.Value,
+			Signatures:    signatures,
+		}
+	}
+	send, tx, _, err := dst.hyperSDKRPC.GenerateTransaction(ctx, dst.parser, []chain.Action{action}, r.factory)
+	if err != nil {
+		return fmt.Errorf("failed to generate transaction: %w", err)
+	}
+	if err := send(ctx); err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+	log.Printf("Relayed transfer %s from %s to %s in tx %s\n", msg.TransferID, msg.SourceChainID, msg.DestinationChainID, tx.ID())
+	return nil
+}
+
+// sign returns the signatures of every key held by [r] that is a relayer on
+// [dst].
+func (r *relayer) sign(ctx context.Context, dst *chainClient, msg *actions.BridgeMessage) ([]byte, error) {
+	bridge, err := dst.hyperVMRPC.Bridge(ctx, msg.SourceChainID)
+	if err != nil {
+		return nil, err
+	}
+	if bridge.Config == nil {
+		return nil, actions.ErrBridgeNotConfigured
+	}
+
+	var sigs []*auth.BLS
+	for _, key := range r.keys {
+		pk := bls.PublicKeyToBytes(bls.PublicFromPrivateKey(key))
+		if !slices.ContainsFunc(bridge.Config.Relayers, func(relayer codec.Bytes) bool {
+			return string(relayer) == string(pk)
+		}) {
+			continue
+		}
+		sigs = append(sigs, actions.SignBridgeMessage(key, msg))
+	}
+	if len(sigs) < int(bridge.Config.Quorum) {
+		return nil, fmt.Errorf("%w: have %d of %d", actions.ErrInsufficientSigners, len(sigs), bridge.Config.Quorum)
+	}
+	return actions.MarshalBridgeSignatures(sigs), nil
+}
@@ -5,5 +5,15 @@ package consts
 
 const (
 	// Action TypeIDs
-	TransferID uint8 = 0
+	TransferID           uint8 = 0
+	SetSpendingLimitID   uint8 = 1
+	CreateSubscriptionID uint8 = 2
+	ChargeSubscriptionID uint8 = 3
+	CancelSubscriptionID uint8 = 4
+	SweepID              uint8 = 5
+	BridgeLockID         uint8 = 6
+	BridgeMintID         uint8 = 7
+	BridgeBurnID         uint8 = 8
+	BridgeUnlockID       uint8 = 9
+	MigrateID            uint8 = 10
 )
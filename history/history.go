@@ -0,0 +1,301 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package history
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"slices"
+	"sync/atomic"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/pebbledb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/event"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+const (
+	// Namespace is the directory, under the VM's data directory, that holds
+	// the index.
+	Namespace = "history"
+
+	DefaultLimit = 100
+	MaxLimit     = 1024
+
+	entryPrefix  byte = 0x0
+	heightPrefix byte = 0x1
+
+	// A cursor is the inverted height and transaction index of an entry.
+	cursorLen = consts.Uint64Len + consts.Uint32Len
+	entryLen  = ids.IDLen + consts.Uint64Len + consts.Int64Len + consts.BoolLen + 3*consts.Uint64Len
+)
+
+var (
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+var _ event.Subscription[*chain.ExecutedBlock] = (*Indexer)(nil)
+
+// Entry is a transaction that touched an address.
+type Entry struct {
+	TxID      ids.ID `json:"txId"`
+	Height    uint64 `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Success   bool   `json:"success"`
+
+	// Sent and Received are the values the transaction moved out of and
+	// into the address. They are zero for failed transactions.
+	Sent     uint64 `json:"sent"`
+	Received uint64 `json:"received"`
+
+	// Fee is the fee the address paid as the sponsor of the transaction.
+	Fee uint64 `json:"fee"`
+}
+
+// Indexer records, for every address, the transactions of the accepted blocks
+// that touched it. Entries are kept newest first.
+type Indexer struct {
+	db         database.Database
+	parser     chain.Parser
+	lastHeight atomic.Uint64
+}
+
+// New opens the index stored at [path]. [parser] decodes the outputs of the
+// indexed transactions.
+func New(path string, parser chain.Parser) (*Indexer, error) {
+	db, err := pebbledb.New(path, nil, logging.NoLog{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newIndexer(db, parser)
+}
+
+func newIndexer(db database.Database, parser chain.Parser) (*Indexer, error) {
+	i := &Indexer{
+		db:     db,
+		parser: parser,
+	}
+	b, err := db.Get([]byte{heightPrefix})
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		height, err := database.ParseUInt64(b)
+		if err != nil {
+			return nil, err
+		}
+		i.lastHeight.Store(height)
+	}
+	return i, nil
+}
+
+// Height returns the height of the last indexed block.
+func (i *Indexer) Height() uint64 {
+	return i.lastHeight.Load()
+}
+
+func (i *Indexer) Accept(blk *chain.ExecutedBlock) error {
+	batch := i.db.NewBatch()
+	for txIndex, tx := range blk.Block.Txs {
+		entries, err := i.entries(tx, blk.Results[txIndex])
+		if err != nil {
+			return err
+		}
+		for addr, entry := range entries {
+			entry.Height = blk.Block.Hght
+			entry.Timestamp = blk.Block.Tmstmp
+			if err := batch.Put(entryKey(addr, blk.Block.Hght, uint32(txIndex)), entry.bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	if err := batch.Put([]byte{heightPrefix}, database.PackUInt64(blk.Block.Hght)); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	i.lastHeight.Store(blk.Block.Hght)
+	return nil
+}
+
+func (i *Indexer) Close() error {
+	return i.db.Close()
+}
+
+// entries returns the entry of every address [tx] touched.
+func (i *Indexer) entries(tx *chain.Transaction, result *chain.Result) (map[codec.Address]*Entry, error) {
+	entries := map[codec.Address]*Entry{}
+	entry := func(addr codec.Address) *Entry {
+		e, ok := entries[addr]
+		if !ok {
+			e = &Entry{TxID: tx.ID(), Success: result.Success}
+			entries[addr] = e
+		}
+		return e
+	}
+	entry(tx.Sponsor()).Fee = result.Fee
+
+	actor := tx.Auth.Actor()
+	entry(actor)
+	if !result.Success {
+		return entries, nil
+	}
+	for j, action := range tx.Actions {
+		var (
+			from, to codec.Address
+			value    uint64
+		)
+		switch a := action.(type) {
+		case *actions.Transfer:
+			from, to, value = actor, a.To, a.Value
+		case *actions.Sweep:
+			output, err := i.output(result, j)
+			if err != nil {
+				return nil, err
+			}
+			r, ok := output.(*actions.SweepResult)
+			if !ok {
+				continue
+			}
+			from, to, value = actor, a.To, r.Value
+		case *actions.ChargeSubscription:
+			output, err := i.output(result, j)
+			if err != nil {
+				return nil, err
+			}
+			r, ok := output.(*actions.ChargeSubscriptionResult)
+			if !ok {
+				continue
+			}
+			from, to, value = a.Payer, actor, r.Amount
+		case *actions.CreateSubscription:
+			entry(a.Payee)
+			continue
+		case *actions.CancelSubscription:
+			entry(a.Payee)
+			continue
+		case *actions.BridgeLock:
+			entry(actor).Sent = add(entry(actor).Sent, a.Value)
+			continue
+		case *actions.BridgeBurn:
+			entry(actor).Sent = add(entry(actor).Sent, a.Value)
+			continue
+		case *actions.BridgeMint:
+			entry(a.Recipient).Received = add(entry(a.Recipient).Received, a.Value)
+			continue
+		case *actions.BridgeUnlock:
+			entry(a.Recipient).Received = add(entry(a.Recipient).Received, a.Value)
+			continue
+		default:
+			continue
+		}
+		entry(from).Sent = add(entry(from).Sent, value)
+		entry(to).Received = add(entry(to).Received, value)
+	}
+	return entries, nil
+}
+
+// add saturates instead of overflowing, which can only happen when the same
+// value is moved back and forth within a transaction.
+func add(a, b uint64) uint64 {
+	sum, err := smath.Add(a, b)
+	if err != nil {
+		return math.MaxUint64
+	}
+	return sum
+}
+
+// output returns the output of the action at [index], or nil if [result] has
+// none. Entries whose output is missing or of another type are skipped by
+// [entries] rather than failing the block.
+func (i *Indexer) output(result *chain.Result, index int) (codec.Typed, error) {
+	if index >= len(result.Outputs) {
+		return nil, nil
+	}
+	b := result.Outputs[index]
+	return i.parser.OutputCodec().Unmarshal(codec.NewReader(b, len(b)))
+}
+
+// History returns up to [limit] entries of [addr], newest first, starting at
+// [cursor]. An empty cursor starts at the newest entry. The returned cursor
+// is empty once there are no more entries.
+func (i *Indexer) History(addr codec.Address, cursor []byte, limit int) ([]*Entry, []byte, error) {
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if limit < 0 || limit > MaxLimit {
+		return nil, nil, ErrInvalidLimit
+	}
+	if len(cursor) != 0 && len(cursor) != cursorLen {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	prefix := addressPrefix(addr)
+	it := i.db.NewIteratorWithStartAndPrefix(append(prefix, cursor...), prefix)
+	defer it.Release()
+
+	entries := []*Entry{}
+	for it.Next() {
+		if len(entries) == limit {
+			return entries, slices.Clone(it.Key()[len(prefix):]), nil
+		}
+		entry, err := parseEntry(it.Value())
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil, it.Error()
+}
+
+func addressPrefix(addr codec.Address) []byte {
+	k := make([]byte, 0, 1+codec.AddressLen+cursorLen)
+	k = append(k, entryPrefix)
+	return append(k, addr[:]...)
+}
+
+// entryKey inverts [height] and [txIndex] so that iterating over the keys of
+// an address yields its newest entries first.
+func entryKey(addr codec.Address, height uint64, txIndex uint32) []byte {
+	k := addressPrefix(addr)
+	k = binary.BigEndian.AppendUint64(k, math.MaxUint64-height)
+	return binary.BigEndian.AppendUint32(k, math.MaxUint32-txIndex)
+}
+
+func (e *Entry) bytes() []byte {
+	p := codec.NewWriter(entryLen, entryLen)
+	p.PackID(e.TxID)
+	p.PackUint64(e.Height)
+	p.PackInt64(e.Timestamp)
+	p.PackBool(e.Success)
+	p.PackUint64(e.Sent)
+	p.PackUint64(e.Received)
+	p.PackUint64(e.Fee)
+	return p.Bytes()
+}
+
+func parseEntry(b []byte) (*Entry, error) {
+	p := codec.NewReader(b, entryLen)
+	e := &Entry{}
+	p.UnpackID(false, &e.TxID)
+	e.Height = p.UnpackUint64(false)
+	e.Timestamp = p.UnpackInt64(false)
+	e.Success = p.UnpackBool()
+	e.Sent = p.UnpackUint64(false)
+	e.Received = p.UnpackUint64(false)
+	e.Fee = p.UnpackUint64(false)
+	return e, p.Err()
+}
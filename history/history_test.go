@@ -0,0 +1,164 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package history
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/genesis"
+)
+
+var _ chain.Parser = (*testParser)(nil)
+
+type testParser struct {
+	outputs *codec.TypeParser[codec.Typed]
+}
+
+func (*testParser) Rules(int64) chain.Rules {
+	return genesis.NewDefaultRules()
+}
+
+func (*testParser) ActionCodec() *codec.TypeParser[chain.Action] {
+	return nil
+}
+
+func (p *testParser) OutputCodec() *codec.TypeParser[codec.Typed] {
+	return p.outputs
+}
+
+func (*testParser) AuthCodec() *codec.TypeParser[chain.Auth] {
+	return nil
+}
+
+func newTestParser(t *testing.T) chain.Parser {
+	outputs := codec.NewTypeParser[codec.Typed]()
+	require.NoError(t, outputs.Register(&actions.SweepResult{}, nil))
+	return &testParser{outputs: outputs}
+}
+
+func newTx(t *testing.T, key ed25519.PrivateKey, actions ...chain.Action) *chain.Transaction {
+	tx, err := chain.NewTxData(&chain.Base{ChainID: ids.Empty, Timestamp: 1, MaxFee: 1}, actions).Sign(auth.NewED25519Factory(key))
+	require.NoError(t, err)
+	return tx
+}
+
+func newBlock(height uint64, txs []*chain.Transaction, results []*chain.Result) *chain.ExecutedBlock {
+	return &chain.ExecutedBlock{
+		Block: &chain.StatelessBlock{
+			Hght:   height,
+			Tmstmp: int64(height) * 1000,
+			Txs:    txs,
+		},
+		Results: results,
+	}
+}
+
+func TestIndexer(t *testing.T) {
+	require := require.New(t)
+
+	key, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	sender := auth.NewED25519Address(key.PublicKey())
+	receiver := codectest.NewRandomAddress()
+
+	sweepOutput, err := chain.MarshalTyped(&actions.SweepResult{Value: 7, ReceiverBalance: 7})
+	require.NoError(err)
+
+	txs := []*chain.Transaction{
+		newTx(t, key, &actions.Transfer{To: receiver, Value: 3}, &actions.Transfer{To: receiver, Value: 4}),
+		newTx(t, key, &actions.Transfer{To: receiver, Value: 5}),
+		newTx(t, key, &actions.Sweep{To: receiver}),
+	}
+	results := []*chain.Result{
+		{Success: true, Outputs: [][]byte{{}, {}}, Fee: 1},
+		{Success: false, Fee: 2},
+		{Success: true, Outputs: [][]byte{sweepOutput}, Fee: 3},
+	}
+
+	db := memdb.New()
+	i, err := newIndexer(db, newTestParser(t))
+	require.NoError(err)
+	require.NoError(i.Accept(newBlock(1, txs[:2], results[:2])))
+	require.NoError(i.Accept(newBlock(2, txs[2:], results[2:])))
+	require.Equal(uint64(2), i.Height())
+
+	entries, next, err := i.History(sender, nil, 0)
+	require.NoError(err)
+	require.Nil(next)
+	require.Equal([]*Entry{
+		{TxID: txs[2].ID(), Height: 2, Timestamp: 2000, Success: true, Sent: 7, Fee: 3},
+		{TxID: txs[1].ID(), Height: 1, Timestamp: 1000, Success: false, Fee: 2},
+		{TxID: txs[0].ID(), Height: 1, Timestamp: 1000, Success: true, Sent: 7, Fee: 1},
+	}, entries)
+
+	// Failed transactions only touch the sponsor.
+	entries, next, err = i.History(receiver, nil, 1)
+	require.NoError(err)
+	require.Equal([]*Entry{
+		{TxID: txs[2].ID(), Height: 2, Timestamp: 2000, Success: true, Received: 7},
+	}, entries)
+	entries, next, err = i.History(receiver, next, 1)
+	require.NoError(err)
+	require.Nil(next)
+	require.Equal([]*Entry{
+		{TxID: txs[0].ID(), Height: 1, Timestamp: 1000, Success: true, Received: 7},
+	}, entries)
+
+	_, _, err = i.History(sender, nil, MaxLimit+1)
+	require.ErrorIs(err, ErrInvalidLimit)
+	_, _, err = i.History(sender, []byte{1}, 1)
+	require.ErrorIs(err, ErrInvalidCursor)
+
+	// The height survives a restart.
+	i, err = newIndexer(db, newTestParser(t))
+	require.NoError(err)
+	require.Equal(uint64(2), i.Height())
+}
+
+func TestIndexerMismatchedOutputs(t *testing.T) {
+	require := require.New(t)
+
+	key, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	sender := auth.NewED25519Address(key.PublicKey())
+	payer := codectest.NewRandomAddress()
+
+	sweepOutput, err := chain.MarshalTyped(&actions.SweepResult{Value: 7, ReceiverBalance: 7})
+	require.NoError(err)
+
+	// A charge whose output is not a charge result and a sweep without an
+	// output only record the fee.
+	txs := []*chain.Transaction{
+		newTx(t, key, &actions.ChargeSubscription{SubscriptionID: ids.GenerateTestID(), Payer: payer}),
+		newTx(t, key, &actions.Sweep{To: payer}),
+	}
+	results := []*chain.Result{
+		{Success: true, Outputs: [][]byte{sweepOutput}, Fee: 1},
+		{Success: true, Fee: 2},
+	}
+
+	i, err := newIndexer(memdb.New(), newTestParser(t))
+	require.NoError(err)
+	require.NoError(i.Accept(newBlock(1, txs, results)))
+
+	entries, _, err := i.History(sender, nil, 0)
+	require.NoError(err)
+	require.Equal([]*Entry{
+		{TxID: txs[1].ID(), Height: 1, Timestamp: 1000, Success: true, Fee: 2},
+		{TxID: txs[0].ID(), Height: 1, Timestamp: 1000, Success: true, Fee: 1},
+	}, entries)
+	entries, _, err = i.History(payer, nil, 0)
+	require.NoError(err)
+	require.Empty(entries)
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package invariant
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/memdb"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+)
+
+// Harness executes blocks of transactions on an in-memory state and checks
+// the invariants after every block. It is meant for tests.
+type Harness struct {
+	db      database.Database
+	bh      chain.BalanceHandler
+	rules   chain.Rules
+	checker *Checker
+
+	// supply is the expected sum of balances: the genesis supply, minus the
+	// fees, plus the declared mints and minus the declared burns.
+	supply *big.Int
+}
+
+// NewHarness returns a harness whose state holds the [genesis] balances.
+func NewHarness(rules chain.Rules, bh chain.BalanceHandler, genesis map[codec.Address]uint64) (*Harness, error) {
+	h := &Harness{
+		db:      memdb.New(),
+		bh:      bh,
+		rules:   rules,
+		checker: NewChecker(),
+		supply:  new(big.Int),
+	}
+	ctx := context.Background()
+	for addr, balance := range genesis {
+		if err := storage.SetBalance(ctx, h, addr, balance); err != nil {
+			return nil, err
+		}
+		h.supply.Add(h.supply, new(big.Int).SetUint64(balance))
+	}
+	return h, nil
+}
+
+// Execute executes [txs] as a block at [timestamp], charging every
+// transaction its max fee, and returns the invariants they broke.
+func (h *Harness) Execute(ctx context.Context, timestamp int64, txs ...*chain.Transaction) ([]*Violation, error) {
+	fees := make([]uint64, len(txs))
+	for i, tx := range txs {
+		fees[i] = tx.Base.MaxFee
+	}
+	s, expected, violations, err := h.checker.checkBlock(ctx, h, h.bh, h.rules, timestamp, txs, fees, nil)
+	if err != nil {
+		return nil, err
+	}
+	batch := h.db.NewBatch()
+	for k, v := range s.writes {
+		if v == nil {
+			err = batch.Delete([]byte(k))
+		} else {
+			err = batch.Put([]byte(k), v)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	h.supply.Add(h.supply, expected)
+
+	total, err := h.Total()
+	if err != nil {
+		return nil, err
+	}
+	if total.Cmp(h.supply) != 0 {
+		violations = append(violations, &Violation{
+			Err: fmt.Errorf("%w: balances sum to %s, expected %s", ErrSupplyMismatch, total, h.supply),
+		})
+	}
+	return violations, nil
+}
+
+// Supply returns the expected sum of balances.
+func (h *Harness) Supply() *big.Int {
+	return new(big.Int).Set(h.supply)
+}
+
+// Total returns the sum of balances in state.
+func (h *Harness) Total() (*big.Int, error) {
+	total := new(big.Int)
+	err := storage.IterateBalances(h.db, func(_ codec.Address, balance uint64) error {
+		total.Add(total, new(big.Int).SetUint64(balance))
+		return nil
+	})
+	return total, err
+}
+
+func (h *Harness) GetValue(_ context.Context, key []byte) ([]byte, error) {
+	return h.db.Get(key)
+}
+
+func (h *Harness) Insert(_ context.Context, key []byte, value []byte) error {
+	return h.db.Put(key, value)
+}
+
+func (h *Harness) Remove(_ context.Context, key []byte) error {
+	return h.db.Delete(key)
+}
@@ -0,0 +1,339 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package invariant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/state"
+	"github.com/ava-labs/hypersdk/state/tstate"
+)
+
+var (
+	ErrSupplyMismatch   = errors.New("balances do not match the supply")
+	ErrUndeclaredPrefix = errors.New("key written outside of the declared prefixes")
+	ErrUndeclaredKey    = errors.New("key accessed without a declared permission")
+	ErrResultMismatch   = errors.New("result does not match the block")
+)
+
+// SupplyChanger is implemented by actions that change the sum of balances.
+// Every other action must leave it unchanged.
+type SupplyChanger interface {
+	// SupplyChange returns the amount added to, and removed from, the sum of
+	// balances when the action succeeds.
+	SupplyChange() (minted uint64, burned uint64)
+}
+
+// Violation is an invariant broken by a transaction. [TxID] is empty if the
+// violation cannot be attributed to a single transaction.
+type Violation struct {
+	TxID ids.ID
+	Err  error
+}
+
+func (v *Violation) Error() string {
+	if v.TxID == ids.Empty {
+		return v.Err.Error()
+	}
+	return fmt.Sprintf("tx %s: %s", v.TxID, v.Err)
+}
+
+func (v *Violation) Unwrap() error {
+	return v.Err
+}
+
+// Change is the value of a key before and after a transaction. A nil value
+// means the key does not exist.
+type Change struct {
+	Key    []byte
+	Before []byte
+	After  []byte
+}
+
+// Checker checks the state changes of every transaction.
+type Checker struct {
+	prefixes [][]byte
+}
+
+// NewChecker returns a checker that only allows transactions to write keys
+// under the prefixes registered in [storage.Registry].
+func NewChecker() *Checker {
+	return &Checker{prefixes: storage.Registry().Prefixes()}
+}
+
+// CheckTx checks the [changes] made by [tx], which charged [fee] to its
+// sponsor and ran its actions if [success]. It returns the change to the
+// sum of balances that [tx] declared, and the invariants it broke.
+func (c *Checker) CheckTx(tx *chain.Transaction, success bool, fee uint64, changes []*Change) (*big.Int, []*Violation) {
+	violations := []*Violation{}
+	actual := new(big.Int)
+	for _, change := range changes {
+		if !c.declared(change.Key) {
+			violations = append(violations, &Violation{
+				TxID: tx.ID(),
+				Err:  fmt.Errorf("%w: %x", ErrUndeclaredPrefix, change.Key),
+			})
+			continue
+		}
+		if _, ok := storage.ParseBalanceKey(change.Key); !ok {
+			continue
+		}
+		before, err := balance(change.Before)
+		if err != nil {
+			violations = append(violations, &Violation{TxID: tx.ID(), Err: err})
+			continue
+		}
+		after, err := balance(change.After)
+		if err != nil {
+			violations = append(violations, &Violation{TxID: tx.ID(), Err: err})
+			continue
+		}
+		actual.Add(actual, after)
+		actual.Sub(actual, before)
+	}
+
+	expected := new(big.Int).Neg(new(big.Int).SetUint64(fee))
+	if success {
+		for _, action := range tx.Actions {
+			sc, ok := action.(SupplyChanger)
+			if !ok {
+				continue
+			}
+			minted, burned := sc.SupplyChange()
+			expected.Add(expected, new(big.Int).SetUint64(minted))
+			expected.Sub(expected, new(big.Int).SetUint64(burned))
+		}
+	}
+	if actual.Cmp(expected) != 0 {
+		violations = append(violations, &Violation{
+			TxID: tx.ID(),
+			Err:  fmt.Errorf("%w: balances changed by %s, expected %s", ErrSupplyMismatch, actual, expected),
+		})
+	}
+	return expected, violations
+}
+
+func (c *Checker) declared(k []byte) bool {
+	for _, prefix := range c.prefixes {
+		if bytes.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func balance(v []byte) (*big.Int, error) {
+	if v == nil {
+		return new(big.Int), nil
+	}
+	bal, err := storage.Uint64Codec{}.Decode(v)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(bal), nil
+}
+
+// Execute runs [tx] on top of [im] the way the chain does: [fee] is charged
+// to its sponsor, then its actions run and are reverted if any of them
+// fails. As on chain, [tx] can only access the keys it declared, with the
+// permissions it declared for them, and any other access fails. It returns
+// whether the actions succeeded, the keys [tx] changed and the accesses that
+// were denied.
+func Execute(
+	ctx context.Context,
+	im state.Immutable,
+	bh chain.BalanceHandler,
+	rules chain.Rules,
+	timestamp int64,
+	tx *chain.Transaction,
+	fee uint64,
+) (bool, []*Change, []*Violation, error) {
+	scope, err := tx.StateKeys(bh)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	s := newOverlay(im)
+	s.scope = scope
+	if err := bh.Deduct(ctx, tx.Auth.Sponsor(), s, fee); err != nil {
+		return false, nil, nil, err
+	}
+	charged := maps.Clone(s.writes)
+	success := true
+	for i, action := range tx.Actions {
+		if _, err := action.Execute(ctx, rules, s, timestamp, tx.Auth.Actor(), chain.CreateActionID(tx.ID(), uint8(i))); err != nil {
+			s.writes = charged
+			success = false
+			break
+		}
+	}
+	changes, err := s.changes(ctx)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	violations := make([]*Violation, len(s.denied))
+	for i, err := range s.denied {
+		violations[i] = &Violation{TxID: tx.ID(), Err: err}
+	}
+	return success, changes, violations, nil
+}
+
+var _ state.Mutable = (*overlay)(nil)
+
+// overlay records writes on top of an immutable state. A nil value means the
+// key was removed.
+type overlay struct {
+	base   state.Immutable
+	writes map[string][]byte
+
+	// scope, if set, holds the keys that can be accessed and their
+	// permissions. The accesses outside of it fail and are recorded in
+	// [denied].
+	scope  state.Keys
+	denied []error
+}
+
+func newOverlay(base state.Immutable) *overlay {
+	return &overlay{
+		base:   base,
+		writes: map[string][]byte{},
+	}
+}
+
+// check returns an error, the one the chain returns, if [key] cannot be
+// accessed with [perm].
+func (o *overlay) check(key []byte, perm state.Permissions, access string) error {
+	if o.scope == nil || o.scope[string(key)].Has(perm) {
+		return nil
+	}
+	o.denied = append(o.denied, fmt.Errorf("%w: %s of %x", ErrUndeclaredKey, access, key))
+	return tstate.ErrInvalidKeyOrPermission
+}
+
+func (o *overlay) GetValue(ctx context.Context, key []byte) ([]byte, error) {
+	if err := o.check(key, state.Read, "read"); err != nil {
+		return nil, err
+	}
+	if v, ok := o.writes[string(key)]; ok {
+		if v == nil {
+			return nil, database.ErrNotFound
+		}
+		return v, nil
+	}
+	return o.base.GetValue(ctx, key)
+}
+
+func (o *overlay) Insert(_ context.Context, key []byte, value []byte) error {
+	if err := o.check(key, state.Write, "write"); err != nil {
+		return err
+	}
+	o.writes[string(key)] = slices.Clone(value)
+	return nil
+}
+
+func (o *overlay) Remove(_ context.Context, key []byte) error {
+	if err := o.check(key, state.Write, "removal"); err != nil {
+		return err
+	}
+	o.writes[string(key)] = nil
+	return nil
+}
+
+// changes returns the writes that changed the value of their key, sorted by
+// key.
+func (o *overlay) changes(ctx context.Context) ([]*Change, error) {
+	keys := make([]string, 0, len(o.writes))
+	for k := range o.writes {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	changes := []*Change{}
+	for _, k := range keys {
+		before, err := o.base.GetValue(ctx, []byte(k))
+		if errors.Is(err, database.ErrNotFound) {
+			before = nil
+		} else if err != nil {
+			return nil, err
+		}
+		after := o.writes[k]
+		if bytes.Equal(before, after) && (before == nil) == (after == nil) {
+			continue
+		}
+		changes = append(changes, &Change{
+			Key:    []byte(k),
+			Before: before,
+			After:  after,
+		})
+	}
+	return changes, nil
+}
+
+// apply writes [changes] to [o].
+func (o *overlay) apply(changes []*Change) {
+	for _, change := range changes {
+		o.writes[string(change.Key)] = change.After
+	}
+}
+
+// CheckBlock executes [txs] in order on top of [im], charging [fees], and
+// checks the changes made by each of them. If [results] is not nil, the
+// executions must match them. It returns the change to the sum of balances
+// declared by [txs], and the invariants they broke.
+func (c *Checker) CheckBlock(
+	ctx context.Context,
+	im state.Immutable,
+	bh chain.BalanceHandler,
+	rules chain.Rules,
+	timestamp int64,
+	txs []*chain.Transaction,
+	fees []uint64,
+	results []*chain.Result,
+) (*big.Int, []*Violation, error) {
+	_, expected, violations, err := c.checkBlock(ctx, im, bh, rules, timestamp, txs, fees, results)
+	return expected, violations, err
+}
+
+func (c *Checker) checkBlock(
+	ctx context.Context,
+	im state.Immutable,
+	bh chain.BalanceHandler,
+	rules chain.Rules,
+	timestamp int64,
+	txs []*chain.Transaction,
+	fees []uint64,
+	results []*chain.Result,
+) (*overlay, *big.Int, []*Violation, error) {
+	s := newOverlay(im)
+	expected := new(big.Int)
+	violations := []*Violation{}
+	for i, tx := range txs {
+		success, changes, denied, err := Execute(ctx, s, bh, rules, timestamp, tx, fees[i])
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%w: failed to execute %s", err, tx.ID())
+		}
+		violations = append(violations, denied...)
+		if results != nil && results[i].Success != success {
+			violations = append(violations, &Violation{
+				TxID: tx.ID(),
+				Err:  fmt.Errorf("%w: success=%t, executed=%t", ErrResultMismatch, results[i].Success, success),
+			})
+		}
+		delta, txViolations := c.CheckTx(tx, success, fees[i], changes)
+		expected.Add(expected, delta)
+		violations = append(violations, txViolations...)
+		s.apply(changes)
+	}
+	return s, expected, violations, nil
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package invariant
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/keys"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var _ chain.Action = (*testMint)(nil)
+
+// testMint credits [To] with [Value], and declares it as minted if
+// [Declared]. If [Key] is set, it is also written. If [Undeclared] is set,
+// it is written without being declared in the state keys.
+type testMint struct {
+	To         codec.Address `serialize:"true"`
+	Value      uint64        `serialize:"true"`
+	Declared   bool          `serialize:"true"`
+	Key        []byte        `serialize:"true"`
+	Undeclared []byte        `serialize:"true"`
+}
+
+func (*testMint) GetTypeID() uint8 {
+	return 255
+}
+
+func (m *testMint) StateKeys(codec.Address, ids.ID) state.Keys {
+	keys := state.Keys{string(storage.BalanceKey(m.To)): state.All}
+	if len(m.Key) > 0 {
+		keys[string(m.Key)] = state.All
+	}
+	return keys
+}
+
+func (m *testMint) Execute(ctx context.Context, _ chain.Rules, mu state.Mutable, _ int64, _ codec.Address, _ ids.ID) (codec.Typed, error) {
+	for _, k := range [][]byte{m.Key, m.Undeclared} {
+		if len(k) == 0 {
+			continue
+		}
+		if err := mu.Insert(ctx, k, []byte{1}); err != nil {
+			return nil, err
+		}
+	}
+	_, err := storage.AddBalance(ctx, mu, m.To, m.Value)
+	return nil, err
+}
+
+func (*testMint) ComputeUnits(chain.Rules) uint64 {
+	return 1
+}
+
+func (*testMint) ValidRange(chain.Rules) (int64, int64) {
+	return -1, -1
+}
+
+func (m *testMint) SupplyChange() (uint64, uint64) {
+	if !m.Declared {
+		return 0, 0
+	}
+	return m.Value, 0
+}
+
+func TestHarness(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	key, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	sender := auth.NewED25519Address(key.PublicKey())
+	to := codectest.NewRandomAddress()
+
+	h, err := NewHarness(genesis.NewDefaultRules(), &storage.BalanceHandler{}, map[codec.Address]uint64{sender: 1_000})
+	require.NoError(err)
+
+	sign := func(timestamp int64, fee uint64, action chain.Action) *chain.Transaction {
+		tx, err := chain.NewTxData(
+			&chain.Base{ChainID: ids.Empty, Timestamp: timestamp, MaxFee: fee},
+			[]chain.Action{action},
+		).Sign(auth.NewED25519Factory(key))
+		require.NoError(err)
+		return tx
+	}
+
+	// Fees are burned, failed actions are reverted and declared mints add
+	// to the supply.
+	violations, err := h.Execute(ctx, 1,
+		sign(1, 10, &actions.Transfer{To: to, Value: 100}),
+		sign(2, 10, &actions.Transfer{To: to, Value: 10_000}),
+		sign(3, 10, &testMint{To: to, Value: 50, Declared: true}),
+	)
+	require.NoError(err)
+	require.Empty(violations)
+	require.Equal(big.NewInt(1_000-30+50), h.Supply())
+	total, err := h.Total()
+	require.NoError(err)
+	require.Equal(h.Supply(), total)
+
+	// Undeclared mints and writes outside of the registered prefixes are
+	// attributed to their transaction.
+	mint := sign(4, 10, &testMint{To: to, Value: 5})
+	write := sign(5, 10, &testMint{To: to, Value: 5, Declared: true, Key: keys.EncodeChunks([]byte{0xff}, 1)})
+	violations, err = h.Execute(ctx, 2, mint, write)
+	require.NoError(err)
+	require.Len(violations, 3)
+	require.ErrorIs(violations[0], ErrSupplyMismatch)
+	require.Equal(mint.ID(), violations[0].TxID)
+	require.ErrorIs(violations[1], ErrUndeclaredPrefix)
+	require.Equal(write.ID(), violations[1].TxID)
+	require.ErrorIs(violations[2], ErrSupplyMismatch)
+	require.Equal(ids.Empty, violations[2].TxID)
+
+	// Accessing a key without declaring it fails, as it does on chain, and
+	// is attributed to the transaction.
+	undeclared := sign(6, 10, &testMint{To: to, Undeclared: storage.BalanceKey(codectest.NewRandomAddress())})
+	violations, err = h.Execute(ctx, 3, undeclared)
+	require.NoError(err)
+	require.Len(violations, 2)
+	require.ErrorIs(violations[0], ErrUndeclaredKey)
+	require.Equal(undeclared.ID(), violations[0].TxID)
+	// The supply is still off by the undeclared mint.
+	require.ErrorIs(violations[1], ErrSupplyMismatch)
+}
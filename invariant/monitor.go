@@ -0,0 +1,250 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package invariant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/maybe"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var _ event.Subscription[*chain.ExecutedBlock] = (*Monitor)(nil)
+
+// Balance changes are read from state in pages of this many keys.
+const proofLength = 1024
+
+// State is the VM state the monitor reads the state before every block from.
+type State interface {
+	storage.RootState
+	GetChangeProof(
+		ctx context.Context,
+		startRootID ids.ID,
+		endRootID ids.ID,
+		start maybe.Maybe[[]byte],
+		end maybe.Maybe[[]byte],
+		maxLength int,
+	) (*merkledb.ChangeProof, error)
+}
+
+// Monitor re-executes every accepted block on top of the state it was built
+// on and logs the invariants its transactions broke. It is a debug mode: it
+// executes every block twice.
+//
+// It also checks that the balances committed by every block sum to the
+// supply its transactions declared. A block only carries the state root of
+// its parent, so this check is made, and reported, with the next block.
+type Monitor struct {
+	log     logging.Logger
+	state   func() (State, error)
+	bh      chain.BalanceHandler
+	rules   func(int64) chain.Rules
+	checker *Checker
+
+	// started is set once the monitor has checked a block: [height] and
+	// [root] are the height and the parent root of that block, [supply] is
+	// the sum of balances at [root] and [declared] the change to it that the
+	// block declared.
+	started  bool
+	height   uint64
+	root     ids.ID
+	supply   *big.Int
+	declared *big.Int
+
+	// OnViolation is called with the violations of every block, if set.
+	OnViolation func(*chain.ExecutedBlock, []*Violation)
+}
+
+// NewMonitor returns a monitor that reads state from [state], which returns
+// an error if the state is not available yet.
+func NewMonitor(
+	log logging.Logger,
+	state func() (State, error),
+	bh chain.BalanceHandler,
+	rules func(int64) chain.Rules,
+) *Monitor {
+	return &Monitor{
+		log:     log,
+		state:   state,
+		bh:      bh,
+		rules:   rules,
+		checker: NewChecker(),
+	}
+}
+
+func (m *Monitor) Accept(blk *chain.ExecutedBlock) error {
+	if m.started && blk.Block.Hght <= m.height {
+		// Blocks are delivered at least once.
+		return nil
+	}
+	s, err := m.state()
+	if err != nil {
+		return err
+	}
+	violations, err := m.check(context.Background(), s, blk)
+	if errors.Is(err, merkledb.ErrInsufficientHistory) {
+		// The state history no longer holds the root [blk] was built on.
+		m.log.Warn("skipping invariant checks",
+			zap.Uint64("height", blk.Block.Hght),
+			zap.Error(err),
+		)
+		m.started = false
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, v := range violations {
+		m.log.Error("invariant violated",
+			zap.Uint64("height", blk.Block.Hght),
+			zap.Stringer("blkID", blk.BlockID),
+			zap.Stringer("txID", v.TxID),
+			zap.Error(v.Err),
+		)
+	}
+	if len(violations) > 0 && m.OnViolation != nil {
+		m.OnViolation(blk, violations)
+	}
+	return nil
+}
+
+// check checks the supply after the previous block and re-executes [blk].
+func (m *Monitor) check(ctx context.Context, s State, blk *chain.ExecutedBlock) ([]*Violation, error) {
+	// [blk] carries the root of the state it was built on.
+	root := blk.Block.StateRoot
+	violations := []*Violation{}
+	if m.started && blk.Block.Hght == m.height+1 {
+		delta, err := balanceDelta(ctx, s, m.root, root)
+		if err != nil {
+			return nil, err
+		}
+		actual := new(big.Int).Add(m.supply, delta)
+		expected := new(big.Int).Add(m.supply, m.declared)
+		if actual.Cmp(expected) != 0 {
+			violations = append(violations, &Violation{
+				Err: fmt.Errorf("%w: balances sum to %s after height %d, expected %s", ErrSupplyMismatch, actual, m.height, expected),
+			})
+		}
+		m.supply = actual
+	} else {
+		// Blocks that were not processed, such as the ones skipped by state
+		// sync, are not delivered.
+		supply := new(big.Int)
+		if err := storage.IterateBalancesAtRoot(ctx, s, root, func(_ codec.Address, balance uint64) error {
+			supply.Add(supply, new(big.Int).SetUint64(balance))
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		m.supply = supply
+	}
+
+	fees := make([]uint64, len(blk.Results))
+	for i, result := range blk.Results {
+		fees[i] = result.Fee
+	}
+	declared, txViolations, err := m.checker.CheckBlock(
+		ctx,
+		&rootState{state: s, root: root},
+		m.bh,
+		m.rules(blk.Block.Tmstmp),
+		blk.Block.Tmstmp,
+		blk.Block.Txs,
+		fees,
+		blk.Results,
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.started = true
+	m.height = blk.Block.Hght
+	m.root = root
+	m.declared = declared
+	return append(violations, txViolations...), nil
+}
+
+func (*Monitor) Close() error {
+	return nil
+}
+
+// balanceDelta returns the change to the sum of balances between [from] and
+// [to].
+func balanceDelta(ctx context.Context, s State, from ids.ID, to ids.ID) (*big.Int, error) {
+	delta := new(big.Int)
+	if from == to {
+		return delta, nil
+	}
+	prefix := storage.BalancePrefix()
+	start, end := maybe.Some([]byte{prefix}), maybe.Some([]byte{prefix + 1})
+	read := storage.ReadStateAtRoot(s, from)
+	for {
+		proof, err := s.GetChangeProof(ctx, from, to, start, end, proofLength)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([][]byte, 0, len(proof.KeyChanges))
+		for _, change := range proof.KeyChanges {
+			if _, ok := storage.ParseBalanceKey(change.Key); !ok {
+				continue
+			}
+			keys = append(keys, change.Key)
+			after, err := balance(change.Value.Value())
+			if err != nil {
+				return nil, err
+			}
+			delta.Add(delta, after)
+		}
+		values, errs := read(ctx, keys)
+		for i := range keys {
+			var v []byte
+			switch {
+			case errs[i] == nil:
+				v = values[i]
+			case !errors.Is(errs[i], database.ErrNotFound):
+				return nil, errs[i]
+			}
+			before, err := balance(v)
+			if err != nil {
+				return nil, err
+			}
+			delta.Sub(delta, before)
+		}
+		if len(proof.KeyChanges) < proofLength {
+			return delta, nil
+		}
+		start = maybe.Some(append(proof.KeyChanges[len(proof.KeyChanges)-1].Key, 0))
+	}
+}
+
+var _ state.Immutable = (*rootState)(nil)
+
+// rootState reads the state at [root] from the state history.
+type rootState struct {
+	state State
+	root  ids.ID
+}
+
+func (r *rootState) GetValue(ctx context.Context, key []byte) ([]byte, error) {
+	proof, err := r.state.GetRangeProofAtRoot(ctx, r.root, maybe.Some(key), maybe.Some(key), 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(proof.KeyValues) == 0 || string(proof.KeyValues[0].Key) != string(key) {
+		return nil, database.ErrNotFound
+	}
+	return proof.KeyValues[0].Value, nil
+}
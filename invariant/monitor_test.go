@@ -0,0 +1,100 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package invariant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/genesis"
+)
+
+func TestMonitor(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	key, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	sender := auth.NewED25519Address(key.PublicKey())
+	to := codectest.NewRandomAddress()
+
+	s, err := merkledb.New(ctx, memdb.New(), merkledb.Config{
+		BranchFactor:                merkledb.BranchFactor16,
+		HistoryLength:               16,
+		ValueNodeCacheSize:          units.MiB,
+		IntermediateNodeCacheSize:   units.MiB,
+		IntermediateWriteBufferSize: units.KiB,
+		IntermediateWriteBatchSize:  units.KiB,
+		TraceLevel:                  merkledb.NoTrace,
+	})
+	require.NoError(err)
+	// commit writes [balances] and returns the new root.
+	commit := func(balances map[codec.Address]uint64) ids.ID {
+		ops := []database.BatchOp{}
+		for addr, balance := range balances {
+			ops = append(ops, database.BatchOp{Key: storage.BalanceKey(addr), Value: database.PackUInt64(balance)})
+		}
+		view, err := s.NewView(ctx, merkledb.ViewChanges{BatchOps: ops})
+		require.NoError(err)
+		require.NoError(view.CommitToDB(ctx))
+		root, err := s.GetMerkleRoot(ctx)
+		require.NoError(err)
+		return root
+	}
+
+	rules := genesis.NewDefaultRules()
+	m := NewMonitor(
+		logging.NoLog{},
+		func() (State, error) { return s, nil },
+		&storage.BalanceHandler{},
+		func(int64) chain.Rules { return rules },
+	)
+	var reported []*Violation
+	m.OnViolation = func(_ *chain.ExecutedBlock, violations []*Violation) {
+		reported = append(reported, violations...)
+	}
+
+	tx, err := chain.NewTxData(
+		&chain.Base{ChainID: ids.Empty, Timestamp: 1, MaxFee: 10},
+		[]chain.Action{&actions.Transfer{To: to, Value: 100}},
+	).Sign(auth.NewED25519Factory(key))
+	require.NoError(err)
+
+	root0 := commit(map[codec.Address]uint64{sender: 1_000})
+	require.NoError(m.Accept(&chain.ExecutedBlock{
+		Block:   &chain.StatelessBlock{Hght: 1, StateRoot: root0, Txs: []*chain.Transaction{tx}},
+		Results: []*chain.Result{{Success: true, Outputs: [][]byte{{}}, Fee: 10}},
+	}))
+
+	// The chain committed what the block declared.
+	root1 := commit(map[codec.Address]uint64{sender: 890, to: 100})
+	require.NoError(m.Accept(&chain.ExecutedBlock{
+		Block: &chain.StatelessBlock{Hght: 2, StateRoot: root1},
+	}))
+	require.Empty(reported)
+
+	// The chain committed a balance no transaction declared.
+	root2 := commit(map[codec.Address]uint64{to: 105})
+	require.NoError(m.Accept(&chain.ExecutedBlock{
+		Block: &chain.StatelessBlock{Hght: 3, StateRoot: root2},
+	}))
+	require.Len(reported, 1)
+	require.ErrorIs(reported[0], ErrSupplyMismatch)
+	require.Equal(ids.Empty, reported[0].TxID)
+}
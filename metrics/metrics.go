@@ -0,0 +1,106 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OtherError is the error label of failed transactions whose error is not
+// one of the labelled errors.
+const OtherError = "other"
+
+// Metrics are the metrics of a VM.
+type Metrics struct {
+	transfers       prometheus.Counter
+	transferVolume  prometheus.Counter
+	memos           prometheus.Counter
+	memoBytes       prometheus.Counter
+	failedTxs       *prometheus.CounterVec
+	accountsCreated prometheus.Counter
+	accountsRemoved prometheus.Counter
+	methodLatency   *prometheus.HistogramVec
+}
+
+// New returns the metrics of a VM, registered with [r].
+func New(r prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		transfers: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "transfers",
+			Help: "number of successful transfers accepted",
+		}),
+		transferVolume: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "transfer_volume",
+			Help: "sum of the values of the successful transfers accepted",
+		}),
+		memos: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "memos",
+			Help: "number of successful transfers accepted with a memo",
+		}),
+		memoBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "memo_bytes",
+			Help: "sum of the sizes of the memos of the successful transfers accepted",
+		}),
+		failedTxs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "failed_txs",
+			Help: "number of failed transactions accepted, by the error an action returned",
+		}, []string{"error"}),
+		accountsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "accounts_created",
+			Help: "number of addresses given a balance by accepted blocks",
+		}),
+		accountsRemoved: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "accounts_removed",
+			Help: "number of addresses left without a balance by accepted blocks",
+		}),
+		methodLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "jsonrpc_method_latency",
+			Help:    "time in seconds spent serving API calls, by method",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+	errs := errors.Join(
+		r.Register(m.transfers),
+		r.Register(m.transferVolume),
+		r.Register(m.memos),
+		r.Register(m.memoBytes),
+		r.Register(m.failedTxs),
+		r.Register(m.accountsCreated),
+		r.Register(m.accountsRemoved),
+		r.Register(m.methodLatency),
+	)
+	return m, errs
+}
+
+// Transfer records an accepted transfer of [value] with a memo of
+// [memoSize] bytes.
+func (m *Metrics) Transfer(value uint64, memoSize int) {
+	m.transfers.Inc()
+	m.transferVolume.Add(float64(value))
+	if memoSize > 0 {
+		m.memos.Inc()
+		m.memoBytes.Add(float64(memoSize))
+	}
+}
+
+// FailedTx records an accepted transaction that failed with the error
+// labelled [label].
+func (m *Metrics) FailedTx(label string) {
+	m.failedTxs.WithLabelValues(label).Inc()
+}
+
+// Accounts records [created] addresses given a balance and [removed]
+// addresses left without one by an accepted block.
+func (m *Metrics) Accounts(created int, removed int) {
+	m.accountsCreated.Add(float64(created))
+	m.accountsRemoved.Add(float64(removed))
+}
+
+// ObserveMethod records a call to [method] that took [d].
+func (m *Metrics) ObserveMethod(method string, d time.Duration) {
+	m.methodLatency.WithLabelValues(method).Observe(d.Seconds())
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	require := require.New(t)
+
+	registry := prometheus.NewRegistry()
+	m, err := New(registry)
+	require.NoError(err)
+
+	m.Transfer(10, 0)
+	m.Transfer(5, 3)
+	require.InDelta(2, testutil.ToFloat64(m.transfers), 0)
+	require.InDelta(15, testutil.ToFloat64(m.transferVolume), 0)
+	require.InDelta(1, testutil.ToFloat64(m.memos), 0)
+	require.InDelta(3, testutil.ToFloat64(m.memoBytes), 0)
+
+	m.FailedTx("ErrOutputValueZero")
+	m.FailedTx("ErrOutputValueZero")
+	m.FailedTx(OtherError)
+	require.InDelta(2, testutil.ToFloat64(m.failedTxs.WithLabelValues("ErrOutputValueZero")), 0)
+	require.InDelta(1, testutil.ToFloat64(m.failedTxs.WithLabelValues(OtherError)), 0)
+
+	m.Accounts(1, 0)
+	m.Accounts(0, 2)
+	require.InDelta(1, testutil.ToFloat64(m.accountsCreated), 0)
+	require.InDelta(2, testutil.ToFloat64(m.accountsRemoved), 0)
+
+	m.ObserveMethod("morpheusvm.balance", time.Millisecond)
+	require.Equal(1, testutil.CollectAndCount(m.methodLatency))
+
+	families, err := registry.Gather()
+	require.NoError(err)
+	names := make([]string, len(families))
+	for i, family := range families {
+		names[i] = family.GetName()
+	}
+	require.Equal([]string{
+		"accounts_created",
+		"accounts_removed",
+		"failed_txs",
+		"jsonrpc_method_latency",
+		"memo_bytes",
+		"memos",
+		"transfer_volume",
+		"transfers",
+	}, names)
+
+	// The metrics of a second VM need their own registry.
+	_, err = New(registry)
+	require.ErrorAs(err, &prometheus.AlreadyRegisteredError{})
+}
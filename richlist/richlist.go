@@ -0,0 +1,501 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package richlist
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/pebbledb"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/utils/units"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/event"
+)
+
+const (
+	// Namespace is the directory, under the VM's data directory, that holds
+	// the index.
+	Namespace = "richlist"
+
+	DefaultLimit = 100
+	MaxLimit     = 1024
+
+	// Bucket i holds the balances in [10^i, 10^(i+1)). Every uint64 fits in
+	// one of the 20 buckets.
+	Buckets = 20
+
+	balancePrefix byte = 0x0 // address -> balance
+	rankPrefix    byte = 0x1 // inverted balance + address -> nil
+	bucketsPrefix byte = 0x2 // holders and total balance of every bucket, height
+	pendingPrefix byte = 0x3 // addresses written by the block after the height
+
+	bucketsLen = Buckets*2*consts.Uint64Len + consts.Uint64Len
+
+	// Rebuilding writes the index in batches of about this size.
+	rebuildBatchSize = units.MiB
+)
+
+var (
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidOffset = errors.New("invalid offset")
+
+	ErrInvalidPending = errors.New("invalid pending addresses")
+)
+
+var (
+	_ event.Subscription[*chain.ExecutedBlock]        = (*Index)(nil)
+	_ event.SubscriptionFactory[*chain.ExecutedBlock] = (*Index)(nil)
+)
+
+// State is the VM state the index reads balances from.
+type State interface {
+	storage.RootState
+}
+
+type Holder struct {
+	Rank    uint64        `json:"rank"`
+	Address codec.Address `json:"address"`
+	Balance uint64        `json:"balance"`
+}
+
+// Bucket is the number of holders, and their total balance, with a balance
+// in [Min, Max].
+type Bucket struct {
+	Min     uint64 `json:"min"`
+	Max     uint64 `json:"max"`
+	Holders uint64 `json:"holders"`
+	Balance uint64 `json:"balance"`
+}
+
+// Index keeps the non-zero balances of the VM sorted. It is updated with the
+// balances touched by every accepted block, and rebuilt from state when it is
+// missing.
+type Index struct {
+	db    database.Database
+	state func() (State, error)
+	bh    chain.BalanceHandler
+
+	l       sync.RWMutex
+	built   bool
+	buckets [Buckets]Bucket
+
+	// height is the height of the last block the index includes. pending
+	// holds the addresses written by the block at [height]+1, whose balances
+	// are read once the state root after it is known.
+	height  uint64
+	pending []codec.Address
+}
+
+// New opens the index stored at [path]. [state] returns the VM state, or an
+// error if it is not available yet. [bh] returns the balance keys touched by
+// a transaction.
+func New(path string, state func() (State, error), bh chain.BalanceHandler) (*Index, error) {
+	db, err := pebbledb.New(path, nil, logging.NoLog{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newIndex(db, state, bh)
+}
+
+func newIndex(db database.Database, state func() (State, error), bh chain.BalanceHandler) (*Index, error) {
+	i := &Index{
+		db:    db,
+		state: state,
+		bh:    bh,
+	}
+	for b := range i.buckets {
+		i.buckets[b].Min, i.buckets[b].Max = bucketRange(b)
+	}
+
+	raw, err := db.Get([]byte{bucketsPrefix})
+	if errors.Is(err, database.ErrNotFound) {
+		return i, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := codec.NewReader(raw, bucketsLen)
+	for b := range i.buckets {
+		i.buckets[b].Holders = p.UnpackUint64(false)
+		i.buckets[b].Balance = p.UnpackUint64(false)
+	}
+	i.height = p.UnpackUint64(false)
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+
+	raw, err = db.Get([]byte{pendingPrefix})
+	switch {
+	case err == nil:
+		i.pending, err = unpackAddresses(raw)
+		if err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, database.ErrNotFound):
+		return nil, err
+	}
+	i.built = true
+	return i, nil
+}
+
+// New returns the index. A missing index is built from state on the next
+// accepted block, as the state is not available while the node syncs.
+func (i *Index) New() (event.Subscription[*chain.ExecutedBlock], error) {
+	return i, nil
+}
+
+// rebuild builds the index from the current state of [s].
+func (i *Index) rebuild(ctx context.Context, s State) error {
+	// Remove what is left of an interrupted rebuild.
+	if err := i.clear(); err != nil {
+		return err
+	}
+	buckets := i.buckets
+	for b := range buckets {
+		buckets[b].Holders = 0
+		buckets[b].Balance = 0
+	}
+	root, height, err := storage.CurrentRoot(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	batch := i.db.NewBatch()
+	if err := storage.IterateBalancesAtRoot(ctx, s, root, func(addr codec.Address, balance uint64) error {
+		if err := setBalance(batch, &buckets, addr, 0, balance); err != nil {
+			return err
+		}
+		if batch.Size() < rebuildBatchSize {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}); err != nil {
+		return err
+	}
+	// The buckets are written last, so an interrupted rebuild is restarted.
+	if err := write(batch, &buckets, height, nil); err != nil {
+		return err
+	}
+	i.buckets, i.height, i.pending = buckets, height, nil
+	i.built = true
+	return nil
+}
+
+func (i *Index) clear() error {
+	it := i.db.NewIterator()
+	defer it.Release()
+
+	batch := i.db.NewBatch()
+	for it.Next() {
+		if err := batch.Delete(it.Key()); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Accept updates the balances written by [blk] with their values right
+// after [blk]. If the state has already moved past [blk], these values are
+// read at the state root carried by the next block, so the index trails the
+// accepted blocks by one height until then.
+func (i *Index) Accept(blk *chain.ExecutedBlock) error {
+	i.l.Lock()
+	defer i.l.Unlock()
+
+	s, err := i.state()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if !i.built {
+		// The state already includes [blk].
+		return i.rebuild(ctx, s)
+	}
+
+	height := blk.Block.Hght
+	last := i.height
+	if i.pending != nil {
+		last++
+	}
+	switch {
+	case height <= last:
+		// Blocks are delivered at least once.
+		return nil
+	case height > last+1:
+		// Blocks that were not processed, such as the ones skipped by state
+		// sync, are not delivered.
+		return i.rebuild(ctx, s)
+	}
+
+	if i.pending != nil {
+		// [blk] carries the root of the state after the pending block. The
+		// pending block is written first, as [update] reads the previous
+		// balances from the index.
+		buckets := i.buckets
+		batch := i.db.NewBatch()
+		if err := i.update(ctx, batch, &buckets, storage.ReadStateAtRoot(s, blk.Block.StateRoot), i.pending); err != nil {
+			return err
+		}
+		if err := write(batch, &buckets, i.height+1, nil); err != nil {
+			return err
+		}
+		i.buckets, i.height, i.pending = buckets, i.height+1, nil
+	}
+
+	touched := set.Set[codec.Address]{}
+	for _, tx := range blk.Block.Txs {
+		stateKeys, err := tx.StateKeys(i.bh)
+		if err != nil {
+			return err
+		}
+		for k := range stateKeys {
+			if addr, ok := storage.ParseBalanceKey([]byte(k)); ok {
+				touched.Add(addr)
+			}
+		}
+	}
+	addrs := touched.List()
+	root, ok, err := storage.RootAfter(ctx, s, height)
+	if err != nil {
+		return err
+	}
+	// The changes are staged and only replace the in-memory state of the
+	// index once they are written.
+	var (
+		buckets = i.buckets
+		indexed = i.height
+		pending []codec.Address
+		batch   = i.db.NewBatch()
+	)
+	if ok {
+		if err := i.update(ctx, batch, &buckets, storage.ReadStateAtRoot(s, root), addrs); err != nil {
+			return err
+		}
+		indexed = height
+	} else {
+		pending = addrs
+	}
+	if err := write(batch, &buckets, indexed, pending); err != nil {
+		return err
+	}
+	i.buckets, i.height, i.pending = buckets, indexed, pending
+	return nil
+}
+
+// write writes [batch] along with [buckets], the [height] and the [pending]
+// addresses of the index.
+func write(batch database.Batch, buckets *[Buckets]Bucket, height uint64, pending []codec.Address) error {
+	var err error
+	if pending != nil {
+		err = batch.Put([]byte{pendingPrefix}, packAddresses(pending))
+	} else {
+		err = batch.Delete([]byte{pendingPrefix})
+	}
+	if err != nil {
+		return err
+	}
+	if err := batch.Put([]byte{bucketsPrefix}, bucketsBytes(buckets, height)); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// update sets the balances of [addrs] to the ones [f] reads.
+func (i *Index) update(ctx context.Context, batch database.Batch, buckets *[Buckets]Bucket, f storage.ReadState, addrs []codec.Address) error {
+	balances, errs := storage.GetBalancesFromState(ctx, f, addrs)
+	for j, addr := range addrs {
+		if errs[j] != nil {
+			return errs[j]
+		}
+		old, err := i.balance(addr)
+		if err != nil {
+			return err
+		}
+		if err := setBalance(batch, buckets, addr, old, balances[j]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Index) Close() error {
+	return i.db.Close()
+}
+
+// TopHolders returns up to [limit] holders with the highest balances,
+// skipping the first [offset].
+func (i *Index) TopHolders(limit int, offset int) ([]*Holder, error) {
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if limit < 0 || limit > MaxLimit {
+		return nil, ErrInvalidLimit
+	}
+	if offset < 0 {
+		return nil, ErrInvalidOffset
+	}
+
+	i.l.RLock()
+	defer i.l.RUnlock()
+
+	it := i.db.NewIteratorWithPrefix([]byte{rankPrefix})
+	defer it.Release()
+
+	holders := []*Holder{}
+	for rank := 0; it.Next() && len(holders) < limit; rank++ {
+		if rank < offset {
+			continue
+		}
+		k := it.Key()
+		holder := &Holder{
+			Rank:    uint64(rank + 1),
+			Balance: math.MaxUint64 - binary.BigEndian.Uint64(k[1:]),
+		}
+		copy(holder.Address[:], k[1+consts.Uint64Len:])
+		holders = append(holders, holder)
+	}
+	return holders, it.Error()
+}
+
+// Holders returns the number of addresses with a non-zero balance.
+func (i *Index) Holders() uint64 {
+	i.l.RLock()
+	defer i.l.RUnlock()
+
+	var holders uint64
+	for _, b := range i.buckets {
+		holders += b.Holders
+	}
+	return holders
+}
+
+// Distribution returns the holders of every bucket.
+func (i *Index) Distribution() []*Bucket {
+	i.l.RLock()
+	defer i.l.RUnlock()
+
+	buckets := make([]*Bucket, Buckets)
+	for b := range i.buckets {
+		bucket := i.buckets[b]
+		buckets[b] = &bucket
+	}
+	return buckets
+}
+
+func (i *Index) balance(addr codec.Address) (uint64, error) {
+	b, err := i.db.Get(balanceKey(addr))
+	if errors.Is(err, database.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return database.ParseUInt64(b)
+}
+
+// setBalance moves [addr] from [old] to [balance].
+func setBalance(batch database.Batch, buckets *[Buckets]Bucket, addr codec.Address, old uint64, balance uint64) error {
+	if old == balance {
+		return nil
+	}
+	if old != 0 {
+		b := &buckets[bucketOf(old)]
+		b.Holders--
+		b.Balance -= old
+		if err := batch.Delete(rankKey(addr, old)); err != nil {
+			return err
+		}
+	}
+	if balance == 0 {
+		return batch.Delete(balanceKey(addr))
+	}
+	b := &buckets[bucketOf(balance)]
+	b.Holders++
+	b.Balance += balance
+	if err := batch.Put(rankKey(addr, balance), []byte{}); err != nil {
+		return err
+	}
+	return batch.Put(balanceKey(addr), database.PackUInt64(balance))
+}
+
+func bucketsBytes(buckets *[Buckets]Bucket, height uint64) []byte {
+	p := codec.NewWriter(bucketsLen, bucketsLen)
+	for _, b := range buckets {
+		p.PackUint64(b.Holders)
+		p.PackUint64(b.Balance)
+	}
+	p.PackUint64(height)
+	return p.Bytes()
+}
+
+func packAddresses(addrs []codec.Address) []byte {
+	b := make([]byte, 0, len(addrs)*codec.AddressLen)
+	for _, addr := range addrs {
+		b = append(b, addr[:]...)
+	}
+	return b
+}
+
+func unpackAddresses(b []byte) ([]codec.Address, error) {
+	if len(b)%codec.AddressLen != 0 {
+		return nil, ErrInvalidPending
+	}
+	addrs := make([]codec.Address, len(b)/codec.AddressLen)
+	for j := range addrs {
+		copy(addrs[j][:], b[j*codec.AddressLen:])
+	}
+	return addrs, nil
+}
+
+func balanceKey(addr codec.Address) []byte {
+	k := make([]byte, 0, 1+codec.AddressLen)
+	k = append(k, balancePrefix)
+	return append(k, addr[:]...)
+}
+
+// rankKey inverts [balance] so that iterating over the ranks yields the
+// highest balances first.
+func rankKey(addr codec.Address, balance uint64) []byte {
+	k := make([]byte, 0, 1+consts.Uint64Len+codec.AddressLen)
+	k = append(k, rankPrefix)
+	k = binary.BigEndian.AppendUint64(k, math.MaxUint64-balance)
+	return append(k, addr[:]...)
+}
+
+func bucketOf(balance uint64) int {
+	b := 0
+	for balance >= 10 {
+		balance /= 10
+		b++
+	}
+	return b
+}
+
+func bucketRange(b int) (uint64, uint64) {
+	low := uint64(1)
+	for j := 0; j < b; j++ {
+		low *= 10
+	}
+	if b == Buckets-1 {
+		return low, math.MaxUint64
+	}
+	return low, low*10 - 1
+}
@@ -0,0 +1,254 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package richlist
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/state/metadata"
+)
+
+func newState(t *testing.T) merkledb.MerkleDB {
+	db, err := merkledb.New(context.Background(), memdb.New(), merkledb.Config{
+		BranchFactor:                merkledb.BranchFactor16,
+		HistoryLength:               16,
+		ValueNodeCacheSize:          units.MiB,
+		IntermediateNodeCacheSize:   units.MiB,
+		IntermediateWriteBufferSize: units.KiB,
+		IntermediateWriteBatchSize:  units.KiB,
+		TraceLevel:                  merkledb.NoTrace,
+	})
+	require.NoError(t, err)
+	return db
+}
+
+// commit writes [balances] to [s] as the block at [height] would and returns
+// the root of [s] before the block.
+func commit(t *testing.T, s merkledb.MerkleDB, height uint64, balances map[codec.Address]uint64) ids.ID {
+	require := require.New(t)
+	ctx := context.Background()
+
+	parentRoot, err := s.GetMerkleRoot(ctx)
+	require.NoError(err)
+	ops := []database.BatchOp{{
+		Key:   chain.HeightKey(metadata.NewDefaultManager().HeightPrefix()),
+		Value: database.PackUInt64(height),
+	}}
+	for addr, balance := range balances {
+		ops = append(ops, database.BatchOp{
+			Key:    storage.BalanceKey(addr),
+			Value:  database.PackUInt64(balance),
+			Delete: balance == 0,
+		})
+	}
+	view, err := s.NewView(ctx, merkledb.ViewChanges{BatchOps: ops})
+	require.NoError(err)
+	require.NoError(view.CommitToDB(ctx))
+	return parentRoot
+}
+
+func transfer(t *testing.T, key ed25519.PrivateKey, to codec.Address, value uint64) *chain.Transaction {
+	tx, err := chain.NewTxData(
+		&chain.Base{ChainID: ids.Empty, Timestamp: 1, MaxFee: 1},
+		[]chain.Action{&actions.Transfer{To: to, Value: value}},
+	).Sign(auth.NewED25519Factory(key))
+	require.NoError(t, err)
+	return tx
+}
+
+func block(height uint64, root ids.ID, txs ...*chain.Transaction) *chain.ExecutedBlock {
+	results := make([]*chain.Result, len(txs))
+	for i := range results {
+		results[i] = &chain.Result{Success: true, Outputs: [][]byte{{}}}
+	}
+	return &chain.ExecutedBlock{
+		Block:   &chain.StatelessBlock{Hght: height, StateRoot: root, Txs: txs},
+		Results: results,
+	}
+}
+
+func TestIndex(t *testing.T) {
+	require := require.New(t)
+
+	key, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	sender := auth.NewED25519Address(key.PublicKey())
+	a := codectest.NewRandomAddress()
+	b := codectest.NewRandomAddress()
+
+	s := newState(t)
+	stateFunc := func() (State, error) { return s, nil }
+	commit(t, s, 0, map[codec.Address]uint64{sender: 1_000, a: 5})
+
+	// The index is built from state on the first accepted block.
+	db := memdb.New()
+	i, err := newIndex(db, stateFunc, &storage.BalanceHandler{})
+	require.NoError(err)
+	_, err = i.New()
+	require.NoError(err)
+	require.Zero(i.Holders())
+
+	// [sender] sends its whole balance, minus the fee, to [b].
+	root := commit(t, s, 1, map[codec.Address]uint64{sender: 0, b: 990})
+	require.NoError(i.Accept(block(1, root, transfer(t, key, b, 990))))
+	require.Equal(uint64(2), i.Holders())
+
+	holders, err := i.TopHolders(0, 0)
+	require.NoError(err)
+	require.Equal([]*Holder{
+		{Rank: 1, Address: b, Balance: 990},
+		{Rank: 2, Address: a, Balance: 5},
+	}, holders)
+	holders, err = i.TopHolders(1, 1)
+	require.NoError(err)
+	require.Equal([]*Holder{{Rank: 2, Address: a, Balance: 5}}, holders)
+	_, err = i.TopHolders(MaxLimit+1, 0)
+	require.ErrorIs(err, ErrInvalidLimit)
+
+	distribution := i.Distribution()
+	require.Len(distribution, Buckets)
+	require.Equal(&Bucket{Min: 1, Max: 9, Holders: 1, Balance: 5}, distribution[0])
+	require.Equal(&Bucket{Min: 100, Max: 999, Holders: 1, Balance: 990}, distribution[2])
+	require.Equal(&Bucket{Min: 10_000_000_000_000_000_000, Max: 18_446_744_073_709_551_615}, distribution[Buckets-1])
+
+	// The index is reloaded instead of rebuilt after a restart.
+	i, err = newIndex(db, func() (State, error) { return nil, database.ErrClosed }, &storage.BalanceHandler{})
+	require.NoError(err)
+	require.Equal(uint64(2), i.Holders())
+	holders, err = i.TopHolders(0, 0)
+	require.NoError(err)
+	require.Len(holders, 2)
+}
+
+func TestIndexReadsBalancesOfBlock(t *testing.T) {
+	require := require.New(t)
+
+	keyB, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	b := auth.NewED25519Address(keyB.PublicKey())
+	keyC, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	c := auth.NewED25519Address(keyC.PublicKey())
+	a := codectest.NewRandomAddress()
+
+	s := newState(t)
+	stateFunc := func() (State, error) { return s, nil }
+	commit(t, s, 0, map[codec.Address]uint64{b: 1_000, c: 1_000})
+
+	db := memdb.New()
+	i, err := newIndex(db, stateFunc, &storage.BalanceHandler{})
+	require.NoError(err)
+	require.NoError(i.Accept(block(0, ids.Empty)))
+	require.Equal(uint64(2), i.Holders())
+
+	// Blocks 1 and 2 are committed before block 1 is delivered, so the
+	// balances after block 1 are read at the root carried by block 2.
+	root1 := commit(t, s, 1, map[codec.Address]uint64{b: 900, a: 100})
+	root2 := commit(t, s, 2, map[codec.Address]uint64{c: 0, a: 1_100})
+	blk1 := block(1, root1, transfer(t, keyB, a, 100))
+	require.NoError(i.Accept(blk1))
+	holders, err := i.TopHolders(0, 0)
+	require.NoError(err)
+	require.Len(holders, 2)
+	for _, holder := range holders {
+		require.Equal(uint64(1_000), holder.Balance)
+	}
+
+	// The pending block survives a restart.
+	i, err = newIndex(db, stateFunc, &storage.BalanceHandler{})
+	require.NoError(err)
+	require.NoError(i.Accept(blk1))
+	require.NoError(i.Accept(block(2, root2, transfer(t, keyC, a, 1_000))))
+	holders, err = i.TopHolders(0, 0)
+	require.NoError(err)
+	require.Equal([]*Holder{
+		{Rank: 1, Address: a, Balance: 1_100},
+		{Rank: 2, Address: b, Balance: 900},
+	}, holders)
+
+	// Skipped blocks rebuild the index from the current state.
+	commit(t, s, 3, map[codec.Address]uint64{a: 1})
+	root4 := commit(t, s, 4, map[codec.Address]uint64{})
+	require.NoError(i.Accept(block(4, root4)))
+	holders, err = i.TopHolders(0, 0)
+	require.NoError(err)
+	require.Equal([]*Holder{
+		{Rank: 1, Address: b, Balance: 900},
+		{Rank: 2, Address: a, Balance: 1},
+	}, holders)
+}
+
+var errWriteFailed = errors.New("write failed")
+
+// failingDB fails every batch write while [fail] is set.
+type failingDB struct {
+	database.Database
+	fail bool
+}
+
+func (db *failingDB) NewBatch() database.Batch {
+	return &failingBatch{Batch: db.Database.NewBatch(), db: db}
+}
+
+type failingBatch struct {
+	database.Batch
+	db *failingDB
+}
+
+func (b *failingBatch) Write() error {
+	if b.db.fail {
+		return errWriteFailed
+	}
+	return b.Batch.Write()
+}
+
+func TestIndexFailedWrite(t *testing.T) {
+	require := require.New(t)
+
+	key, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	sender := auth.NewED25519Address(key.PublicKey())
+	a := codectest.NewRandomAddress()
+
+	s := newState(t)
+	commit(t, s, 0, map[codec.Address]uint64{sender: 1_000})
+	db := &failingDB{Database: memdb.New()}
+	i, err := newIndex(db, func() (State, error) { return s, nil }, &storage.BalanceHandler{})
+	require.NoError(err)
+	root := commit(t, s, 1, map[codec.Address]uint64{sender: 900, a: 99})
+	require.NoError(i.Accept(block(1, root, transfer(t, key, a, 99))))
+	distribution := i.Distribution()
+
+	// A block whose changes cannot be written leaves the index as it was.
+	db.fail = true
+	root = commit(t, s, 2, map[codec.Address]uint64{sender: 0, a: 998})
+	blk := block(2, root, transfer(t, key, a, 899))
+	require.ErrorIs(i.Accept(blk), errWriteFailed)
+	require.Equal(distribution, i.Distribution())
+	require.Equal(uint64(1), i.height)
+
+	// The block is applied once it is delivered again.
+	db.fail = false
+	require.NoError(i.Accept(blk))
+	require.Equal(uint64(1), i.Holders())
+	holders, err := i.TopHolders(0, 0)
+	require.NoError(err)
+	require.Equal([]*Holder{{Rank: 1, Address: a, Balance: 998}}, holders)
+}
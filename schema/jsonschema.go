@@ -0,0 +1,71 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package schema
+
+const (
+	JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+	// DefsPrefix is the prefix of references to the definitions of a JSON
+	// Schema document.
+	DefsPrefix = "#/$defs/"
+
+	// JSONRPCVersion is the version of the JSON-RPC envelopes.
+	JSONRPCVersion = "2.0"
+)
+
+// JSONSchema is a JSON Schema document made of definitions only.
+type JSONSchema struct {
+	Schema      string             `json:"$schema"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Defs        map[string]*Schema `json:"$defs"`
+}
+
+// NewJSONSchema returns a document holding the definitions of [g], which
+// must reference them with [DefsPrefix].
+func NewJSONSchema(title string, g *Generator) *JSONSchema {
+	return &JSONSchema{
+		Schema: JSONSchemaDialect,
+		Title:  title,
+		Defs:   g.Definitions(),
+	}
+}
+
+// JSONRPCRequest returns the schema of a JSON-RPC request calling [method]
+// with [params].
+func JSONRPCRequest(method string, params *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"jsonrpc": {Type: "string", Enum: []any{JSONRPCVersion}},
+			"method":  {Type: "string", Enum: []any{method}},
+			"params":  params,
+			"id":      {Type: "integer"},
+		},
+		Required: []string{"jsonrpc", "method", "params", "id"},
+	}
+}
+
+// JSONRPCResponse returns the schema of a JSON-RPC response holding either
+// [result] or an error.
+func JSONRPCResponse(result *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"jsonrpc": {Type: "string", Enum: []any{JSONRPCVersion}},
+			"result":  result,
+			"error": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"code":    {Type: "integer"},
+					"message": {Type: "string"},
+					"data":    {},
+				},
+				Required: []string{"code", "message"},
+			},
+			"id": {Type: "integer"},
+		},
+		Required: []string{"jsonrpc", "id"},
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package schema
+
+import "strings"
+
+const (
+	OpenAPIVersion = "3.0.3"
+
+	// ComponentsPrefix is the prefix of references to the schemas of an
+	// OpenAPI document.
+	ComponentsPrefix = "#/components/schemas/"
+)
+
+// OpenAPI is an OpenAPI 3 document.
+type OpenAPI struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []*Server            `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// PathItem holds the operations of a path, by lowercase HTTP method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// JSON returns the content of a JSON body described by [s].
+func JSON(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
+
+// Document builds an OpenAPI document whose schemas are generated from Go
+// types.
+type Document struct {
+	*Generator
+
+	doc *OpenAPI
+}
+
+func NewDocument(info Info) *Document {
+	return &Document{
+		Generator: NewGenerator(ComponentsPrefix),
+		doc: &OpenAPI{
+			OpenAPI: OpenAPIVersion,
+			Info:    info,
+			Paths:   map[string]*PathItem{},
+		},
+	}
+}
+
+// AddServer adds a server the paths are relative to.
+func (d *Document) AddServer(s *Server) {
+	d.doc.Servers = append(d.doc.Servers, s)
+}
+
+// AddOperation adds [op] as the [method] operation of [path].
+func (d *Document) AddOperation(method string, path string, op *Operation) {
+	item, ok := d.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.doc.Paths[path] = item
+	}
+	(*item)[strings.ToLower(method)] = op
+}
+
+// OpenAPI returns the document with every schema generated so far.
+func (d *Document) OpenAPI() *OpenAPI {
+	doc := *d.doc
+	doc.Components.Schemas = d.Definitions()
+	return &doc
+}
@@ -0,0 +1,223 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+)
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Schema is a JSON Schema, restricted to the subset that OpenAPI 3.0 accepts.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+
+	// TypeID is the type ID of a registered action or output.
+	TypeID *uint8 `json:"x-typeID,omitempty"`
+}
+
+// Generator turns Go types into JSON Schemas, following the rules of
+// encoding/json. Named struct types are defined once and referenced with
+// [RefPrefix] followed by their name.
+type Generator struct {
+	RefPrefix string
+
+	overrides   map[reflect.Type]*Schema
+	definitions map[string]*Schema
+}
+
+// NewGenerator returns a generator whose references start with [refPrefix],
+// such as "#/components/schemas/".
+func NewGenerator(refPrefix string) *Generator {
+	return &Generator{
+		RefPrefix:   refPrefix,
+		overrides:   map[reflect.Type]*Schema{},
+		definitions: map[string]*Schema{},
+	}
+}
+
+// Override makes the generator use [s] for every value of type [t]. Types
+// with a custom JSON encoding that is not a string need an override.
+func (g *Generator) Override(t reflect.Type, s *Schema) {
+	g.overrides[t] = s
+}
+
+// Definitions returns the schemas of the named struct types generated so
+// far, by name.
+func (g *Generator) Definitions() map[string]*Schema {
+	return g.definitions
+}
+
+// Schema returns the schema of the type of [v].
+func (g *Generator) Schema(v any) *Schema {
+	return g.TypeSchema(reflect.TypeOf(v))
+}
+
+// TypeSchema returns the schema of [t].
+func (g *Generator) TypeSchema(t reflect.Type) *Schema {
+	if s, ok := g.overrides[t]; ok {
+		return s
+	}
+	if t.Kind() == reflect.Pointer {
+		s := *g.TypeSchema(t.Elem())
+		if s.Ref != "" {
+			// Siblings of $ref are ignored, so a reference cannot be nullable.
+			return &s
+		}
+		s.Nullable = true
+		return &s
+	}
+	switch {
+	case t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler):
+		// The encoding is unknown.
+		return &Schema{}
+	case t.Implements(textMarshaler) || reflect.PointerTo(t).Implements(textMarshaler):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer", Format: intFormat(t)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		minimum := 0
+		return &Schema{Type: "integer", Format: intFormat(t), Minimum: &minimum}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.TypeSchema(t.Elem())}
+	case reflect.Array:
+		n := t.Len()
+		return &Schema{Type: "array", Items: g.TypeSchema(t.Elem()), MinItems: &n, MaxItems: &n}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.TypeSchema(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		// Interfaces can hold any value.
+		return &Schema{}
+	}
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	name := Name(t)
+	if name == "" {
+		return g.objectSchema(t)
+	}
+	ref := &Schema{Ref: g.RefPrefix + name}
+	if _, ok := g.definitions[name]; ok {
+		return ref
+	}
+	// Reserve the name first so that recursive types terminate.
+	g.definitions[name] = &Schema{}
+	g.definitions[name] = g.objectSchema(t)
+	return ref
+}
+
+func (g *Generator) objectSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(s, t)
+	return s
+}
+
+// addFields adds the fields of [t] to [s]. Fields of embedded structs are
+// promoted like encoding/json does.
+func (g *Generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := g.TypeSchema(ft)
+		if hasOption(opts, "string") {
+			fs = &Schema{Type: "string"}
+		}
+		s.Properties[name] = fs
+		if !hasOption(opts, "omitempty") && ft.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// Name returns the name [t] is defined under, made of its package name and
+// type name. It is empty for unnamed types.
+func Name(t reflect.Type) string {
+	if t.Name() == "" {
+		return ""
+	}
+	name := path.Base(t.PkgPath()) + "." + t.Name()
+	// Instantiated generic types contain characters that are not allowed in
+	// component names.
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func hasOption(opts string, option string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func intFormat(t reflect.Type) string {
+	if t.Bits() <= 32 {
+		return "int32"
+	}
+	return "int64"
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/codec"
+)
+
+type testEmbedded struct {
+	Embedded uint64 `json:"embedded"`
+}
+
+type testNode struct {
+	testEmbedded
+
+	Name     string           `json:"name"`
+	Address  codec.Address    `json:"address"`
+	Data     []byte           `json:"data,omitempty"`
+	Tags     map[string]int32 `json:"tags"`
+	Next     *testNode        `json:"next"`
+	Children []*testNode      `json:"children"`
+	Pair     [2]bool          `json:"pair"`
+	Any      any              `json:"any"`
+	Skipped  string           `json:"-"`
+	Custom   testCustom       `json:"custom"`
+	Quoted   int64            `json:"quoted,string"`
+	Plain    uint16
+	Options  map[string]string `json:"options,omitempty"`
+}
+
+type testCustom struct{}
+
+func TestGenerator(t *testing.T) {
+	require := require.New(t)
+
+	g := NewGenerator(ComponentsPrefix)
+	g.Override(reflect.TypeOf(testCustom{}), &Schema{Type: "string", Format: "custom"})
+	require.Equal(&Schema{Ref: ComponentsPrefix + "schema.testNode"}, g.Schema(&testNode{}))
+
+	two := 2
+	zero := 0
+	s := g.Definitions()["schema.testNode"]
+	require.Equal(&Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"embedded": {Type: "integer", Format: "int64", Minimum: &zero},
+			"name":     {Type: "string"},
+			"address":  {Type: "string"},
+			"data":     {Type: "string", Format: "byte"},
+			"tags":     {Type: "object", AdditionalProperties: &Schema{Type: "integer", Format: "int32"}},
+			"next":     {Ref: ComponentsPrefix + "schema.testNode"},
+			"children": {Type: "array", Items: &Schema{Ref: ComponentsPrefix + "schema.testNode"}},
+			"pair":     {Type: "array", Items: &Schema{Type: "boolean"}, MinItems: &two, MaxItems: &two},
+			"any":      {},
+			"custom":   {Type: "string", Format: "custom"},
+			"quoted":   {Type: "string"},
+			"Plain":    {Type: "integer", Format: "int32", Minimum: &zero},
+			"options":  {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+		},
+		Required: []string{"embedded", "name", "address", "tags", "children", "pair", "any", "custom", "quoted", "Plain"},
+	}, s)
+}
+
+func TestDocument(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDocument(Info{Title: "test", Version: "1"})
+	d.AddOperation("GET", "/nodes", &Operation{
+		OperationID: "getNodes",
+		Responses: map[string]*Response{
+			"200": {Description: "OK", Content: JSON(d.Schema(&testNode{}))},
+		},
+	})
+	doc := d.OpenAPI()
+	require.Equal(OpenAPIVersion, doc.OpenAPI)
+	require.Contains(*doc.Paths["/nodes"], "get")
+	require.Contains(doc.Components.Schemas, "schema.testNode")
+}
+
+func TestJSONSchema(t *testing.T) {
+	require := require.New(t)
+
+	g := NewGenerator(DefsPrefix)
+	require.Equal(&Schema{Ref: DefsPrefix + "schema.testNode"}, g.Schema(&testNode{}))
+	doc := NewJSONSchema("test", g)
+	require.Equal(JSONSchemaDialect, doc.Schema)
+	require.Contains(doc.Defs, "schema.testNode")
+
+	params := &Schema{Type: "string"}
+	req := JSONRPCRequest("test.method", params)
+	require.Equal([]any{"test.method"}, req.Properties["method"].Enum)
+	require.Equal(params, req.Properties["params"])
+	require.Contains(JSONRPCResponse(params).Properties, "error")
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/ava-labs/hypersdk/codec"
+)
+
+// Account is every record stored for an address.
+type Account struct {
+	// Exists is false if the address has no balance record, which is the
+	// case for addresses that never held funds or that have spent them all.
+	Exists  bool   `json:"exists"`
+	Balance uint64 `json:"balance"`
+
+	// SpendingLimit is nil if the address has not set a limit, or if the
+	// limit has been removed by a change that is due.
+	SpendingLimit *SpendingLimit `json:"spendingLimit"`
+
+	// PayerSubscriptions are the subscriptions the address pays for. The
+	// subscriptions it charges are not indexed in state.
+	PayerSubscriptions []SubscriptionRef `json:"payerSubscriptions"`
+}
+
+// Used to serve RPC queries. The records are read in a single call to [f] so
+// they are consistent with each other. The spending limit is advanced to
+// [timestamp], as the next debit would see it.
+func GetAccountFromState(
+	ctx context.Context,
+	f ReadState,
+	addr codec.Address,
+	timestamp int64,
+) (*Account, error) {
+	values, errs := f(ctx, [][]byte{
+		BalanceKey(addr),
+		SpendingLimitKey(addr),
+		PayerSubscriptionsKey(addr),
+	})
+	balance, exists, err := balanceRecord.decode(values[0], errs[0])
+	if err != nil {
+		return nil, err
+	}
+	limit, limited, err := innerGetSpendingLimit(values[1], errs[1])
+	if err != nil {
+		return nil, err
+	}
+	if limited && !limit.Advance(timestamp) {
+		limit = nil
+	}
+	payerSubscriptions, err := innerGetSubscriptionIndex(values[2], errs[2])
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		Exists:             exists,
+		Balance:            balance,
+		SpendingLimit:      limit,
+		PayerSubscriptions: payerSubscriptions,
+	}, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+)
+
+func TestGetAccountFromState(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	store := chaintest.NewInMemoryStore()
+	payer := codectest.NewRandomAddress()
+	payee := codectest.NewRandomAddress()
+
+	account, err := GetAccountFromState(ctx, readState(store), payer, 0)
+	require.NoError(err)
+	require.Equal(&Account{}, account)
+
+	limit := &SpendingLimit{Limit: 10, Window: 1_000, Spent: 4, WindowStart: 500}
+	subscriptionID := ids.GenerateTestID()
+	require.NoError(SetBalance(ctx, store, payer, 5))
+	require.NoError(SetSpendingLimit(ctx, store, payer, limit))
+	require.NoError(AddSubscription(ctx, store, subscriptionID, &Subscription{
+		Payer:  payer,
+		Payee:  payee,
+		Amount: 1,
+		Period: 1_000,
+	}))
+
+	account, err = GetAccountFromState(ctx, readState(store), payer, 1_000)
+	require.NoError(err)
+	require.Equal(&Account{
+		Exists:             true,
+		Balance:            5,
+		SpendingLimit:      limit,
+		PayerSubscriptions: []SubscriptionRef{{Payee: payee, ID: subscriptionID}},
+	}, account)
+
+	// The spending limit is returned as of the timestamp: the spent amount
+	// resets once the window has elapsed, and a due change is applied.
+	account, err = GetAccountFromState(ctx, readState(store), payer, 1_500)
+	require.NoError(err)
+	require.Equal(&SpendingLimit{Limit: 10, Window: 1_000, WindowStart: 1_500}, account.SpendingLimit)
+
+	limit.PendingWindow, limit.PendingAt = 0, 2_000
+	require.NoError(SetSpendingLimit(ctx, store, payer, limit))
+	account, err = GetAccountFromState(ctx, readState(store), payer, 2_000)
+	require.NoError(err)
+	require.Nil(account.SpendingLimit)
+	require.True(account.Exists)
+
+	// An address with a spending limit but no balance record does not exist.
+	limited := codectest.NewRandomAddress()
+	require.NoError(SetSpendingLimit(ctx, store, limited, &SpendingLimit{Limit: 1, Window: 1_000}))
+	account, err = GetAccountFromState(ctx, readState(store), limited, 0)
+	require.NoError(err)
+	require.False(account.Exists)
+	require.NotNil(account.SpendingLimit)
+
+	// The subscriptions of a payee are not indexed.
+	account, err = GetAccountFromState(ctx, readState(store), payee, 0)
+	require.NoError(err)
+	require.Equal(&Account{}, account)
+}
@@ -0,0 +1,353 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/state"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+const (
+	// MaxBridgeRelayers bounds the relayer set, and so the number of
+	// signatures a mint or unlock carries.
+	MaxBridgeRelayers = 16
+
+	BridgeConfigChunks   uint16 = 13
+	BridgeAmountChunks   uint16 = 1
+	BridgeTransferChunks uint16 = 1
+)
+
+// BridgeConfig is the set of relayers trusted to attest to transfers made on
+// other chains. A mint or unlock must be signed by at least [Quorum] of them.
+type BridgeConfig struct {
+	Quorum   uint8         `json:"quorum"`
+	Relayers []codec.Bytes `json:"relayers"`
+}
+
+// Verify checks that [c] is well formed.
+func (c *BridgeConfig) Verify() error {
+	if len(c.Relayers) == 0 || len(c.Relayers) > MaxBridgeRelayers {
+		return fmt.Errorf("%w: %d relayers", ErrInvalidBridgeConfig, len(c.Relayers))
+	}
+	if c.Quorum == 0 || int(c.Quorum) > len(c.Relayers) {
+		return fmt.Errorf("%w: quorum %d of %d relayers", ErrInvalidBridgeConfig, c.Quorum, len(c.Relayers))
+	}
+	for i, relayer := range c.Relayers {
+		if _, err := bls.PublicKeyFromBytes(relayer); err != nil {
+			return fmt.Errorf("%w: relayer %d: %w", ErrInvalidBridgeConfig, i, err)
+		}
+	}
+	return nil
+}
+
+var (
+	bridgeConfigLayout = register(&RecordLayout{
+		Name:        "bridge config",
+		Prefix:      0x8,
+		Chunks:      BridgeConfigChunks,
+		ValueFields: "quorum|relayers",
+	})
+	bridgeEscrowLayout = register(&RecordLayout{
+		Name:        "bridge escrow",
+		Prefix:      0x9,
+		KeyLen:      ids.IDLen,
+		Chunks:      BridgeAmountChunks,
+		KeyFields:   "destinationChainID",
+		ValueFields: "locked",
+	})
+	bridgeMintedLayout = register(&RecordLayout{
+		Name:        "bridge minted",
+		Prefix:      0xa,
+		KeyLen:      ids.IDLen,
+		Chunks:      BridgeAmountChunks,
+		KeyFields:   "sourceChainID",
+		ValueFields: "minted",
+	})
+	bridgeTransferLayout = register(&RecordLayout{
+		Name:        "bridge transfers",
+		Prefix:      0xb,
+		KeyLen:      2 * ids.IDLen,
+		Chunks:      BridgeTransferChunks,
+		KeyFields:   "sourceChainID|transferID",
+		ValueFields: "processedAt",
+	})
+)
+
+// [bridgeConfigPrefix]
+func BridgeConfigKey() []byte {
+	return bridgeConfigLayout.Key()
+}
+
+// [bridgeEscrowPrefix] + [destinationChainID]
+func BridgeEscrowKey(chainID ids.ID) []byte {
+	return bridgeEscrowLayout.Key(chainID[:])
+}
+
+// [bridgeMintedPrefix] + [sourceChainID]
+func BridgeMintedKey(chainID ids.ID) []byte {
+	return bridgeMintedLayout.Key(chainID[:])
+}
+
+// [bridgeTransferPrefix] + [sourceChainID] + [transferID]
+func BridgeTransferKey(chainID ids.ID, transferID ids.ID) []byte {
+	return bridgeTransferLayout.Key(chainID[:], transferID[:])
+}
+
+func GetBridgeConfig(
+	ctx context.Context,
+	im state.Immutable,
+) (*BridgeConfig, bool, error) {
+	return innerGetBridgeConfig(im.GetValue(ctx, BridgeConfigKey()))
+}
+
+// Used to serve RPC queries
+func GetBridgeConfigFromState(
+	ctx context.Context,
+	f ReadState,
+) (*BridgeConfig, bool, error) {
+	values, errs := f(ctx, [][]byte{BridgeConfigKey()})
+	return innerGetBridgeConfig(values[0], errs[0])
+}
+
+func innerGetBridgeConfig(v []byte, err error) (*BridgeConfig, bool, error) {
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	p := codec.NewReader(v, len(v))
+	c := &BridgeConfig{Quorum: p.UnpackByte()}
+	numRelayers := int(p.UnpackByte())
+	if numRelayers > MaxBridgeRelayers {
+		return nil, false, ErrInvalidBridgeConfig
+	}
+	c.Relayers = make([]codec.Bytes, numRelayers)
+	for i := range c.Relayers {
+		c.Relayers[i] = make([]byte, bls.PublicKeyLen)
+		p.UnpackFixedBytes(bls.PublicKeyLen, (*[]byte)(&c.Relayers[i]))
+	}
+	if err := p.Err(); err != nil {
+		return nil, false, err
+	}
+	return c, true, nil
+}
+
+func SetBridgeConfig(
+	ctx context.Context,
+	mu state.Mutable,
+	c *BridgeConfig,
+) error {
+	if err := c.Verify(); err != nil {
+		return err
+	}
+	size := 2 + len(c.Relayers)*bls.PublicKeyLen
+	p := codec.NewWriter(size, size)
+	p.PackByte(c.Quorum)
+	p.PackByte(byte(len(c.Relayers)))
+	for _, relayer := range c.Relayers {
+		p.PackFixedBytes(relayer)
+	}
+	if err := p.Err(); err != nil {
+		return err
+	}
+	return mu.Insert(ctx, BridgeConfigKey(), p.Bytes())
+}
+
+// GetBridgeEscrow returns the amount locked on this chain for transfers to
+// [chainID] that has not been unlocked yet.
+func GetBridgeEscrow(
+	ctx context.Context,
+	im state.Immutable,
+	chainID ids.ID,
+) (uint64, error) {
+	return innerGetBridgeAmount(im.GetValue(ctx, BridgeEscrowKey(chainID)))
+}
+
+// Used to serve RPC queries
+func GetBridgeEscrowFromState(
+	ctx context.Context,
+	f ReadState,
+	chainID ids.ID,
+) (uint64, error) {
+	values, errs := f(ctx, [][]byte{BridgeEscrowKey(chainID)})
+	return innerGetBridgeAmount(values[0], errs[0])
+}
+
+func AddBridgeEscrow(
+	ctx context.Context,
+	mu state.Mutable,
+	chainID ids.ID,
+	amount uint64,
+) (uint64, error) {
+	return addBridgeAmount(ctx, mu, BridgeEscrowKey(chainID), amount)
+}
+
+func SubBridgeEscrow(
+	ctx context.Context,
+	mu state.Mutable,
+	chainID ids.ID,
+	amount uint64,
+) (uint64, error) {
+	return subBridgeAmount(ctx, mu, BridgeEscrowKey(chainID), amount)
+}
+
+// GetBridgeMinted returns the amount minted on this chain for transfers from
+// [chainID] that has not been burned yet.
+func GetBridgeMinted(
+	ctx context.Context,
+	im state.Immutable,
+	chainID ids.ID,
+) (uint64, error) {
+	return innerGetBridgeAmount(im.GetValue(ctx, BridgeMintedKey(chainID)))
+}
+
+// Used to serve RPC queries
+func GetBridgeMintedFromState(
+	ctx context.Context,
+	f ReadState,
+	chainID ids.ID,
+) (uint64, error) {
+	values, errs := f(ctx, [][]byte{BridgeMintedKey(chainID)})
+	return innerGetBridgeAmount(values[0], errs[0])
+}
+
+func AddBridgeMinted(
+	ctx context.Context,
+	mu state.Mutable,
+	chainID ids.ID,
+	amount uint64,
+) (uint64, error) {
+	return addBridgeAmount(ctx, mu, BridgeMintedKey(chainID), amount)
+}
+
+func SubBridgeMinted(
+	ctx context.Context,
+	mu state.Mutable,
+	chainID ids.ID,
+	amount uint64,
+) (uint64, error) {
+	return subBridgeAmount(ctx, mu, BridgeMintedKey(chainID), amount)
+}
+
+func innerGetBridgeAmount(v []byte, err error) (uint64, error) {
+	if errors.Is(err, database.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	val, err := database.ParseUInt64(v)
+	if err != nil {
+		return 0, err
+	}
+	return val, nil
+}
+
+func addBridgeAmount(
+	ctx context.Context,
+	mu state.Mutable,
+	key []byte,
+	amount uint64,
+) (uint64, error) {
+	current, err := innerGetBridgeAmount(mu.GetValue(ctx, key))
+	if err != nil {
+		return 0, err
+	}
+	nval, err := smath.Add(current, amount)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"%w: could not add bridge amount (current=%d, amount=%d)",
+			ErrInvalidBridgeAmount,
+			current,
+			amount,
+		)
+	}
+	return nval, mu.Insert(ctx, key, binary.BigEndian.AppendUint64(nil, nval))
+}
+
+func subBridgeAmount(
+	ctx context.Context,
+	mu state.Mutable,
+	key []byte,
+	amount uint64,
+) (uint64, error) {
+	current, err := innerGetBridgeAmount(mu.GetValue(ctx, key))
+	if err != nil {
+		return 0, err
+	}
+	nval, err := smath.Sub(current, amount)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"%w: could not subtract bridge amount (current=%d, amount=%d)",
+			ErrInvalidBridgeAmount,
+			current,
+			amount,
+		)
+	}
+	if nval == 0 {
+		return 0, mu.Remove(ctx, key)
+	}
+	return nval, mu.Insert(ctx, key, binary.BigEndian.AppendUint64(nil, nval))
+}
+
+// GetBridgeTransfer returns the timestamp at which the transfer [transferID]
+// from [chainID] was processed on this chain. If it has not been processed,
+// it returns false.
+func GetBridgeTransfer(
+	ctx context.Context,
+	im state.Immutable,
+	chainID ids.ID,
+	transferID ids.ID,
+) (int64, bool, error) {
+	return innerGetBridgeTransfer(im.GetValue(ctx, BridgeTransferKey(chainID, transferID)))
+}
+
+// Used to serve RPC queries
+func GetBridgeTransferFromState(
+	ctx context.Context,
+	f ReadState,
+	chainID ids.ID,
+	transferID ids.ID,
+) (int64, bool, error) {
+	values, errs := f(ctx, [][]byte{BridgeTransferKey(chainID, transferID)})
+	return innerGetBridgeTransfer(values[0], errs[0])
+}
+
+func innerGetBridgeTransfer(v []byte, err error) (int64, bool, error) {
+	if errors.Is(err, database.ErrNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if len(v) != consts.Int64Len {
+		return 0, false, ErrInvalidBridgeTransfer
+	}
+	return int64(binary.BigEndian.Uint64(v)), true, nil
+}
+
+// SetBridgeTransfer marks the transfer [transferID] from [chainID] as
+// processed at [timestamp] so that it cannot be replayed.
+func SetBridgeTransfer(
+	ctx context.Context,
+	mu state.Mutable,
+	chainID ids.ID,
+	transferID ids.ID,
+	timestamp int64,
+) error {
+	return mu.Insert(ctx, BridgeTransferKey(chainID, transferID), binary.BigEndian.AppendUint64(nil, uint64(timestamp)))
+}
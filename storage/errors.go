@@ -8,4 +8,21 @@ import "errors"
 var (
 	ErrInvalidAddress = errors.New("invalid address")
 	ErrInvalidBalance = errors.New("invalid balance")
+
+	ErrDuplicatePrefix = errors.New("duplicate prefix")
+	ErrDuplicateRecord = errors.New("duplicate record")
+
+	ErrStateBehind = errors.New("state is behind")
+
+	ErrInvalidMigration = errors.New("invalid migration")
+	ErrSchemaBehind     = errors.New("state schema is behind the record layout")
+
+	ErrSpendingLimitExceeded = errors.New("spending limit exceeded")
+
+	ErrTooManySubscriptions     = errors.New("too many subscriptions")
+	ErrInvalidSubscriptionIndex = errors.New("invalid subscription index")
+
+	ErrInvalidBridgeConfig   = errors.New("invalid bridge config")
+	ErrInvalidBridgeAmount   = errors.New("invalid bridge amount")
+	ErrInvalidBridgeTransfer = errors.New("invalid bridge transfer")
 )
@@ -0,0 +1,30 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// layoutgen writes the state layout registered in the storage package to
+// layout.md and layout.json in the current directory.
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+)
+
+func main() {
+	var doc, layout bytes.Buffer
+	if err := storage.Registry().WriteDoc(&doc); err != nil {
+		log.Fatal(err)
+	}
+	if err := storage.Registry().WriteJSON(&layout); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("layout.md", doc.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("layout.json", layout.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/database"
+
+	"github.com/ava-labs/hypersdk/state"
+)
+
+const SchemaVersionChunks uint16 = 1
+
+var schemaVersionRecord = NewRecord[struct{}, uint64](
+	register(&RecordLayout{
+		Name:        "schema version",
+		Prefix:      0xc,
+		Chunks:      SchemaVersionChunks,
+		ValueFields: "version",
+	}),
+	func(struct{}) []byte { return nil },
+	Uint64Codec{},
+)
+
+// [schemaVersionPrefix]
+func SchemaVersionKey() []byte {
+	return schemaVersionRecord.Key(struct{}{})
+}
+
+// GetSchemaVersion returns the version of the last migration applied to
+// state. It is 0 if none was.
+func GetSchemaVersion(ctx context.Context, im state.Immutable) (uint64, error) {
+	version, _, err := schemaVersionRecord.Get(ctx, im, struct{}{})
+	return version, err
+}
+
+// Used to serve RPC queries
+func GetSchemaVersionFromState(ctx context.Context, f ReadState) (uint64, error) {
+	version, _, err := schemaVersionRecord.GetFromState(ctx, f, struct{}{})
+	return version, err
+}
+
+func SetSchemaVersion(ctx context.Context, mu state.Mutable, version uint64) error {
+	return schemaVersionRecord.Put(ctx, mu, struct{}{}, version)
+}
+
+// Migration rewrites the state from the layout of [Version]-1 to the layout
+// of [Version]. It runs in the first Migrate action included after its
+// activation, not at the start of a block, as the block executor has no
+// hook for it. Until then, records whose [RecordLayout.Since] is [Version]
+// can be neither read nor written.
+//
+// As it runs in a transaction, a migration can only access the keys in
+// [StateKeys], with the permissions declared there, within the unit limits
+// of a block. It therefore cannot rewrite an open-ended set of records, such
+// as every balance: changing the encoding of those requires a new prefix.
+type Migration struct {
+	Version   uint64
+	Name      string
+	StateKeys state.Keys
+	Migrate   func(ctx context.Context, mu state.Mutable) error
+}
+
+// MigrationRules schedule the migrations of a chain.
+type MigrationRules interface {
+	// GetMigrationTime returns the timestamp from which migration [version]
+	// is active. It returns false if [version] is not scheduled.
+	GetMigrationTime(version uint64) (int64, bool)
+}
+
+// MigrationRegistry holds the migrations of the VM in version order.
+type MigrationRegistry struct {
+	migrations []*Migration
+}
+
+func NewMigrationRegistry() *MigrationRegistry {
+	return &MigrationRegistry{}
+}
+
+// Register adds [m] to the registry. Versions start at 1 and must be
+// registered in order.
+func (r *MigrationRegistry) Register(m *Migration) error {
+	if m.Version != r.Latest()+1 {
+		return fmt.Errorf("%w: %s has version %d, expected %d", ErrInvalidMigration, m.Name, m.Version, r.Latest()+1)
+	}
+	if m.Migrate == nil {
+		return fmt.Errorf("%w: %s has no migration function", ErrInvalidMigration, m.Name)
+	}
+	r.migrations = append(r.migrations, m)
+	return nil
+}
+
+// Latest returns the version of the last registered migration, which is the
+// version of the current layout.
+func (r *MigrationRegistry) Latest() uint64 {
+	return uint64(len(r.migrations))
+}
+
+// Migrations returns the registered migrations in version order.
+func (r *MigrationRegistry) Migrations() []*Migration {
+	return slices.Clone(r.migrations)
+}
+
+// StateKeys returns the keys [Apply] may access: the schema version and the
+// keys of every registered migration.
+func (r *MigrationRegistry) StateKeys() state.Keys {
+	keys := state.Keys{string(SchemaVersionKey()): state.Read | state.Write}
+	for _, m := range r.migrations {
+		for k, p := range m.StateKeys {
+			keys[k] |= p
+		}
+	}
+	return keys
+}
+
+// Apply runs, in order, the migrations after the version recorded in [mu]
+// that [rules] activate at or before [timestamp], and records the version of
+// the last one. It is called by the Migrate action, so every migration runs
+// once, in the first transaction that applies it. It returns the migrations
+// it ran.
+func (r *MigrationRegistry) Apply(
+	ctx context.Context,
+	mu state.Mutable,
+	rules MigrationRules,
+	timestamp int64,
+) ([]*Migration, error) {
+	version, err := GetSchemaVersion(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if version > r.Latest() {
+		return nil, fmt.Errorf("%w: state is at version %d, latest is %d", ErrInvalidMigration, version, r.Latest())
+	}
+	applied := []*Migration{}
+	for _, m := range r.migrations[version:] {
+		activation, ok := rules.GetMigrationTime(m.Version)
+		if !ok || timestamp < activation {
+			break
+		}
+		if err := m.Migrate(ctx, mu); err != nil {
+			return nil, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if err := SetSchemaVersion(ctx, mu, m.Version); err != nil {
+			return nil, err
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// MigrateRecords rewrites, with [f], the values stored under the keys of
+// [keys] that have the prefix of [layout]. Keys without a value are skipped.
+// If [f] returns nil, the key is removed. Keys are rewritten in order, so
+// that every node reports the same error.
+func MigrateRecords(
+	ctx context.Context,
+	mu state.Mutable,
+	layout *RecordLayout,
+	keys state.Keys,
+	f func(key []byte, value []byte) ([]byte, error),
+) error {
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		if len(k) > 0 && k[0] == layout.Prefix {
+			sorted = append(sorted, k)
+		}
+	}
+	slices.Sort(sorted)
+
+	for _, k := range sorted {
+		key := []byte(k)
+		value, err := mu.GetValue(ctx, key)
+		if errors.Is(err, database.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		value, err = f(key, value)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			err = mu.Remove(ctx, key)
+		} else {
+			err = mu.Insert(ctx, key, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var migrations = NewMigrationRegistry()
+
+// Migrations returns the registry holding every migration of the VM.
+func Migrations() *MigrationRegistry {
+	return migrations
+}
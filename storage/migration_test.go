@@ -0,0 +1,153 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+type migrationState struct {
+	*memdb.Database
+}
+
+func (s migrationState) GetValue(_ context.Context, key []byte) ([]byte, error) {
+	return s.Get(key)
+}
+
+func (s migrationState) Insert(_ context.Context, key []byte, value []byte) error {
+	return s.Put(key, value)
+}
+
+func (s migrationState) Remove(_ context.Context, key []byte) error {
+	return s.Delete(key)
+}
+
+type migrationRules map[uint64]int64
+
+func (r migrationRules) GetMigrationTime(version uint64) (int64, bool) {
+	t, ok := r[version]
+	return t, ok
+}
+
+func TestMigrations(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// The old balance store holds 8 byte balances. Version 1 appends an 8
+	// byte reserved balance, and version 2 drops the empty balances.
+	a := codectest.NewRandomAddress()
+	b := codectest.NewRandomAddress()
+	keys := state.Keys{
+		string(BalanceKey(a)):       state.Read | state.Write,
+		string(BalanceKey(b)):       state.Read | state.Write,
+		string(SpendingLimitKey(a)): state.Read | state.Write,
+	}
+	r := NewMigrationRegistry()
+	require.NoError(r.Register(&Migration{
+		Version:   1,
+		Name:      "reserved balance",
+		StateKeys: keys,
+		Migrate: func(ctx context.Context, mu state.Mutable) error {
+			return MigrateRecords(ctx, mu, balanceRecord.Layout(), keys, func(_ []byte, v []byte) ([]byte, error) {
+				balance, err := database.ParseUInt64(v)
+				if err != nil {
+					return nil, err
+				}
+				return binary.BigEndian.AppendUint64(database.PackUInt64(balance), 0), nil
+			})
+		},
+	}))
+	require.NoError(r.Register(&Migration{
+		Version:   2,
+		Name:      "drop empty balances",
+		StateKeys: keys,
+		Migrate: func(ctx context.Context, mu state.Mutable) error {
+			return MigrateRecords(ctx, mu, balanceRecord.Layout(), keys, func(_ []byte, v []byte) ([]byte, error) {
+				if binary.BigEndian.Uint64(v) == 0 {
+					return nil, nil
+				}
+				return v, nil
+			})
+		},
+	}))
+	require.ErrorIs(r.Register(&Migration{Version: 4, Name: "gap", Migrate: r.migrations[0].Migrate}), ErrInvalidMigration)
+	require.Equal(uint64(2), r.Latest())
+	require.Len(r.StateKeys(), 4)
+
+	s := migrationState{memdb.New()}
+	for addr, balance := range map[codec.Address]uint64{a: 100, b: 0} {
+		require.NoError(s.Put(BalanceKey(addr), database.PackUInt64(balance)))
+	}
+	require.NoError(s.Put(SpendingLimitKey(a), []byte{1}))
+
+	rules := migrationRules{1: 10, 2: 20}
+
+	// Nothing runs before the activation.
+	applied, err := r.Apply(ctx, s, rules, 9)
+	require.NoError(err)
+	require.Empty(applied)
+
+	// Only the active migrations run, once.
+	applied, err = r.Apply(ctx, s, rules, 15)
+	require.NoError(err)
+	require.Len(applied, 1)
+	applied, err = r.Apply(ctx, s, rules, 16)
+	require.NoError(err)
+	require.Empty(applied)
+
+	version, err := GetSchemaVersion(ctx, s)
+	require.NoError(err)
+	require.Equal(uint64(1), version)
+	v, err := s.Get(BalanceKey(a))
+	require.NoError(err)
+	require.Equal(binary.BigEndian.AppendUint64(database.PackUInt64(100), 0), v)
+	v, err = s.Get(BalanceKey(b))
+	require.NoError(err)
+	require.Len(v, 16)
+
+	applied, err = r.Apply(ctx, s, rules, 20)
+	require.NoError(err)
+	require.Len(applied, 1)
+	version, err = GetSchemaVersion(ctx, s)
+	require.NoError(err)
+	require.Equal(uint64(2), version)
+	_, err = s.Get(BalanceKey(b))
+	require.ErrorIs(err, database.ErrNotFound)
+
+	// Other records are left alone.
+	v, err = s.Get(SpendingLimitKey(a))
+	require.NoError(err)
+	require.Equal([]byte{1}, v)
+
+	// Migrations that are not scheduled never run.
+	s = migrationState{memdb.New()}
+	applied, err = r.Apply(ctx, s, migrationRules{2: 0}, 100)
+	require.NoError(err)
+	require.Empty(applied)
+
+	// A failed migration leaves the version unchanged.
+	errFailed := errors.New("failed")
+	r = NewMigrationRegistry()
+	require.NoError(r.Register(&Migration{
+		Version: 1,
+		Name:    "fail",
+		Migrate: func(context.Context, state.Mutable) error { return errFailed },
+	}))
+	_, err = r.Apply(ctx, s, migrationRules{1: 0}, 100)
+	require.ErrorIs(err, errFailed)
+	version, err = GetSchemaVersion(ctx, s)
+	require.NoError(err)
+	require.Zero(version)
+}
@@ -0,0 +1,175 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// ValueCodec serializes the values of a [Record].
+type ValueCodec[V any] interface {
+	Encode(V) ([]byte, error)
+	Decode([]byte) (V, error)
+}
+
+var _ ValueCodec[uint64] = Uint64Codec{}
+
+// Uint64Codec stores a uint64 as 8 big-endian bytes.
+type Uint64Codec struct{}
+
+func (Uint64Codec) Encode(v uint64) ([]byte, error) {
+	return binary.BigEndian.AppendUint64(make([]byte, 0, consts.Uint64Len), v), nil
+}
+
+func (Uint64Codec) Decode(b []byte) (uint64, error) {
+	return database.ParseUInt64(b)
+}
+
+// PackerCodec serializes values with a [codec.Packer]. [Size] is the
+// maximum encoded size of a value.
+type PackerCodec[V any] struct {
+	Size   int
+	Pack   func(*codec.Packer, V)
+	Unpack func(*codec.Packer) V
+}
+
+func (c PackerCodec[V]) Encode(v V) ([]byte, error) {
+	p := codec.NewWriter(c.Size, c.Size)
+	c.Pack(p, v)
+	return p.Bytes(), p.Err()
+}
+
+func (c PackerCodec[V]) Decode(b []byte) (V, error) {
+	p := codec.NewReader(b, c.Size)
+	v := c.Unpack(p)
+	return v, p.Err()
+}
+
+// Record is a typed record stored under the prefix of [RecordLayout]. Keys of
+// type [K] are turned into state keys by a key builder, and values of type
+// [V] are serialized by a [ValueCodec].
+type Record[K any, V any] struct {
+	layout *RecordLayout
+	key    func(K) []byte
+	codec  ValueCodec[V]
+}
+
+// NewRecord returns a record stored under [layout]. [key] returns the part
+// of the state key between the prefix and the chunk suffix.
+func NewRecord[K any, V any](layout *RecordLayout, key func(K) []byte, codec ValueCodec[V]) *Record[K, V] {
+	return &Record[K, V]{
+		layout: layout,
+		key:    key,
+		codec:  codec,
+	}
+}
+
+func (r *Record[K, V]) Layout() *RecordLayout {
+	return r.layout
+}
+
+// Key returns the state key of [k].
+func (r *Record[K, V]) Key(k K) []byte {
+	return r.layout.Key(r.key(k))
+}
+
+// Get returns the value stored at [k]. If there is none, it returns false.
+func (r *Record[K, V]) Get(ctx context.Context, im state.Immutable, k K) (V, bool, error) {
+	if err := r.checkSchema(ctx, im); err != nil {
+		var v V
+		return v, false, err
+	}
+	return r.decode(im.GetValue(ctx, r.Key(k)))
+}
+
+// Exists reports whether a value is stored at [k].
+func (r *Record[K, V]) Exists(ctx context.Context, im state.Immutable, k K) (bool, error) {
+	_, err := im.GetValue(ctx, r.Key(k))
+	if errors.Is(err, database.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *Record[K, V]) Put(ctx context.Context, mu state.Mutable, k K, v V) error {
+	if err := r.checkSchema(ctx, mu); err != nil {
+		return err
+	}
+	b, err := r.codec.Encode(v)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, r.Key(k), b)
+}
+
+func (r *Record[K, V]) Delete(ctx context.Context, mu state.Mutable, k K) error {
+	return mu.Remove(ctx, r.Key(k))
+}
+
+// Used to serve RPC queries
+func (r *Record[K, V]) GetFromState(ctx context.Context, f ReadState, k K) (V, bool, error) {
+	if r.layout.Since == 0 {
+		values, errs := f(ctx, [][]byte{r.Key(k)})
+		return r.decode(values[0], errs[0])
+	}
+	// The schema version is read along with the value so they are
+	// consistent with each other.
+	values, errs := f(ctx, [][]byte{SchemaVersionKey(), r.Key(k)})
+	version, _, err := schemaVersionRecord.decode(values[0], errs[0])
+	if err == nil {
+		err = r.layout.checkSchema(version)
+	}
+	if err != nil {
+		var v V
+		return v, false, err
+	}
+	return r.decode(values[1], errs[1])
+}
+
+// Used to serve RPC queries
+func (r *Record[K, V]) ExistsFromState(ctx context.Context, f ReadState, k K) (bool, error) {
+	_, errs := f(ctx, [][]byte{r.Key(k)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return false, nil
+	}
+	return errs[0] == nil, errs[0]
+}
+
+// checkSchema fails if the state [im] holds has not reached the encoding
+// of [r].
+func (r *Record[K, V]) checkSchema(ctx context.Context, im state.Immutable) error {
+	if r.layout.Since == 0 {
+		return nil
+	}
+	version, err := GetSchemaVersion(ctx, im)
+	if err != nil {
+		return err
+	}
+	return r.layout.checkSchema(version)
+}
+
+// decode returns the value [b] holds. Values read in bulk must be checked
+// with [RecordLayout.checkSchema] first.
+func (r *Record[K, V]) decode(b []byte, err error) (V, bool, error) {
+	var v V
+	if errors.Is(err, database.ErrNotFound) {
+		return v, false, nil
+	}
+	if err != nil {
+		return v, false, err
+	}
+	v, err = r.codec.Decode(b)
+	if err != nil {
+		return v, false, err
+	}
+	return v, true, nil
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/consts"
+)
+
+type testValue struct {
+	A uint64
+	B int64
+}
+
+func readState(store *chaintest.InMemoryStore) ReadState {
+	return func(ctx context.Context, keys [][]byte) ([][]byte, []error) {
+		values := make([][]byte, len(keys))
+		errs := make([]error, len(keys))
+		for i, key := range keys {
+			values[i], errs[i] = store.GetValue(ctx, key)
+		}
+		return values, errs
+	}
+}
+
+func TestRecord(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	r := NewRecord(
+		&RecordLayout{Name: "test", Prefix: 0xff, KeyLen: codec.AddressLen, Chunks: 1},
+		func(addr codec.Address) []byte { return addr[:] },
+		PackerCodec[*testValue]{
+			Size: consts.Uint64Len + consts.Int64Len,
+			Pack: func(p *codec.Packer, v *testValue) {
+				p.PackUint64(v.A)
+				p.PackInt64(v.B)
+			},
+			Unpack: func(p *codec.Packer) *testValue {
+				return &testValue{
+					A: p.UnpackUint64(true),
+					B: p.UnpackInt64(false),
+				}
+			},
+		},
+	)
+	store := chaintest.NewInMemoryStore()
+	addr := codectest.NewRandomAddress()
+
+	v, exists, err := r.Get(ctx, store, addr)
+	require.NoError(err)
+	require.False(exists)
+	require.Nil(v)
+	exists, err = r.ExistsFromState(ctx, readState(store), addr)
+	require.NoError(err)
+	require.False(exists)
+
+	require.NoError(r.Put(ctx, store, addr, &testValue{A: 1, B: -2}))
+	v, exists, err = r.Get(ctx, store, addr)
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(&testValue{A: 1, B: -2}, v)
+	v, exists, err = r.GetFromState(ctx, readState(store), addr)
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(&testValue{A: 1, B: -2}, v)
+	exists, err = r.Exists(ctx, store, addr)
+	require.NoError(err)
+	require.True(exists)
+
+	require.NoError(r.Delete(ctx, store, addr))
+	exists, err = r.Exists(ctx, store, addr)
+	require.NoError(err)
+	require.False(exists)
+
+	// Values that fail to decode are reported instead of treated as missing.
+	require.NoError(store.Insert(ctx, r.Key(addr), []byte{1}))
+	_, _, err = r.Get(ctx, store, addr)
+	require.Error(err)
+}
+
+func TestBalanceRecordEncoding(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	store := chaintest.NewInMemoryStore()
+	addr := codectest.NewRandomAddress()
+	require.NoError(SetBalance(ctx, store, addr, 258))
+
+	v, err := store.GetValue(ctx, BalanceKey(addr))
+	require.NoError(err)
+	require.Equal([]byte{0, 0, 0, 0, 0, 0, 1, 2}, v)
+}
+
+func TestGetBalancesFromState(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	store := chaintest.NewInMemoryStore()
+	addrs := []codec.Address{codectest.NewRandomAddress(), codectest.NewRandomAddress(), codectest.NewRandomAddress()}
+	require.NoError(SetBalance(ctx, store, addrs[0], 5))
+	require.NoError(store.Insert(ctx, BalanceKey(addrs[2]), []byte{1}))
+
+	calls := 0
+	f := func(ctx context.Context, keys [][]byte) ([][]byte, []error) {
+		calls++
+		return readState(store)(ctx, keys)
+	}
+	balances, errs := GetBalancesFromState(ctx, f, addrs)
+	require.Equal(1, calls)
+	require.Equal([]uint64{5, 0, 0}, balances)
+	require.NoError(errs[0])
+	require.NoError(errs[1])
+	require.Error(errs[2])
+}
+
+func TestIterateBalances(t *testing.T) {
+	require := require.New(t)
+
+	db := memdb.New()
+	addrs := []codec.Address{codectest.NewRandomAddress(), codectest.NewRandomAddress()}
+	for i, addr := range addrs {
+		require.NoError(db.Put(BalanceKey(addr), binary.BigEndian.AppendUint64(nil, uint64(i+1))))
+	}
+	require.NoError(db.Put(SpendingLimitKey(addrs[0]), []byte{1}))
+
+	balances := map[codec.Address]uint64{}
+	require.NoError(IterateBalances(db, func(addr codec.Address, balance uint64) error {
+		balances[addr] = balance
+		return nil
+	}))
+	require.Equal(map[codec.Address]uint64{addrs[0]: 1, addrs[1]: 2}, balances)
+}
+
+func TestRecordSchemaGate(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// The value of the record changed encoding in version 2.
+	record := NewRecord[codec.Address, uint64](
+		&RecordLayout{Name: "gated", Prefix: 0xf0, KeyLen: codec.AddressLen, Since: 2},
+		func(addr codec.Address) []byte { return addr[:] },
+		Uint64Codec{},
+	)
+	addr := codectest.NewRandomAddress()
+	store := chaintest.NewInMemoryStore()
+
+	for _, version := range []uint64{0, 1} {
+		if version > 0 {
+			require.NoError(SetSchemaVersion(ctx, store, version))
+		}
+		require.ErrorIs(record.Put(ctx, store, addr, 1), ErrSchemaBehind)
+		_, _, err := record.Get(ctx, store, addr)
+		require.ErrorIs(err, ErrSchemaBehind)
+		_, _, err = record.GetFromState(ctx, readState(store), addr)
+		require.ErrorIs(err, ErrSchemaBehind)
+	}
+
+	require.NoError(SetSchemaVersion(ctx, store, 2))
+	require.NoError(record.Put(ctx, store, addr, 1))
+	v, exists, err := record.Get(ctx, store, addr)
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(uint64(1), v)
+	v, exists, err = record.GetFromState(ctx, readState(store), addr)
+	require.NoError(err)
+	require.True(exists)
+	require.Equal(uint64(1), v)
+}
@@ -0,0 +1,191 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state/metadata"
+)
+
+//go:generate go run ./layoutgen
+
+// RecordLayout describes a record type stored under a single byte prefix.
+// Keys are laid out as [Prefix] + [key] + [Chunks], where [key] is [KeyLen]
+// bytes long.
+type RecordLayout struct {
+	Name   string `json:"name"`
+	Prefix byte   `json:"prefix"`
+	KeyLen int    `json:"keyLen"`
+	Chunks uint16 `json:"chunks"`
+
+	// KeyFields and ValueFields describe the fields of the key and value
+	// for documentation.
+	KeyFields   string `json:"keyFields"`
+	ValueFields string `json:"valueFields"`
+
+	// Reserved is set for prefixes used by the hypersdk itself.
+	Reserved bool `json:"reserved"`
+
+	// Since is the schema version that introduced the current encoding of
+	// the record. Until the state reaches it, values may still be in the
+	// previous encoding, so the record can be neither read nor written.
+	// Actions that access a record with a non-zero [Since] must declare
+	// [SchemaVersionKey] as read.
+	Since uint64 `json:"since,omitempty"`
+}
+
+// Key returns the state key made of [parts]. It panics if [parts] do not
+// add up to [KeyLen] bytes.
+func (l *RecordLayout) Key(parts ...[]byte) []byte {
+	k := make([]byte, 1, 1+l.KeyLen+consts.Uint16Len)
+	k[0] = l.Prefix
+	for _, part := range parts {
+		k = append(k, part...)
+	}
+	if len(k) != 1+l.KeyLen {
+		panic(fmt.Sprintf("%s key is %d bytes, expected %d", l.Name, len(k)-1, l.KeyLen))
+	}
+	return binary.BigEndian.AppendUint16(k, l.Chunks)
+}
+
+// checkSchema fails if a state at schema [version] may still hold values of
+// [l] in their previous encoding.
+func (l *RecordLayout) checkSchema(version uint64) error {
+	if version < l.Since {
+		return fmt.Errorf("%w: %s requires version %d, state is at %d", ErrSchemaBehind, l.Name, l.Since, version)
+	}
+	return nil
+}
+
+// PrefixRegistry tracks the prefixes used in state so that two record types
+// can never share one.
+type PrefixRegistry struct {
+	layouts  []*RecordLayout
+	reserved [][]byte
+}
+
+// NewPrefixRegistry returns a registry in which the prefixes of [m] are
+// reserved.
+func NewPrefixRegistry(m chain.MetadataManager) *PrefixRegistry {
+	r := &PrefixRegistry{
+		reserved: [][]byte{m.HeightPrefix(), m.TimestampPrefix(), m.FeePrefix()},
+	}
+	for i, name := range []string{"hypersdk-height", "hypersdk-timestamp", "hypersdk-fee"} {
+		if len(r.reserved[i]) != 1 {
+			continue
+		}
+		r.layouts = append(r.layouts, &RecordLayout{
+			Name:     name,
+			Prefix:   r.reserved[i][0],
+			Reserved: true,
+		})
+	}
+	return r
+}
+
+// Register adds [l] to the registry. It fails if the prefix or name of [l]
+// is already in use.
+func (r *PrefixRegistry) Register(l *RecordLayout) error {
+	for _, prefix := range r.reserved {
+		if bytes.HasPrefix(prefix, []byte{l.Prefix}) {
+			return fmt.Errorf("%w: %s uses reserved prefix %#x", ErrDuplicatePrefix, l.Name, l.Prefix)
+		}
+	}
+	for _, other := range r.layouts {
+		if other.Reserved {
+			continue
+		}
+		if other.Prefix == l.Prefix {
+			return fmt.Errorf("%w: %s and %s both use %#x", ErrDuplicatePrefix, other.Name, l.Name, l.Prefix)
+		}
+		if other.Name == l.Name {
+			return fmt.Errorf("%w: %s", ErrDuplicateRecord, l.Name)
+		}
+	}
+	r.layouts = append(r.layouts, l)
+	return nil
+}
+
+// Layouts returns the registered record layouts, including the reserved
+// ones, sorted by prefix.
+func (r *PrefixRegistry) Layouts() []*RecordLayout {
+	layouts := slices.Clone(r.layouts)
+	slices.SortFunc(layouts, func(a, b *RecordLayout) int {
+		return int(a.Prefix) - int(b.Prefix)
+	})
+	return layouts
+}
+
+// Prefixes returns the prefixes registered by the VM, excluding the
+// reserved ones.
+func (r *PrefixRegistry) Prefixes() [][]byte {
+	prefixes := make([][]byte, 0, len(r.layouts))
+	for _, l := range r.Layouts() {
+		if !l.Reserved {
+			prefixes = append(prefixes, []byte{l.Prefix})
+		}
+	}
+	return prefixes
+}
+
+// WriteDoc writes a markdown description of the state layout to [w].
+func (r *PrefixRegistry) WriteDoc(w io.Writer) error {
+	if _, err := fmt.Fprint(w, "# State Layout\n\n"+
+		"<!-- Code generated by go generate ./storage. DO NOT EDIT. -->\n\n"+
+		"Keys are laid out as `[prefix] + [key] + [chunks]`, where `[chunks]` is a\n"+
+		"big-endian uint16.\n\n"+
+		"| Prefix | Record | Key | Key Length | Chunks | Value |\n"+
+		"| ------ | ------ | --- | ---------- | ------ | ----- |\n",
+	); err != nil {
+		return err
+	}
+	for _, l := range r.Layouts() {
+		if l.Reserved {
+			if _, err := fmt.Fprintf(w, "| `%#x` | %s | | | | reserved |\n", l.Prefix, l.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "| `%#x` | %s | %s | %d | %d | %s |\n", l.Prefix, l.Name, escapeCell(l.KeyFields), l.KeyLen, l.Chunks, escapeCell(l.ValueFields)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// WriteJSON writes the state layout to [w] as JSON.
+func (r *PrefixRegistry) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Layouts())
+}
+
+var registry = NewPrefixRegistry(metadata.NewDefaultManager())
+
+// Registry returns the registry holding every record type of the VM.
+func Registry() *PrefixRegistry {
+	return registry
+}
+
+// register adds [l] to the VM's registry and panics on collision, so that a
+// duplicate prefix fails at init time.
+func register(l *RecordLayout) *RecordLayout {
+	if err := registry.Register(l); err != nil {
+		panic(err)
+	}
+	return l
+}
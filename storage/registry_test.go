@@ -0,0 +1,53 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/state/metadata"
+)
+
+func TestPrefixRegistry(t *testing.T) {
+	require := require.New(t)
+
+	r := NewPrefixRegistry(metadata.NewDefaultManager())
+	require.NoError(r.Register(&RecordLayout{Name: "a", Prefix: 0x3}))
+	require.ErrorIs(r.Register(&RecordLayout{Name: "b", Prefix: 0x3}), ErrDuplicatePrefix)
+	require.ErrorIs(r.Register(&RecordLayout{Name: "a", Prefix: 0x4}), ErrDuplicateRecord)
+	require.ErrorIs(r.Register(&RecordLayout{Name: "c", Prefix: 0x0}), ErrDuplicatePrefix)
+	require.NoError(r.Register(&RecordLayout{Name: "d", Prefix: 0x4}))
+	require.Equal([][]byte{{0x3}, {0x4}}, r.Prefixes())
+}
+
+func TestRecordLayoutKey(t *testing.T) {
+	require := require.New(t)
+
+	l := &RecordLayout{Name: "test", Prefix: 0x7, KeyLen: 3, Chunks: 2}
+	require.Equal([]byte{0x7, 1, 2, 3, 0, 2}, l.Key([]byte{1}, []byte{2, 3}))
+	require.Panics(func() { l.Key([]byte{1}) })
+}
+
+func TestRegistryNoConflicts(t *testing.T) {
+	require.False(t, metadata.HasConflictingPrefixes(metadata.NewDefaultManager(), Registry().Prefixes()))
+}
+
+func TestGeneratedLayoutIsUpToDate(t *testing.T) {
+	require := require.New(t)
+
+	var doc, layout bytes.Buffer
+	require.NoError(Registry().WriteDoc(&doc))
+	require.NoError(Registry().WriteJSON(&layout))
+
+	expectedDoc, err := os.ReadFile("layout.md")
+	require.NoError(err)
+	require.Equal(string(expectedDoc), doc.String(), "run go generate ./storage")
+	expectedLayout, err := os.ReadFile("layout.json")
+	require.NoError(err)
+	require.Equal(string(expectedLayout), layout.String(), "run go generate ./storage")
+}
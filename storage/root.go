@@ -0,0 +1,158 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/maybe"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state/metadata"
+)
+
+// Balances are iterated in pages of this many keys.
+const rootPageLength = 1024
+
+var heightKey = chain.HeightKey(metadata.NewDefaultManager().HeightPrefix())
+
+// RootState is the VM state as of any root kept in its history.
+//
+// Accepted blocks are delivered to subscriptions after the state has been
+// committed, possibly along with the blocks that follow, so a subscription
+// that reads the current state may see the balances of a later block.
+// Reading at a root gives the balances as of a given block.
+type RootState interface {
+	GetMerkleRoot(ctx context.Context) (ids.ID, error)
+	GetRangeProofAtRoot(
+		ctx context.Context,
+		rootID ids.ID,
+		start maybe.Maybe[[]byte],
+		end maybe.Maybe[[]byte],
+		maxLength int,
+	) (*merkledb.RangeProof, error)
+}
+
+// ReadStateAtRoot returns a [ReadState] that reads the state at [root].
+//
+// Every range proof rebuilds the state at [root], so the keys are read in
+// one pass in key order: a proof starts at the first key not read yet and
+// ends at the last key, and keys close to each other share a proof.
+func ReadStateAtRoot(s RootState, root ids.ID) ReadState {
+	return func(ctx context.Context, keys [][]byte) ([][]byte, []error) {
+		values := make([][]byte, len(keys))
+		errs := make([]error, len(keys))
+		if len(keys) == 0 {
+			return values, errs
+		}
+		order := make([]int, len(keys))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortFunc(order, func(a, b int) int {
+			return bytes.Compare(keys[a], keys[b])
+		})
+		end := maybe.Some(keys[order[len(order)-1]])
+		for next := 0; next < len(order); {
+			proof, err := s.GetRangeProofAtRoot(ctx, root, maybe.Some(keys[order[next]]), end, rootPageLength)
+			if err != nil {
+				for _, i := range order[next:] {
+					errs[i] = err
+				}
+				break
+			}
+			kvs, j := proof.KeyValues, 0
+			for ; next < len(order); next++ {
+				i := order[next]
+				for j < len(kvs) && bytes.Compare(kvs[j].Key, keys[i]) < 0 {
+					j++
+				}
+				if j == len(kvs) && len(kvs) == rootPageLength {
+					// [keys[i]] is past the page and is read with the
+					// next proof.
+					break
+				}
+				if j < len(kvs) && bytes.Equal(kvs[j].Key, keys[i]) {
+					values[i] = kvs[j].Value
+				} else {
+					errs[i] = database.ErrNotFound
+				}
+			}
+		}
+		return values, errs
+	}
+}
+
+// GetHeightFromState returns the height of the last block executed on the
+// state [f] reads.
+func GetHeightFromState(ctx context.Context, f ReadState) (uint64, error) {
+	values, errs := f(ctx, [][]byte{heightKey})
+	if errs[0] != nil {
+		return 0, fmt.Errorf("%w: failed to read state height", errs[0])
+	}
+	return database.ParseUInt64(values[0])
+}
+
+// CurrentRoot returns the current root of [s] and the height of the last
+// block executed on it.
+func CurrentRoot(ctx context.Context, s RootState) (ids.ID, uint64, error) {
+	root, err := s.GetMerkleRoot(ctx)
+	if err != nil {
+		return ids.Empty, 0, err
+	}
+	height, err := GetHeightFromState(ctx, ReadStateAtRoot(s, root))
+	return root, height, err
+}
+
+// RootAfter returns the root of the state right after the block at [height]
+// was executed. It returns false if the state has moved past [height]: the
+// root is then the state root of the block at [height]+1, which is accepted
+// next.
+func RootAfter(ctx context.Context, s RootState, height uint64) (ids.ID, bool, error) {
+	root, current, err := CurrentRoot(ctx, s)
+	if err != nil {
+		return ids.Empty, false, err
+	}
+	if current < height {
+		return ids.Empty, false, fmt.Errorf("%w: state is at height %d, block at %d", ErrStateBehind, current, height)
+	}
+	return root, current == height, nil
+}
+
+// IterateBalancesAtRoot calls [f] with every non-zero balance in the state
+// at [root], in address order. It stops at the first error returned by [f].
+func IterateBalancesAtRoot(ctx context.Context, s RootState, root ids.ID, f func(codec.Address, uint64) error) error {
+	start := maybe.Some([]byte{BalancePrefix()})
+	end := maybe.Some([]byte{BalancePrefix() + 1})
+	for {
+		proof, err := s.GetRangeProofAtRoot(ctx, root, start, end, rootPageLength)
+		if err != nil {
+			return err
+		}
+		for _, kv := range proof.KeyValues {
+			addr, ok := ParseBalanceKey(kv.Key)
+			if !ok {
+				continue
+			}
+			bal, err := Uint64Codec{}.Decode(kv.Value)
+			if err != nil {
+				return err
+			}
+			if err := f(addr, bal); err != nil {
+				return err
+			}
+		}
+		if len(proof.KeyValues) < rootPageLength {
+			return nil
+		}
+		start = maybe.Some(append(proof.KeyValues[len(proof.KeyValues)-1].Key, 0))
+	}
+}
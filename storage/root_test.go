@@ -0,0 +1,150 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/maybe"
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+)
+
+// countingState counts the range proofs read from a [RootState].
+type countingState struct {
+	merkledb.MerkleDB
+	proofs int
+}
+
+func (s *countingState) GetRangeProofAtRoot(
+	ctx context.Context,
+	rootID ids.ID,
+	start maybe.Maybe[[]byte],
+	end maybe.Maybe[[]byte],
+	maxLength int,
+) (*merkledb.RangeProof, error) {
+	s.proofs++
+	return s.MerkleDB.GetRangeProofAtRoot(ctx, rootID, start, end, maxLength)
+}
+
+func newRootTestState(t *testing.T) merkledb.MerkleDB {
+	s, err := merkledb.New(context.Background(), memdb.New(), merkledb.Config{
+		BranchFactor:                merkledb.BranchFactor16,
+		HistoryLength:               4,
+		ValueNodeCacheSize:          units.MiB,
+		IntermediateNodeCacheSize:   units.MiB,
+		IntermediateWriteBufferSize: units.KiB,
+		IntermediateWriteBatchSize:  units.KiB,
+		TraceLevel:                  merkledb.NoTrace,
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestReadStateAtRoot(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := newRootTestState(t)
+	addr := codectest.NewRandomAddress()
+	commit := func(height uint64, balance uint64) {
+		view, err := s.NewView(ctx, merkledb.ViewChanges{BatchOps: []database.BatchOp{
+			{Key: heightKey, Value: database.PackUInt64(height)},
+			{Key: BalanceKey(addr), Value: database.PackUInt64(balance), Delete: balance == 0},
+		}})
+		require.NoError(err)
+		require.NoError(view.CommitToDB(ctx))
+	}
+
+	commit(1, 10)
+	root1, ok, err := RootAfter(ctx, s, 1)
+	require.NoError(err)
+	require.True(ok)
+	commit(2, 0)
+
+	// The state has moved past height 1, but its root is still readable.
+	_, ok, err = RootAfter(ctx, s, 1)
+	require.NoError(err)
+	require.False(ok)
+	_, _, err = RootAfter(ctx, s, 3)
+	require.ErrorIs(err, ErrStateBehind)
+
+	balances, errs := GetBalancesFromState(ctx, ReadStateAtRoot(s, root1), []codec.Address{addr})
+	require.NoError(errs[0])
+	require.Equal(uint64(10), balances[0])
+	height, err := GetHeightFromState(ctx, ReadStateAtRoot(s, root1))
+	require.NoError(err)
+	require.Equal(uint64(1), height)
+
+	root2, height, err := CurrentRoot(ctx, s)
+	require.NoError(err)
+	require.Equal(uint64(2), height)
+	balances, errs = GetBalancesFromState(ctx, ReadStateAtRoot(s, root2), []codec.Address{addr})
+	require.NoError(errs[0])
+	require.Zero(balances[0])
+
+	var found []codec.Address
+	require.NoError(IterateBalancesAtRoot(ctx, s, root1, func(a codec.Address, balance uint64) error {
+		require.Equal(uint64(10), balance)
+		found = append(found, a)
+		return nil
+	}))
+	require.Equal([]codec.Address{addr}, found)
+}
+
+func TestReadStateAtRootInOnePass(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Three pages of balances, every other one of which is read.
+	const n = 3 * rootPageLength
+	addrs := make([]codec.Address, n)
+	ops := make([]database.BatchOp, 0, n/2+1)
+	for i := range addrs {
+		addrs[i] = codec.CreateAddress(0, ids.ID{byte(i >> 8), byte(i)})
+		if i%2 == 0 {
+			ops = append(ops, database.BatchOp{Key: BalanceKey(addrs[i]), Value: database.PackUInt64(uint64(i + 1))})
+		}
+	}
+	ops = append(ops, database.BatchOp{Key: heightKey, Value: database.PackUInt64(1)})
+	db := newRootTestState(t)
+	view, err := db.NewView(ctx, merkledb.ViewChanges{BatchOps: ops})
+	require.NoError(err)
+	require.NoError(view.CommitToDB(ctx))
+	root, err := db.GetMerkleRoot(ctx)
+	require.NoError(err)
+
+	// The keys are read in any order, including keys that are missing and
+	// keys that are read twice.
+	keys := [][]byte{heightKey}
+	for i := n - 1; i >= 0; i-- {
+		keys = append(keys, BalanceKey(addrs[i]))
+	}
+	keys = append(keys, BalanceKey(addrs[0]))
+	s := &countingState{MerkleDB: db}
+	values, errs := ReadStateAtRoot(s, root)(ctx, keys)
+
+	require.NoError(errs[0])
+	require.Equal(database.PackUInt64(1), values[0])
+	for j, key := range keys[1:] {
+		addr, ok := ParseBalanceKey(key)
+		require.True(ok)
+		i := int(addr[1])<<8 | int(addr[2])
+		if i%2 != 0 {
+			require.ErrorIs(errs[j+1], database.ErrNotFound)
+			continue
+		}
+		require.NoError(errs[j+1])
+		require.Equal(database.PackUInt64(uint64(i+1)), values[j+1])
+	}
+	require.LessOrEqual(s.proofs, 4)
+}
@@ -0,0 +1,183 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/database"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+const (
+	SpendingLimitChunks uint16 = 1
+
+	spendingLimitSize = 3*consts.Uint64Len + 4*consts.Int64Len
+)
+
+// SpendingLimit caps the amount an account can debit through [SubBalance]
+// within a window of [Window] milliseconds. The window starts at the first
+// debit made after the previous window has elapsed.
+//
+// Changes that loosen the limit are staged in the Pending fields and only
+// take effect once [PendingAt] is reached.
+type SpendingLimit struct {
+	Limit       uint64 `json:"limit"`
+	Window      int64  `json:"window"`
+	Spent       uint64 `json:"spent"`
+	WindowStart int64  `json:"windowStart"`
+
+	// A [PendingAt] of 0 means there is no pending change. A [PendingWindow]
+	// of 0 means the limit is removed once the change takes effect.
+	PendingLimit  uint64 `json:"pendingLimit"`
+	PendingWindow int64  `json:"pendingWindow"`
+	PendingAt     int64  `json:"pendingAt"`
+}
+
+// Advance applies any pending change that is due at [timestamp] and resets
+// [Spent] if the current window has elapsed. It returns false if the limit
+// has been removed.
+func (l *SpendingLimit) Advance(timestamp int64) bool {
+	if l.PendingAt != 0 && timestamp >= l.PendingAt {
+		if l.PendingWindow == 0 {
+			return false
+		}
+		l.Limit = l.PendingLimit
+		l.Window = l.PendingWindow
+		l.PendingLimit = 0
+		l.PendingWindow = 0
+		l.PendingAt = 0
+	}
+	if timestamp-l.WindowStart >= l.Window {
+		l.Spent = 0
+		l.WindowStart = timestamp
+	}
+	return true
+}
+
+var spendingLimitLayout = register(&RecordLayout{
+	Name:        "spending limit",
+	Prefix:      0x4,
+	KeyLen:      codec.AddressLen,
+	Chunks:      SpendingLimitChunks,
+	KeyFields:   "owner",
+	ValueFields: "limit|window|spent|windowStart|pendingLimit|pendingWindow|pendingAt",
+})
+
+// [spendingLimitPrefix] + [address]
+func SpendingLimitKey(addr codec.Address) []byte {
+	return spendingLimitLayout.Key(addr[:])
+}
+
+// GetSpendingLimit returns the stored spending limit of [addr] without
+// applying pending changes. If there is no limit, it returns false.
+func GetSpendingLimit(
+	ctx context.Context,
+	im state.Immutable,
+	addr codec.Address,
+) (*SpendingLimit, bool, error) {
+	return innerGetSpendingLimit(im.GetValue(ctx, SpendingLimitKey(addr)))
+}
+
+// Used to serve RPC queries
+func GetSpendingLimitFromState(
+	ctx context.Context,
+	f ReadState,
+	addr codec.Address,
+) (*SpendingLimit, bool, error) {
+	values, errs := f(ctx, [][]byte{SpendingLimitKey(addr)})
+	return innerGetSpendingLimit(values[0], errs[0])
+}
+
+func innerGetSpendingLimit(v []byte, err error) (*SpendingLimit, bool, error) {
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	p := codec.NewReader(v, spendingLimitSize)
+	l := &SpendingLimit{
+		Limit:         p.UnpackUint64(false),
+		Window:        p.UnpackInt64(false),
+		Spent:         p.UnpackUint64(false),
+		WindowStart:   p.UnpackInt64(false),
+		PendingLimit:  p.UnpackUint64(false),
+		PendingWindow: p.UnpackInt64(false),
+		PendingAt:     p.UnpackInt64(false),
+	}
+	if err := p.Err(); err != nil {
+		return nil, false, err
+	}
+	return l, true, nil
+}
+
+func SetSpendingLimit(
+	ctx context.Context,
+	mu state.Mutable,
+	addr codec.Address,
+	l *SpendingLimit,
+) error {
+	p := codec.NewWriter(spendingLimitSize, spendingLimitSize)
+	p.PackUint64(l.Limit)
+	p.PackInt64(l.Window)
+	p.PackUint64(l.Spent)
+	p.PackInt64(l.WindowStart)
+	p.PackUint64(l.PendingLimit)
+	p.PackInt64(l.PendingWindow)
+	p.PackInt64(l.PendingAt)
+	if err := p.Err(); err != nil {
+		return err
+	}
+	return mu.Insert(ctx, SpendingLimitKey(addr), p.Bytes())
+}
+
+func RemoveSpendingLimit(
+	ctx context.Context,
+	mu state.Mutable,
+	addr codec.Address,
+) error {
+	return mu.Remove(ctx, SpendingLimitKey(addr))
+}
+
+// chargeSpendingLimit records a debit of [amount] against the spending limit
+// of [addr], if any, and fails if the debit would exceed it.
+func chargeSpendingLimit(
+	ctx context.Context,
+	mu state.Mutable,
+	timestamp int64,
+	addr codec.Address,
+	amount uint64,
+) error {
+	l, exists, err := GetSpendingLimit(ctx, mu, addr)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	if !l.Advance(timestamp) {
+		return RemoveSpendingLimit(ctx, mu, addr)
+	}
+	spent, err := smath.Add(l.Spent, amount)
+	if err != nil || spent > l.Limit {
+		return fmt.Errorf(
+			"%w: (spent=%d, limit=%d, addr=%v, amount=%d)",
+			ErrSpendingLimitExceeded,
+			l.Spent,
+			l.Limit,
+			addr,
+			amount,
+		)
+	}
+	l.Spent = spent
+	return SetSpendingLimit(ctx, mu, addr, l)
+}
@@ -6,6 +6,7 @@ package storage
 import (
 	"context"
 
+	"github.com/ava-labs/hypersdk-starter-kit/tracing"
 	"github.com/ava-labs/hypersdk/chain"
 	"github.com/ava-labs/hypersdk/codec"
 	"github.com/ava-labs/hypersdk/state"
@@ -27,6 +28,12 @@ func (*BalanceHandler) CanDeduct(
 	im state.Immutable,
 	amount uint64,
 ) error {
+	ctx, span := tracing.Start(ctx, "BalanceHandler.CanDeduct")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Address("address", addr), tracing.Amount("amount", amount))
+	}
+
 	bal, err := GetBalance(ctx, im, addr)
 	if err != nil {
 		return err
@@ -43,7 +50,15 @@ func (*BalanceHandler) Deduct(
 	mu state.Mutable,
 	amount uint64,
 ) error {
-	_, err := SubBalance(ctx, mu, addr, amount)
+	ctx, span := tracing.Start(ctx, "BalanceHandler.Deduct")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Address("address", addr), tracing.Amount("amount", amount))
+	}
+
+	// Fees are not counted against spending limits so that an account that
+	// has exhausted its limit can still pay to change it.
+	_, err := subBalance(ctx, mu, addr, amount)
 	return err
 }
 
@@ -53,10 +68,22 @@ func (*BalanceHandler) AddBalance(
 	mu state.Mutable,
 	amount uint64,
 ) error {
+	ctx, span := tracing.Start(ctx, "BalanceHandler.AddBalance")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Address("address", addr), tracing.Amount("amount", amount))
+	}
+
 	_, err := AddBalance(ctx, mu, addr, amount)
 	return err
 }
 
 func (*BalanceHandler) GetBalance(ctx context.Context, addr codec.Address, im state.Immutable) (uint64, error) {
+	ctx, span := tracing.Start(ctx, "BalanceHandler.GetBalance")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Address("address", addr))
+	}
+
 	return GetBalance(ctx, im, addr)
 }
@@ -7,8 +7,13 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/tracing"
 	"github.com/ava-labs/hypersdk/chain"
 	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
 )
 
 func NewBalanceHandler() chain.BalanceHandler {
@@ -18,3 +23,40 @@ func NewBalanceHandler() chain.BalanceHandler {
 func TestBalanceHandler(t *testing.T) {
 	chaintest.TestBalanceHandler(t, context.Background(), NewBalanceHandler)
 }
+
+func TestBalanceHandlerTracing(t *testing.T) {
+	require := require.New(t)
+
+	exporter := tracing.NewTestExporter()
+	ctx := exporter.Context(context.Background())
+	addr := codectest.NewRandomAddress()
+	store := chaintest.NewInMemoryStore()
+	bh := NewBalanceHandler()
+
+	require.NoError(bh.AddBalance(ctx, addr, store, 3))
+	require.NoError(bh.Deduct(ctx, addr, store, 1))
+	require.Equal([]string{
+		"storage.AddBalance",
+		"BalanceHandler.AddBalance",
+		"BalanceHandler.Deduct",
+	}, exporter.Names())
+	attrs, ok := exporter.Attributes("BalanceHandler.Deduct")
+	require.True(ok)
+	require.Equal(addr.String(), attrs["address"].AsString())
+	require.Equal(int64(1), attrs["amount"].AsInt64())
+
+	exporter.Reset()
+	read := func(ctx context.Context, keys [][]byte) ([][]byte, []error) {
+		values := make([][]byte, len(keys))
+		errs := make([]error, len(keys))
+		for i, key := range keys {
+			values[i], errs[i] = store.GetValue(ctx, key)
+		}
+		return values, errs
+	}
+	_, errs := GetBalancesFromState(ctx, read, []codec.Address{addr, codectest.NewRandomAddress()})
+	require.Len(errs, 2)
+	attrs, ok = exporter.Attributes("storage.GetBalancesFromState")
+	require.True(ok)
+	require.Equal(int64(2), attrs["keys"].AsInt64())
+}
@@ -5,12 +5,12 @@ package storage
 
 import (
 	"context"
-	"encoding/binary"
-	"errors"
 	"fmt"
 
 	"github.com/ava-labs/avalanchego/database"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/ava-labs/hypersdk-starter-kit/tracing"
 	"github.com/ava-labs/hypersdk/codec"
 	"github.com/ava-labs/hypersdk/consts"
 	"github.com/ava-labs/hypersdk/state"
@@ -21,25 +21,44 @@ import (
 
 type ReadState func(context.Context, [][]byte) ([][]byte, []error)
 
-// State
-// 0x0/ (hypersdk-height)
-// 0x1/ (hypersdk-timestamp)
-// 0x2/ (hypersdk-fee)
-//
-// 0x3/ (balance)
-//   -> [owner] => balance
-
-const balancePrefix byte = metadata.DefaultMinimumPrefix
+// The state layout is described by the record types registered with
+// [Registry]. See layout.md for the generated documentation.
 
 const BalanceChunks uint16 = 1
 
+var balanceRecord = NewRecord(
+	register(&RecordLayout{
+		Name:        "balance",
+		Prefix:      metadata.DefaultMinimumPrefix,
+		KeyLen:      codec.AddressLen,
+		Chunks:      BalanceChunks,
+		KeyFields:   "owner",
+		ValueFields: "balance",
+	}),
+	func(addr codec.Address) []byte { return addr[:] },
+	Uint64Codec{},
+)
+
 // [balancePrefix] + [address]
-func BalanceKey(addr codec.Address) (k []byte) {
-	k = make([]byte, 1+codec.AddressLen+consts.Uint16Len)
-	k[0] = balancePrefix
-	copy(k[1:], addr[:])
-	binary.BigEndian.PutUint16(k[1+codec.AddressLen:], BalanceChunks)
-	return
+func BalanceKey(addr codec.Address) []byte {
+	return balanceRecord.Key(addr)
+}
+
+// BalancePrefix returns the prefix of every balance key.
+func BalancePrefix() byte {
+	return balanceRecord.Layout().Prefix
+}
+
+// ParseBalanceKey returns the owner of the balance stored at [k]. It returns
+// false if [k] is not a balance key.
+func ParseBalanceKey(k []byte) (codec.Address, bool) {
+	layout := balanceRecord.Layout()
+	var addr codec.Address
+	if len(k) != 1+layout.KeyLen+consts.Uint16Len || k[0] != layout.Prefix {
+		return addr, false
+	}
+	copy(addr[:], k[1:])
+	return addr, true
 }
 
 // If locked is 0, then account does not exist
@@ -48,18 +67,14 @@ func GetBalance(
 	im state.Immutable,
 	addr codec.Address,
 ) (uint64, error) {
-	_, bal, _, err := getBalance(ctx, im, addr)
-	return bal, err
-}
+	ctx, span := tracing.Start(ctx, "storage.GetBalance")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Address("address", addr))
+	}
 
-func getBalance(
-	ctx context.Context,
-	im state.Immutable,
-	addr codec.Address,
-) ([]byte, uint64, bool, error) {
-	k := BalanceKey(addr)
-	bal, exists, err := innerGetBalance(im.GetValue(ctx, k))
-	return k, bal, exists, err
+	bal, _, err := balanceRecord.Get(ctx, im, addr)
+	return bal, err
 }
 
 // Used to serve RPC queries
@@ -68,27 +83,60 @@ func GetBalanceFromState(
 	f ReadState,
 	addr codec.Address,
 ) (uint64, error) {
-	k := BalanceKey(addr)
-	values, errs := f(ctx, [][]byte{k})
-	bal, _, err := innerGetBalance(values[0], errs[0])
+	ctx, span := tracing.Start(ctx, "storage.GetBalanceFromState")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Address("address", addr))
+	}
+
+	bal, _, err := balanceRecord.GetFromState(ctx, f, addr)
 	return bal, err
 }
 
-func innerGetBalance(
-	v []byte,
-	err error,
-) (uint64, bool, error) {
-	if errors.Is(err, database.ErrNotFound) {
-		return 0, false, nil
+// GetBalancesFromState reads the balances of [addrs] in a single call to
+// [f]. The error of every address is returned separately.
+func GetBalancesFromState(
+	ctx context.Context,
+	f ReadState,
+	addrs []codec.Address,
+) ([]uint64, []error) {
+	ctx, span := tracing.Start(ctx, "storage.GetBalancesFromState")
+	defer span.End()
+	span.SetAttributes(attribute.Int("keys", len(addrs)))
+
+	keys := make([][]byte, len(addrs))
+	for i, addr := range addrs {
+		keys[i] = BalanceKey(addr)
 	}
-	if err != nil {
-		return 0, false, err
+	values, errs := f(ctx, keys)
+	balances := make([]uint64, len(addrs))
+	for i := range addrs {
+		balances[i], _, errs[i] = balanceRecord.decode(values[i], errs[i])
 	}
-	val, err := database.ParseUInt64(v)
-	if err != nil {
-		return 0, false, err
+	return balances, errs
+}
+
+// IterateBalances calls [f] with every non-zero balance in [db], which must
+// hold the VM's state, in address order. It stops at the first error
+// returned by [f].
+func IterateBalances(db database.Iteratee, f func(codec.Address, uint64) error) error {
+	it := db.NewIteratorWithPrefix([]byte{BalancePrefix()})
+	defer it.Release()
+
+	for it.Next() {
+		addr, ok := ParseBalanceKey(it.Key())
+		if !ok {
+			return fmt.Errorf("%w: unexpected balance key %x", ErrInvalidAddress, it.Key())
+		}
+		bal, err := Uint64Codec{}.Decode(it.Value())
+		if err != nil {
+			return err
+		}
+		if err := f(addr, bal); err != nil {
+			return err
+		}
 	}
-	return val, true, nil
+	return it.Error()
 }
 
 func SetBalance(
@@ -97,17 +145,13 @@ func SetBalance(
 	addr codec.Address,
 	balance uint64,
 ) error {
-	k := BalanceKey(addr)
-	return setBalance(ctx, mu, k, balance)
-}
+	ctx, span := tracing.Start(ctx, "storage.SetBalance")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Address("address", addr), tracing.Amount("balance", balance))
+	}
 
-func setBalance(
-	ctx context.Context,
-	mu state.Mutable,
-	key []byte,
-	balance uint64,
-) error {
-	return mu.Insert(ctx, key, binary.BigEndian.AppendUint64(nil, balance))
+	return balanceRecord.Put(ctx, mu, addr, balance)
 }
 
 func AddBalance(
@@ -116,7 +160,13 @@ func AddBalance(
 	addr codec.Address,
 	amount uint64,
 ) (uint64, error) {
-	key, bal, _, err := getBalance(ctx, mu, addr)
+	ctx, span := tracing.Start(ctx, "storage.AddBalance")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Address("address", addr), tracing.Amount("amount", amount))
+	}
+
+	bal, _, err := balanceRecord.Get(ctx, mu, addr)
 	if err != nil {
 		return 0, err
 	}
@@ -130,22 +180,43 @@ func AddBalance(
 			amount,
 		)
 	}
-	return nbal, setBalance(ctx, mu, key, nbal)
+	return nbal, balanceRecord.Put(ctx, mu, addr, nbal)
 }
 
+// SubBalance debits [amount] from [addr] on behalf of an action executed at
+// [timestamp]. The debit counts against any spending limit [addr] has set.
 func SubBalance(
 	ctx context.Context,
 	mu state.Mutable,
+	timestamp int64,
 	addr codec.Address,
 	amount uint64,
 ) (uint64, error) {
-	key, bal, ok, err := getBalance(ctx, mu, addr)
-	if !ok {
-		return 0, ErrInvalidBalance
+	ctx, span := tracing.Start(ctx, "storage.SubBalance")
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Address("address", addr), tracing.Amount("amount", amount))
 	}
+
+	if err := chargeSpendingLimit(ctx, mu, timestamp, addr, amount); err != nil {
+		return 0, err
+	}
+	return subBalance(ctx, mu, addr, amount)
+}
+
+func subBalance(
+	ctx context.Context,
+	mu state.Mutable,
+	addr codec.Address,
+	amount uint64,
+) (uint64, error) {
+	bal, ok, err := balanceRecord.Get(ctx, mu, addr)
 	if err != nil {
 		return 0, err
 	}
+	if !ok {
+		return 0, ErrInvalidBalance
+	}
 	nbal, err := smath.Sub(bal, amount)
 	if err != nil {
 		return 0, fmt.Errorf(
@@ -159,7 +230,7 @@ func SubBalance(
 	if nbal == 0 {
 		// If there is no balance left, we should delete the record instead of
 		// setting it to 0.
-		return 0, mu.Remove(ctx, key)
+		return 0, balanceRecord.Delete(ctx, mu, addr)
 	}
-	return nbal, setBalance(ctx, mu, key, nbal)
+	return nbal, balanceRecord.Put(ctx, mu, addr, nbal)
 }
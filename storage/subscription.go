@@ -0,0 +1,311 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+const (
+	// MaxSubscriptionsPerAccount bounds the number of subscriptions an account
+	// can pay for, so that its index fits in [SubscriptionIndexChunks]. Only
+	// the payer adds to its index. The subscriptions of a payee are not
+	// indexed in state, so they are not bounded.
+	MaxSubscriptionsPerAccount = 16
+
+	SubscriptionChunks      uint16 = 2
+	SubscriptionIndexChunks uint16 = 17
+
+	subscriptionSize    = 2*codec.AddressLen + 3*consts.Uint64Len + 2*consts.Int64Len
+	subscriptionRefSize = codec.AddressLen + ids.IDLen
+)
+
+// Subscription allows [Payee] to pull [Amount] from [Payer] once every
+// [Period] milliseconds, up to [MaxCycles] times (0 means no limit).
+type Subscription struct {
+	Payer        codec.Address `json:"payer"`
+	Payee        codec.Address `json:"payee"`
+	Amount       uint64        `json:"amount"`
+	Period       int64         `json:"period"`
+	MaxCycles    uint64        `json:"maxCycles"`
+	Cycles       uint64        `json:"cycles"`
+	NextChargeAt int64         `json:"nextChargeAt"`
+}
+
+// SubscriptionRef locates a subscription in state.
+type SubscriptionRef struct {
+	Payee codec.Address `json:"payee"`
+	ID    ids.ID        `json:"id"`
+}
+
+var (
+	// Subscriptions are keyed by payee so that the subscriptions of a payee
+	// can be listed by iterating over its prefix, without an index that
+	// anyone could fill by subscribing to it.
+	subscriptionLayout = register(&RecordLayout{
+		Name:        "subscription",
+		Prefix:      0x5,
+		KeyLen:      codec.AddressLen + ids.IDLen,
+		Chunks:      SubscriptionChunks,
+		KeyFields:   "payee|subscriptionID",
+		ValueFields: "payer|payee|amount|period|maxCycles|cycles|nextChargeAt",
+	})
+	payerSubscriptionsLayout = register(&RecordLayout{
+		Name:        "payer subscriptions",
+		Prefix:      0x6,
+		KeyLen:      codec.AddressLen,
+		Chunks:      SubscriptionIndexChunks,
+		KeyFields:   "payer",
+		ValueFields: "(payee|subscriptionID)...",
+	})
+)
+
+// [subscriptionPrefix] + [payee] + [subscriptionID]
+func SubscriptionKey(payee codec.Address, id ids.ID) []byte {
+	return subscriptionLayout.Key(payee[:], id[:])
+}
+
+// [payerSubscriptionsPrefix] + [payer]
+func PayerSubscriptionsKey(addr codec.Address) []byte {
+	return payerSubscriptionsLayout.Key(addr[:])
+}
+
+// payeeSubscriptionsPrefix is the prefix of the keys of the subscriptions
+// charged by [payee].
+func payeeSubscriptionsPrefix(payee codec.Address) []byte {
+	return append([]byte{subscriptionLayout.Prefix}, payee[:]...)
+}
+
+func GetSubscription(
+	ctx context.Context,
+	im state.Immutable,
+	payee codec.Address,
+	id ids.ID,
+) (*Subscription, bool, error) {
+	return innerGetSubscription(im.GetValue(ctx, SubscriptionKey(payee, id)))
+}
+
+func innerGetSubscription(v []byte, err error) (*Subscription, bool, error) {
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	p := codec.NewReader(v, subscriptionSize)
+	s := &Subscription{}
+	p.UnpackAddress(&s.Payer)
+	p.UnpackAddress(&s.Payee)
+	s.Amount = p.UnpackUint64(true)
+	s.Period = p.UnpackInt64(true)
+	s.MaxCycles = p.UnpackUint64(false)
+	s.Cycles = p.UnpackUint64(false)
+	s.NextChargeAt = p.UnpackInt64(false)
+	if err := p.Err(); err != nil {
+		return nil, false, err
+	}
+	return s, true, nil
+}
+
+func SetSubscription(
+	ctx context.Context,
+	mu state.Mutable,
+	id ids.ID,
+	s *Subscription,
+) error {
+	p := codec.NewWriter(subscriptionSize, subscriptionSize)
+	p.PackAddress(s.Payer)
+	p.PackAddress(s.Payee)
+	p.PackUint64(s.Amount)
+	p.PackInt64(s.Period)
+	p.PackUint64(s.MaxCycles)
+	p.PackUint64(s.Cycles)
+	p.PackInt64(s.NextChargeAt)
+	if err := p.Err(); err != nil {
+		return err
+	}
+	return mu.Insert(ctx, SubscriptionKey(s.Payee, id), p.Bytes())
+}
+
+// AddSubscription stores a new subscription and adds it to the index of its
+// payer.
+func AddSubscription(
+	ctx context.Context,
+	mu state.Mutable,
+	id ids.ID,
+	s *Subscription,
+) error {
+	if err := SetSubscription(ctx, mu, id, s); err != nil {
+		return err
+	}
+	return addToSubscriptionIndex(ctx, mu, PayerSubscriptionsKey(s.Payer), SubscriptionRef{Payee: s.Payee, ID: id})
+}
+
+// RemoveSubscription deletes a subscription and removes it from the index of
+// its payer.
+func RemoveSubscription(
+	ctx context.Context,
+	mu state.Mutable,
+	id ids.ID,
+	s *Subscription,
+) error {
+	if err := mu.Remove(ctx, SubscriptionKey(s.Payee, id)); err != nil {
+		return err
+	}
+	return removeFromSubscriptionIndex(ctx, mu, PayerSubscriptionsKey(s.Payer), id)
+}
+
+func GetPayerSubscriptions(
+	ctx context.Context,
+	im state.Immutable,
+	addr codec.Address,
+) ([]SubscriptionRef, error) {
+	return innerGetSubscriptionIndex(im.GetValue(ctx, PayerSubscriptionsKey(addr)))
+}
+
+// Used to serve RPC queries
+func GetPayerSubscriptionsFromState(
+	ctx context.Context,
+	f ReadState,
+	addr codec.Address,
+) ([]ids.ID, []*Subscription, error) {
+	values, errs := f(ctx, [][]byte{PayerSubscriptionsKey(addr)})
+	refs, err := innerGetSubscriptionIndex(values[0], errs[0])
+	if err != nil || len(refs) == 0 {
+		return nil, nil, err
+	}
+
+	keys := make([][]byte, len(refs))
+	for i, ref := range refs {
+		keys[i] = SubscriptionKey(ref.Payee, ref.ID)
+	}
+	values, errs = f(ctx, keys)
+	subscriptionIDs := make([]ids.ID, len(refs))
+	subscriptions := make([]*Subscription, len(refs))
+	for i, ref := range refs {
+		s, exists, err := innerGetSubscription(values[i], errs[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return nil, nil, fmt.Errorf("%w: indexed subscription %s", database.ErrNotFound, ref.ID)
+		}
+		subscriptionIDs[i] = ref.ID
+		subscriptions[i] = s
+	}
+	return subscriptionIDs, subscriptions, nil
+}
+
+// GetPayeeSubscriptions returns up to [limit] of the subscriptions charged by
+// [payee] in [db], which must hold the VM's state, in ID order starting at
+// [cursor]. It also returns the ID to resume from, which is empty once
+// there are no more subscriptions.
+func GetPayeeSubscriptions(
+	db database.Iteratee,
+	payee codec.Address,
+	cursor ids.ID,
+	limit int,
+) ([]ids.ID, []*Subscription, ids.ID, error) {
+	prefix := payeeSubscriptionsPrefix(payee)
+	it := db.NewIteratorWithStartAndPrefix(append(slices.Clone(prefix), cursor[:]...), prefix)
+	defer it.Release()
+
+	subscriptionIDs := []ids.ID{}
+	subscriptions := []*Subscription{}
+	for it.Next() {
+		k := it.Key()
+		if len(k) != len(prefix)+ids.IDLen+consts.Uint16Len {
+			return nil, nil, ids.Empty, fmt.Errorf("%w: unexpected subscription key %x", ErrInvalidSubscriptionIndex, k)
+		}
+		id := ids.ID(k[len(prefix) : len(prefix)+ids.IDLen])
+		if len(subscriptionIDs) == limit {
+			return subscriptionIDs, subscriptions, id, nil
+		}
+		s, _, err := innerGetSubscription(it.Value(), nil)
+		if err != nil {
+			return nil, nil, ids.Empty, err
+		}
+		subscriptionIDs = append(subscriptionIDs, id)
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptionIDs, subscriptions, ids.Empty, it.Error()
+}
+
+func innerGetSubscriptionIndex(v []byte, err error) ([]SubscriptionRef, error) {
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(v)%subscriptionRefSize != 0 {
+		return nil, ErrInvalidSubscriptionIndex
+	}
+	refs := make([]SubscriptionRef, len(v)/subscriptionRefSize)
+	for i := range refs {
+		entry := v[i*subscriptionRefSize:]
+		copy(refs[i].Payee[:], entry)
+		copy(refs[i].ID[:], entry[codec.AddressLen:])
+	}
+	return refs, nil
+}
+
+func addToSubscriptionIndex(
+	ctx context.Context,
+	mu state.Mutable,
+	key []byte,
+	ref SubscriptionRef,
+) error {
+	refs, err := innerGetSubscriptionIndex(mu.GetValue(ctx, key))
+	if err != nil {
+		return err
+	}
+	if len(refs) >= MaxSubscriptionsPerAccount {
+		return ErrTooManySubscriptions
+	}
+	return setSubscriptionIndex(ctx, mu, key, append(refs, ref))
+}
+
+func removeFromSubscriptionIndex(
+	ctx context.Context,
+	mu state.Mutable,
+	key []byte,
+	id ids.ID,
+) error {
+	refs, err := innerGetSubscriptionIndex(mu.GetValue(ctx, key))
+	if err != nil {
+		return err
+	}
+	refs = slices.DeleteFunc(refs, func(other SubscriptionRef) bool {
+		return other.ID == id
+	})
+	if len(refs) == 0 {
+		return mu.Remove(ctx, key)
+	}
+	return setSubscriptionIndex(ctx, mu, key, refs)
+}
+
+func setSubscriptionIndex(
+	ctx context.Context,
+	mu state.Mutable,
+	key []byte,
+	refs []SubscriptionRef,
+) error {
+	v := make([]byte, 0, len(refs)*subscriptionRefSize)
+	for _, ref := range refs {
+		v = append(v, ref.Payee[:]...)
+		v = append(v, ref.ID[:]...)
+	}
+	return mu.Insert(ctx, key, v)
+}
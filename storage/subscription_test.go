@@ -0,0 +1,56 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/codec/codectest"
+)
+
+func TestGetPayeeSubscriptions(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := migrationState{memdb.New()}
+	payee := codectest.NewRandomAddress()
+	subscriptionIDs := []ids.ID{{1}, {2}, {3}}
+	for _, id := range subscriptionIDs {
+		require.NoError(AddSubscription(ctx, s, id, &Subscription{
+			Payer:  codectest.NewRandomAddress(),
+			Payee:  payee,
+			Amount: 1,
+			Period: 1,
+		}))
+	}
+	// The subscriptions of other payees are not listed.
+	require.NoError(AddSubscription(ctx, s, ids.ID{0}, &Subscription{
+		Payer:  codectest.NewRandomAddress(),
+		Payee:  codectest.NewRandomAddress(),
+		Amount: 1,
+		Period: 1,
+	}))
+
+	got, subscriptions, next, err := GetPayeeSubscriptions(s, payee, ids.Empty, 2)
+	require.NoError(err)
+	require.Equal(subscriptionIDs[:2], got)
+	require.Len(subscriptions, 2)
+	require.Equal(payee, subscriptions[0].Payee)
+	require.Equal(subscriptionIDs[2], next)
+
+	got, _, next, err = GetPayeeSubscriptions(s, payee, next, 2)
+	require.NoError(err)
+	require.Equal(subscriptionIDs[2:], got)
+	require.Equal(ids.Empty, next)
+
+	got, _, next, err = GetPayeeSubscriptions(s, codectest.NewRandomAddress(), ids.Empty, 2)
+	require.NoError(err)
+	require.Empty(got)
+	require.Equal(ids.Empty, next)
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tests
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk-starter-kit/tests/workload"
+	"github.com/ava-labs/hypersdk-starter-kit/vm"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/tests/registry"
+
+	tworkload "github.com/ava-labs/hypersdk/tests/workload"
+	ginkgo "github.com/onsi/ginkgo/v2"
+)
+
+var _ = registry.Register(TestsRegistry, "Simulate Actions", func(t ginkgo.FullGinkgoTInterface, tn tworkload.TestNetwork) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	networkConfig := tn.Configuration().(*workload.NetworkConfiguration)
+	factory := auth.NewED25519Factory(networkConfig.Keys()[0])
+	actor := factory.Address()
+	cli := vm.NewJSONRPCClient(tn.URIs()[0])
+
+	balance, err := cli.Balance(ctx, actor)
+	require.NoError(err)
+	transfers := []chain.Action{
+		&actions.Transfer{To: codectest.NewRandomAddress(), Value: 1},
+		&actions.Transfer{To: codectest.NewRandomAddress(), Value: 2, Memo: []byte("memo")},
+	}
+	reply, err := cli.Simulate(ctx, transfers, actor, auth.ED25519Key)
+	require.NoError(err)
+	require.True(reply.Success)
+	require.Empty(reply.Error)
+	require.Len(reply.Results, 2)
+
+	// The second transfer sees the state left by the fee and the first one.
+	output, err := vm.ParseOutput(reply.Results[1].Output)
+	require.NoError(err)
+	require.Equal(&actions.TransferResult{
+		SenderBalance:   balance - reply.Fee - 3,
+		ReceiverBalance: 2,
+	}, output)
+
+	// The units and fee are those of the signed transaction.
+	tx, err := tn.GenerateTx(ctx, transfers, factory)
+	require.NoError(err)
+	parser, err := cli.Parser(ctx)
+	require.NoError(err)
+	units, err := tx.Units(&storage.BalanceHandler{}, parser.Rules(0))
+	require.NoError(err)
+	require.Equal(units, reply.Units)
+	fee, err := fees.MulSum(reply.UnitPrices, units)
+	require.NoError(err)
+	require.Equal(fee, reply.Fee)
+
+	// Actions after a failure do not run.
+	reply, err = cli.Simulate(ctx, []chain.Action{
+		&actions.Transfer{To: actor, Value: 0},
+		&actions.Transfer{To: actor, Value: 1},
+	}, actor, "")
+	require.NoError(err)
+	require.False(reply.Success)
+	require.Len(reply.Results, 1)
+	require.Equal(actions.ErrOutputValueZero.Error(), reply.Results[0].Error)
+	require.Empty(reply.Results[0].Output)
+
+	// The auth type must derive the actor.
+	_, err = cli.Simulate(ctx, transfers, actor, auth.BLSKey)
+	require.ErrorContains(err, vm.ErrAuthTypeMismatch.Error())
+})
@@ -0,0 +1,75 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestExporter records spans in memory so that tests can check them without
+// a collector.
+type TestExporter struct {
+	exporter *tracetest.InMemoryExporter
+	provider *sdktrace.TracerProvider
+}
+
+func NewTestExporter() *TestExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	return &TestExporter{
+		exporter: exporter,
+		provider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+	}
+}
+
+// Tracer returns a tracer whose spans are recorded by [e].
+func (e *TestExporter) Tracer() trace.Tracer {
+	return e.provider.Tracer(Name)
+}
+
+// Context returns a copy of [ctx] in which [Start] records spans in [e].
+func (e *TestExporter) Context(ctx context.Context) context.Context {
+	return WithTracer(ctx, e.Tracer())
+}
+
+// Spans returns the ended spans, in the order they ended.
+func (e *TestExporter) Spans() tracetest.SpanStubs {
+	return e.exporter.GetSpans()
+}
+
+// Names returns the names of the ended spans, in the order they ended.
+func (e *TestExporter) Names() []string {
+	spans := e.Spans()
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	return names
+}
+
+// Attributes returns the attributes of the first ended span named [name],
+// or false if no such span ended.
+func (e *TestExporter) Attributes(name string) (map[attribute.Key]attribute.Value, bool) {
+	for _, span := range e.Spans() {
+		if span.Name != name {
+			continue
+		}
+		attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+		for _, attr := range span.Attributes {
+			attrs[attr.Key] = attr.Value
+		}
+		return attrs, true
+	}
+	return nil, false
+}
+
+// Reset drops the recorded spans.
+func (e *TestExporter) Reset() {
+	e.exporter.Reset()
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracing
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ava-labs/hypersdk/codec"
+)
+
+// Name is the instrumentation name of the tracers returned by [Tracer].
+const Name = "github.com/ava-labs/hypersdk-starter-kit"
+
+type tracerKey struct{}
+
+// WithTracer returns a copy of [ctx] in which [Start] uses [tracer].
+func WithTracer(ctx context.Context, tracer trace.Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, tracer)
+}
+
+// Tracer returns the tracer set on [ctx] with [WithTracer]. Otherwise, it
+// returns a tracer of the provider of the span in [ctx], which is the VM
+// tracer inside action execution and JSON-RPC handlers. Without either, the
+// tracer records nothing.
+func Tracer(ctx context.Context) trace.Tracer {
+	if tracer, ok := ctx.Value(tracerKey{}).(trace.Tracer); ok {
+		return tracer
+	}
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(Name)
+}
+
+// Start starts a span named [name] with the tracer of [ctx]. Attributes
+// should only be computed if the span is recording.
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return Tracer(ctx).Start(ctx, name)
+}
+
+// Address returns the attribute [key] of [addr].
+func Address(key string, addr codec.Address) attribute.KeyValue {
+	return attribute.String(key, addr.String())
+}
+
+// Amount returns the attribute [key] of [amount]. Attributes cannot hold
+// a uint64, so amounts above the largest int64 are recorded as strings.
+func Amount(key string, amount uint64) attribute.KeyValue {
+	if amount > math.MaxInt64 {
+		return attribute.String(key, strconv.FormatUint(amount, 10))
+	}
+	return attribute.Int64(key, int64(amount))
+}
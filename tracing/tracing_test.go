@@ -0,0 +1,49 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracing
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestTracer(t *testing.T) {
+	require := require.New(t)
+
+	// Without a tracer, spans are not recorded.
+	_, span := Start(context.Background(), "none")
+	require.False(span.IsRecording())
+	span.End()
+
+	// A tracer set on the context is used.
+	exporter := NewTestExporter()
+	ctx, parent := Start(exporter.Context(context.Background()), "parent")
+	require.True(parent.IsRecording())
+
+	// Without one, the tracer of the span in the context is used, as when
+	// the VM calls an action.
+	ctx, child := Start(context.WithoutCancel(ctx), "child")
+	child.SetAttributes(Amount("small", 1), Amount("large", math.MaxUint64))
+	child.End()
+	parent.End()
+
+	require.Equal([]string{"child", "parent"}, exporter.Names())
+	spans := exporter.Spans()
+	require.Equal(spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+
+	attrs, ok := exporter.Attributes("child")
+	require.True(ok)
+	require.Equal(attribute.Int64Value(1), attrs["small"])
+	require.Equal(attribute.StringValue("18446744073709551615"), attrs["large"])
+
+	_, ok = exporter.Attributes("missing")
+	require.False(ok)
+	exporter.Reset()
+	require.Empty(exporter.Spans())
+	require.NotNil(ctx)
+}
@@ -0,0 +1,26 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"github.com/ava-labs/hypersdk-starter-kit/apiguard"
+	"github.com/ava-labs/hypersdk-starter-kit/balancefeed"
+	"github.com/ava-labs/hypersdk/api"
+)
+
+const BalanceFeedEndpoint = "/morpheusbalancews"
+
+var _ api.HandlerFactory[api.VM] = (*balanceFeedFactory)(nil)
+
+type balanceFeedFactory struct {
+	feed  *balancefeed.Feed
+	guard *apiguard.Guard
+}
+
+func (f balanceFeedFactory) New(api.VM) (api.Handler, error) {
+	return api.Handler{
+		Path:    BalanceFeedEndpoint,
+		Handler: f.guard.Method(BalanceFeedMethod, f.feed.Handler()),
+	}, nil
+}
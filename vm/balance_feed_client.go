@@ -0,0 +1,166 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/gorilla/websocket"
+
+	"github.com/ava-labs/hypersdk-starter-kit/balancefeed"
+	"github.com/ava-labs/hypersdk/api/ws"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/pubsub"
+)
+
+var ErrFeedClosed = errors.New("balance feed closed")
+
+type feedMessage struct {
+	update *balancefeed.Update
+	err    error
+}
+
+// BalanceFeedClient receives the balance changes of the addresses it
+// subscribes to from the balance feed of a node.
+type BalanceFeedClient struct {
+	conn *websocket.Conn
+	mb   *pubsub.MessageBuffer
+
+	pending      chan *feedMessage
+	readStopped  chan struct{}
+	writeStopped chan struct{}
+
+	closeOnce sync.Once
+	errOnce   sync.Once
+	err       error
+}
+
+// NewBalanceFeedClient connects to the balance feed of the chain at [uri].
+func NewBalanceFeedClient(uri string) (*BalanceFeedClient, error) {
+	uri = strings.Replace(uri, "http://", "ws://", 1)
+	uri = strings.Replace(uri, "https://", "wss://", 1)
+	if !strings.HasPrefix(uri, "ws") {
+		uri = "ws://" + uri
+	}
+	uri = strings.TrimSuffix(uri, "/") + BalanceFeedEndpoint
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: ws.DefaultHandshakeTimeout,
+	}
+	conn, resp, err := dialer.Dial(uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	c := &BalanceFeedClient{
+		conn:         conn,
+		mb:           pubsub.NewMessageBuffer(logging.NoLog{}, pubsub.MaxPendingMessages, pubsub.MaxWriteMessageSize, pubsub.MaxMessageWait),
+		pending:      make(chan *feedMessage, pubsub.MaxPendingMessages),
+		readStopped:  make(chan struct{}),
+		writeStopped: make(chan struct{}),
+	}
+	go c.read()
+	go c.write()
+	return c, nil
+}
+
+func (c *BalanceFeedClient) setErr(err error) {
+	c.errOnce.Do(func() {
+		c.err = err
+	})
+}
+
+func (c *BalanceFeedClient) read() {
+	defer close(c.readStopped)
+	for {
+		_, batch, err := c.conn.ReadMessage()
+		if err != nil {
+			c.setErr(err)
+			return
+		}
+		msgs, err := pubsub.ParseBatchMessage(pubsub.MaxReadMessageSize, batch)
+		if err != nil {
+			c.setErr(err)
+			return
+		}
+		for _, msg := range msgs {
+			update, feedErr, err := balancefeed.UnpackMessage(msg)
+			if err != nil {
+				c.setErr(err)
+				return
+			}
+			c.pending <- &feedMessage{update: update, err: feedErr}
+		}
+	}
+}
+
+func (c *BalanceFeedClient) write() {
+	defer close(c.writeStopped)
+	for {
+		select {
+		case msg, ok := <-c.mb.Queue:
+			if !ok {
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.BinaryMessage, msg); err != nil {
+				c.setErr(err)
+				_ = c.conn.Close()
+				return
+			}
+		case <-c.readStopped:
+			_ = c.mb.Close()
+			return
+		}
+	}
+}
+
+// Subscribe starts sending the balance changes of [addrs]. A connection can
+// subscribe to up to [balancefeed.MaxAddresses] addresses; subscriptions the
+// feed rejects are reported by [Listen].
+func (c *BalanceFeedClient) Subscribe(addrs ...codec.Address) error {
+	return c.mb.Send(balancefeed.PackSubscribe(addrs))
+}
+
+// Unsubscribe stops sending the balance changes of [addrs].
+func (c *BalanceFeedClient) Unsubscribe(addrs ...codec.Address) error {
+	return c.mb.Send(balancefeed.PackUnsubscribe(addrs))
+}
+
+// Listen returns the next balance change, or the error of a rejected
+// subscription.
+func (c *BalanceFeedClient) Listen(ctx context.Context) (*balancefeed.Update, error) {
+	select {
+	case msg := <-c.pending:
+		return msg.update, msg.err
+	case <-c.readStopped:
+		select {
+		case msg := <-c.pending:
+			return msg.update, msg.err
+		default:
+		}
+		if c.err != nil {
+			return nil, c.err
+		}
+		return nil, ErrFeedClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close flushes the pending subscriptions and closes the connection.
+func (c *BalanceFeedClient) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		_ = c.mb.Close()
+		<-c.writeStopped
+		err = c.conn.Close()
+	})
+	return err
+}
@@ -9,10 +9,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
 	"github.com/ava-labs/hypersdk-starter-kit/consts"
+	"github.com/ava-labs/hypersdk/api/indexer"
 	"github.com/ava-labs/hypersdk/api/jsonrpc"
+	"github.com/ava-labs/hypersdk/auth"
 	"github.com/ava-labs/hypersdk/chain"
 	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
 	"github.com/ava-labs/hypersdk/genesis"
 	"github.com/ava-labs/hypersdk/requester"
 	"github.com/ava-labs/hypersdk/utils"
@@ -22,18 +28,27 @@ const balanceCheckInterval = 500 * time.Millisecond
 
 type JSONRPCClient struct {
 	requester *requester.EndpointRequester
-	g         *genesis.DefaultGenesis
+	indexer   *indexer.Client
+	g         *Genesis
 }
 
 // NewJSONRPCClient creates a new client object.
 func NewJSONRPCClient(uri string) *JSONRPCClient {
 	uri = strings.TrimSuffix(uri, "/")
-	uri += JSONRPCEndpoint
-	req := requester.New(uri, consts.Name)
-	return &JSONRPCClient{req, nil}
+	req := requester.New(uri+JSONRPCEndpoint, consts.Name)
+	return &JSONRPCClient{req, indexer.NewClient(uri), nil}
 }
 
 func (cli *JSONRPCClient) Genesis(ctx context.Context) (*genesis.DefaultGenesis, error) {
+	g, err := cli.VMGenesis(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return g.DefaultGenesis, nil
+}
+
+// VMGenesis returns the genesis of the chain along with the bridge config.
+func (cli *JSONRPCClient) VMGenesis(ctx context.Context) (*Genesis, error) {
 	if cli.g != nil {
 		return cli.g, nil
 	}
@@ -65,6 +80,270 @@ func (cli *JSONRPCClient) Balance(ctx context.Context, addr codec.Address) (uint
 	return resp.Amount, err
 }
 
+// BalanceAt returns the balance of [addr] after the block at [height] was
+// accepted. The node must run with the balance archive enabled.
+func (cli *JSONRPCClient) BalanceAt(ctx context.Context, addr codec.Address, height uint64) (uint64, error) {
+	resp := new(BalanceReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"balance",
+		&BalanceArgs{
+			Address: addr,
+			Height:  &height,
+		},
+		resp,
+	)
+	return resp.Amount, err
+}
+
+// Balances returns the balances of [addrs], in order, in a single request.
+// At most [MaxBalancesAddresses] addresses can be looked up at once. The
+// error of every address is set in its result.
+func (cli *JSONRPCClient) Balances(ctx context.Context, addrs []codec.Address) ([]*BalanceResult, error) {
+	resp := new(BalancesReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"balances",
+		&BalancesArgs{
+			Addresses: addrs,
+		},
+		resp,
+	)
+	return resp.Balances, err
+}
+
+// Account returns every record stored for [addr], and whether [addr] exists.
+func (cli *JSONRPCClient) Account(ctx context.Context, addr codec.Address) (*AccountReply, error) {
+	resp := new(AccountReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"account",
+		&AccountArgs{
+			Address: addr,
+		},
+		resp,
+	)
+	return resp, err
+}
+
+func (cli *JSONRPCClient) PayerSubscriptions(ctx context.Context, addr codec.Address) ([]*SubscriptionReply, error) {
+	resp := new(SubscriptionsReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"payerSubscriptions",
+		&SubscriptionsArgs{
+			Address: addr,
+		},
+		resp,
+	)
+	return resp.Subscriptions, err
+}
+
+// PayeeSubscriptions returns every subscription [addr] can charge, fetching
+// them a page at a time.
+func (cli *JSONRPCClient) PayeeSubscriptions(ctx context.Context, addr codec.Address) ([]*SubscriptionReply, error) {
+	subscriptions := []*SubscriptionReply{}
+	cursor := ids.Empty
+	for {
+		resp := new(SubscriptionsReply)
+		err := cli.requester.SendRequest(
+			ctx,
+			"payeeSubscriptions",
+			&SubscriptionsArgs{
+				Address: addr,
+				Cursor:  cursor,
+			},
+			resp,
+		)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, resp.Subscriptions...)
+		if resp.Next == ids.Empty {
+			return subscriptions, nil
+		}
+		cursor = resp.Next
+	}
+}
+
+func (cli *JSONRPCClient) Bridge(ctx context.Context, chainID ids.ID) (*BridgeReply, error) {
+	resp := new(BridgeReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"bridge",
+		&BridgeArgs{
+			ChainID: chainID,
+		},
+		resp,
+	)
+	return resp, err
+}
+
+// BridgeTransfer reports whether [transferID] from [sourceChainID] has
+// already been processed on this chain.
+func (cli *JSONRPCClient) BridgeTransfer(ctx context.Context, sourceChainID ids.ID, transferID ids.ID) (bool, error) {
+	resp := new(BridgeTransferReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"bridgeTransfer",
+		&BridgeTransferArgs{
+			SourceChainID: sourceChainID,
+			TransferID:    transferID,
+		},
+		resp,
+	)
+	return resp.Processed, err
+}
+
+// History returns a page of at most [limit] transactions that touched
+// [addr], newest first, along with the cursor of the next page. [cursor] is
+// empty for the first page, and the returned cursor is empty after the last.
+func (cli *JSONRPCClient) History(
+	ctx context.Context,
+	addr codec.Address,
+	cursor []byte,
+	limit int,
+) (*HistoryReply, error) {
+	resp := new(HistoryReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"history",
+		&HistoryArgs{
+			Address: addr,
+			Cursor:  cursor,
+			Limit:   limit,
+		},
+		resp,
+	)
+	return resp, err
+}
+
+// TopHolders returns up to [limit] of the addresses with the highest
+// balances, skipping the first [offset].
+func (cli *JSONRPCClient) TopHolders(ctx context.Context, limit int, offset int) (*TopHoldersReply, error) {
+	resp := new(TopHoldersReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"topHolders",
+		&TopHoldersArgs{
+			Limit:  limit,
+			Offset: offset,
+		},
+		resp,
+	)
+	return resp, err
+}
+
+func (cli *JSONRPCClient) Distribution(ctx context.Context) (*DistributionReply, error) {
+	resp := new(DistributionReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"distribution",
+		nil,
+		resp,
+	)
+	return resp, err
+}
+
+// Block returns the block at [height] with its transactions decoded.
+func (cli *JSONRPCClient) Block(ctx context.Context, height uint64) (*ExplorerBlock, error) {
+	return cli.block(ctx, &BlockArgs{Height: &height})
+}
+
+// BlockByID returns the block [blkID] with its transactions decoded.
+func (cli *JSONRPCClient) BlockByID(ctx context.Context, blkID ids.ID) (*ExplorerBlock, error) {
+	return cli.block(ctx, &BlockArgs{ID: blkID})
+}
+
+// LastBlock returns the last block stored by the explorer with its
+// transactions decoded.
+func (cli *JSONRPCClient) LastBlock(ctx context.Context) (*ExplorerBlock, error) {
+	return cli.block(ctx, &BlockArgs{})
+}
+
+func (cli *JSONRPCClient) block(ctx context.Context, args *BlockArgs) (*ExplorerBlock, error) {
+	resp := new(BlockReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"block",
+		args,
+		resp,
+	)
+	return resp.Block, err
+}
+
+// Blocks returns a page of at most [limit] blocks, newest first, along with
+// the cursor of the next page. [cursor] is empty for the first page, and the
+// returned cursor is empty after the last.
+func (cli *JSONRPCClient) Blocks(ctx context.Context, cursor []byte, limit int) (*BlocksReply, error) {
+	resp := new(BlocksReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"blocks",
+		&BlocksArgs{
+			Cursor: cursor,
+			Limit:  limit,
+		},
+		resp,
+	)
+	return resp, err
+}
+
+// Transaction returns the accepted transaction [txID] with its auth, actions
+// and outputs decoded. The actions and outputs can be turned back into
+// their types with [ExplorerAction.Decode] and [ExplorerOutput.Decode].
+func (cli *JSONRPCClient) Transaction(ctx context.Context, txID ids.ID) (*ExplorerTx, error) {
+	resp := new(TransactionReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"transaction",
+		&TransactionArgs{TxID: txID},
+		resp,
+	)
+	return resp.Transaction, err
+}
+
+// Simulate executes [actions] on behalf of [actor] against the current state
+// without committing them. [authType] is the auth type that will sign the
+// transaction, or empty for the one that derives [actor]. The outputs of
+// the reply can be decoded with [ParseOutput].
+func (cli *JSONRPCClient) Simulate(
+	ctx context.Context,
+	actions []chain.Action,
+	actor codec.Address,
+	authType string,
+) (*SimulateReply, error) {
+	actionBytes := make([]codec.Bytes, len(actions))
+	for i, action := range actions {
+		b, err := chain.MarshalTyped(action)
+		if err != nil {
+			return nil, err
+		}
+		actionBytes[i] = b
+	}
+	resp := new(SimulateReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"simulate",
+		&SimulateArgs{
+			Actions:  actionBytes,
+			Actor:    actor,
+			AuthType: authType,
+		},
+		resp,
+	)
+	return resp, err
+}
+
+// ParseOutput decodes an action output returned by [JSONRPCClient.Simulate].
+// It returns nil if [b] is empty, as it is for failed actions.
+func ParseOutput(b []byte) (codec.Typed, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	return OutputParser.Unmarshal(codec.NewReader(b, len(b)))
+}
+
 func (cli *JSONRPCClient) WaitForBalance(
 	ctx context.Context,
 	addr codec.Address,
@@ -87,6 +366,57 @@ func (cli *JSONRPCClient) WaitForBalance(
 	})
 }
 
+type ReceivedMemo struct {
+	TxID   ids.ID        `json:"txId"`
+	Sender codec.Address `json:"sender"`
+	Value  uint64        `json:"value"`
+	Memo   []byte        `json:"memo"`
+}
+
+// ReceivedMemos returns the decrypted memos of the successful transfers to
+// [key] in the block at [height]. The block must still be retained by the
+// node's indexer.
+func (cli *JSONRPCClient) ReceivedMemos(
+	ctx context.Context,
+	key ed25519.PrivateKey,
+	height uint64,
+) ([]*ReceivedMemo, error) {
+	parser, err := cli.Parser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	blk, err := cli.indexer.GetBlockByHeight(ctx, height, parser)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := auth.NewED25519Address(key.PublicKey())
+	memos := []*ReceivedMemo{}
+	for i, tx := range blk.Block.Txs {
+		if !blk.Results[i].Success {
+			continue
+		}
+		for _, action := range tx.Actions {
+			transfer, ok := action.(*actions.Transfer)
+			if !ok || transfer.To != addr || !actions.IsEncryptedMemo(transfer.Memo) {
+				continue
+			}
+			memo, err := actions.OpenMemo(key, transfer.Memo)
+			if err != nil {
+				// The memo was not sealed for [key].
+				continue
+			}
+			memos = append(memos, &ReceivedMemo{
+				TxID:   tx.ID(),
+				Sender: tx.Auth.Actor(),
+				Value:  transfer.Value,
+				Memo:   memo,
+			})
+		}
+	}
+	return memos, nil
+}
+
 func (cli *JSONRPCClient) Parser(ctx context.Context) (chain.Parser, error) {
 	g, err := cli.Genesis(ctx)
 	if err != nil {
@@ -0,0 +1,324 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/blockstore"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/fees"
+)
+
+var (
+	ErrExplorerDisabled = errors.New("explorer is disabled")
+	ErrUnknownTypeID    = errors.New("unknown type ID")
+)
+
+// ExplorerBlock is an accepted block with its transactions decoded.
+type ExplorerBlock struct {
+	ID     ids.ID `json:"id"`
+	Height uint64 `json:"height"`
+	Parent ids.ID `json:"parent"`
+
+	// Timestamp is in milliseconds. StateRoot is the root of the state
+	// after [Parent] was executed.
+	Timestamp  int64           `json:"timestamp"`
+	StateRoot  ids.ID          `json:"stateRoot"`
+	UnitPrices fees.Dimensions `json:"unitPrices"`
+	Txs        []*ExplorerTx   `json:"txs"`
+}
+
+// ExplorerTx is an accepted transaction with its auth, actions and outputs
+// decoded.
+type ExplorerTx struct {
+	ID      ids.ID `json:"id"`
+	BlockID ids.ID `json:"blockId"`
+	Height  uint64 `json:"height"`
+	Index   int    `json:"index"`
+
+	// Expiry is the timestamp, in milliseconds, after which the transaction
+	// could not be included.
+	Expiry  int64             `json:"expiry"`
+	MaxFee  uint64            `json:"maxFee"`
+	Auth    *ExplorerAuth     `json:"auth"`
+	Actions []*ExplorerAction `json:"actions"`
+
+	Success bool            `json:"success"`
+	Error   string          `json:"error,omitempty"`
+	Units   fees.Dimensions `json:"units"`
+	Fee     uint64          `json:"fee"`
+}
+
+type ExplorerAuth struct {
+	// Type is the name of the auth type, such as "ed25519".
+	Type      string        `json:"type"`
+	TypeID    uint8         `json:"typeId"`
+	Actor     codec.Address `json:"actor"`
+	Sponsor   codec.Address `json:"sponsor"`
+	Signer    codec.Bytes   `json:"signer"`
+	Signature codec.Bytes   `json:"signature"`
+}
+
+type ExplorerAction struct {
+	// Type is the name of the action, such as "Transfer", and Data its JSON
+	// encoding.
+	Type   string          `json:"type"`
+	TypeID uint8           `json:"typeId"`
+	Data   json.RawMessage `json:"data"`
+
+	// Output is nil if the transaction failed or the action has no output.
+	Output *ExplorerOutput `json:"output,omitempty"`
+}
+
+// Decode returns the action [a] holds.
+func (a *ExplorerAction) Decode() (chain.Action, error) {
+	return decodeTyped[chain.Action](ActionParser, a.TypeID, a.Data)
+}
+
+type ExplorerOutput struct {
+	Type   string          `json:"type"`
+	TypeID uint8           `json:"typeId"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Decode returns the output [o] holds.
+func (o *ExplorerOutput) Decode() (codec.Typed, error) {
+	return decodeTyped[codec.Typed](OutputParser, o.TypeID, o.Data)
+}
+
+// decodeTyped unmarshals [data] into a new value of the type registered in
+// [parser] with [typeID].
+func decodeTyped[T codec.Typed](parser *codec.TypeParser[T], typeID uint8, data []byte) (T, error) {
+	var zero T
+	for _, typ := range parser.GetRegisteredTypes() {
+		if typ.GetTypeID() != typeID {
+			continue
+		}
+		v := reflect.New(reflect.TypeOf(typ).Elem()).Interface()
+		if err := json.Unmarshal(data, v); err != nil {
+			return zero, err
+		}
+		return v.(T), nil
+	}
+	return zero, fmt.Errorf("%w: %d", ErrUnknownTypeID, typeID)
+}
+
+func newExplorerBlock(blk *chain.ExecutedBlock) (*ExplorerBlock, error) {
+	b := &ExplorerBlock{
+		ID:         blk.BlockID,
+		Height:     blk.Block.Hght,
+		Parent:     blk.Block.Prnt,
+		Timestamp:  blk.Block.Tmstmp,
+		StateRoot:  blk.Block.StateRoot,
+		UnitPrices: blk.UnitPrices,
+		Txs:        make([]*ExplorerTx, len(blk.Block.Txs)),
+	}
+	for i := range blk.Block.Txs {
+		tx, err := newExplorerTx(blk, i)
+		if err != nil {
+			return nil, err
+		}
+		b.Txs[i] = tx
+	}
+	return b, nil
+}
+
+// newExplorerTx decodes the transaction at [index] in [blk].
+func newExplorerTx(blk *chain.ExecutedBlock, index int) (*ExplorerTx, error) {
+	tx, result := blk.Block.Txs[index], blk.Results[index]
+	outputs, err := decodeOutputs(tx, result)
+	if err != nil {
+		return nil, err
+	}
+	t := &ExplorerTx{
+		ID:      tx.ID(),
+		BlockID: blk.BlockID,
+		Height:  blk.Block.Hght,
+		Index:   index,
+		Expiry:  tx.Base.Timestamp,
+		MaxFee:  tx.Base.MaxFee,
+		Auth:    newExplorerAuth(tx.Auth),
+		Actions: make([]*ExplorerAction, len(tx.Actions)),
+		Success: result.Success,
+		Error:   string(result.Error),
+		Units:   result.Units,
+		Fee:     result.Fee,
+	}
+	for i, action := range tx.Actions {
+		data, err := json.Marshal(action)
+		if err != nil {
+			return nil, err
+		}
+		t.Actions[i] = &ExplorerAction{
+			Type:   typeName(action),
+			TypeID: action.GetTypeID(),
+			Data:   data,
+		}
+		if outputs[i] == nil {
+			continue
+		}
+		data, err = json.Marshal(outputs[i])
+		if err != nil {
+			return nil, err
+		}
+		t.Actions[i].Output = &ExplorerOutput{
+			Type:   typeName(outputs[i]),
+			TypeID: outputs[i].GetTypeID(),
+			Data:   data,
+		}
+	}
+	return t, nil
+}
+
+func newExplorerAuth(a chain.Auth) *ExplorerAuth {
+	e := &ExplorerAuth{
+		Type:    authTypes[a.GetTypeID()],
+		TypeID:  a.GetTypeID(),
+		Actor:   a.Actor(),
+		Sponsor: a.Sponsor(),
+	}
+	switch a := a.(type) {
+	case *auth.ED25519:
+		e.Signer, e.Signature = a.Signer[:], a.Signature[:]
+	case *auth.SECP256R1:
+		e.Signer, e.Signature = a.Signer[:], a.Signature[:]
+	case *auth.BLS:
+		e.Signer, e.Signature = bls.PublicKeyToBytes(a.Signer), bls.SignatureToBytes(a.Signature)
+	}
+	return e
+}
+
+// decodeOutputs returns the output of every action of [tx]. The outputs are
+// nil if the transaction failed.
+func decodeOutputs(tx *chain.Transaction, result *chain.Result) ([]codec.Typed, error) {
+	outputs := make([]codec.Typed, len(tx.Actions))
+	if !result.Success {
+		return outputs, nil
+	}
+	for i, b := range result.Outputs {
+		if i >= len(outputs) || len(b) == 0 {
+			continue
+		}
+		output, err := OutputParser.Unmarshal(codec.NewReader(b, len(b)))
+		if err != nil {
+			return nil, fmt.Errorf("output %d: %w", i, err)
+		}
+		outputs[i] = output
+	}
+	return outputs, nil
+}
+
+type BlockArgs struct {
+	// Height or ID selects the block. If neither is set, the last stored
+	// block is returned.
+	Height *uint64 `json:"height,omitempty"`
+	ID     ids.ID  `json:"id"`
+}
+
+type BlockReply struct {
+	Block *ExplorerBlock `json:"block"`
+}
+
+// Block returns a stored block with its transactions decoded. The node must
+// run with the explorer enabled.
+func (j *JSONRPCServer) Block(req *http.Request, args *BlockArgs, reply *BlockReply) error {
+	_, span := j.vm.Tracer().Start(req.Context(), "Server.Block")
+	defer span.End()
+
+	if j.blocks == nil {
+		return ErrExplorerDisabled
+	}
+	var (
+		blk *chain.ExecutedBlock
+		err error
+	)
+	switch {
+	case args.ID != ids.Empty:
+		blk, err = j.blocks.BlockByID(args.ID)
+	case args.Height != nil:
+		blk, err = j.blocks.Block(*args.Height)
+	default:
+		_, last, ok := j.blocks.Heights()
+		if !ok {
+			return blockstore.ErrBlockNotFound
+		}
+		blk, err = j.blocks.Block(last)
+	}
+	if err != nil {
+		return err
+	}
+	reply.Block, err = newExplorerBlock(blk)
+	return err
+}
+
+type BlocksArgs struct {
+	// Cursor is the [BlocksReply.Next] of the previous page. It is empty for
+	// the first page.
+	Cursor codec.Bytes `json:"cursor"`
+	Limit  int         `json:"limit"`
+}
+
+type BlocksReply struct {
+	Blocks []*ExplorerBlock `json:"blocks"`
+	Next   codec.Bytes      `json:"next"`
+}
+
+// Blocks returns the stored blocks with their transactions decoded, newest
+// first. The node must run with the explorer enabled.
+func (j *JSONRPCServer) Blocks(req *http.Request, args *BlocksArgs, reply *BlocksReply) error {
+	_, span := j.vm.Tracer().Start(req.Context(), "Server.Blocks")
+	defer span.End()
+
+	if j.blocks == nil {
+		return ErrExplorerDisabled
+	}
+	blks, next, err := j.blocks.Blocks(args.Cursor, args.Limit)
+	if err != nil {
+		return err
+	}
+	reply.Blocks = make([]*ExplorerBlock, len(blks))
+	for i, blk := range blks {
+		reply.Blocks[i], err = newExplorerBlock(blk)
+		if err != nil {
+			return err
+		}
+	}
+	reply.Next = next
+	return nil
+}
+
+type TransactionArgs struct {
+	TxID ids.ID `json:"txId"`
+}
+
+type TransactionReply struct {
+	Transaction *ExplorerTx `json:"transaction"`
+}
+
+// Transaction returns a transaction of a stored block with its auth, actions
+// and outputs decoded. The node must run with the explorer enabled.
+func (j *JSONRPCServer) Transaction(req *http.Request, args *TransactionArgs, reply *TransactionReply) error {
+	_, span := j.vm.Tracer().Start(req.Context(), "Server.Transaction")
+	defer span.End()
+
+	if j.blocks == nil {
+		return ErrExplorerDisabled
+	}
+	blk, index, err := j.blocks.Tx(args.TxID)
+	if err != nil {
+		return err
+	}
+	reply.Transaction, err = newExplorerTx(blk, index)
+	return err
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/crypto/secp256r1"
+	"github.com/ava-labs/hypersdk/fees"
+)
+
+// explorerTestActions returns a value of every registered action, with the
+// value of its output.
+func explorerTestActions() ([]chain.Action, []codec.Typed) {
+	addr := codectest.NewRandomAddress()
+	txActions := []chain.Action{
+		&actions.Transfer{To: addr, Value: 1, Memo: []byte("memo")},
+		&actions.SetSpendingLimit{Limit: 2, Window: 3},
+		&actions.CreateSubscription{Payee: addr, Amount: 4, Period: 5, MaxCycles: 6},
+		&actions.ChargeSubscription{SubscriptionID: ids.GenerateTestID(), Payer: addr},
+		&actions.CancelSubscription{SubscriptionID: ids.GenerateTestID(), Payee: addr},
+		&actions.Sweep{To: addr},
+		&actions.BridgeLock{DestinationChainID: ids.GenerateTestID(), Recipient: addr, Value: 7},
+		&actions.BridgeMint{
+			SourceChainID: ids.GenerateTestID(),
+			TransferID:    ids.GenerateTestID(),
+			Recipient:     addr,
+			Value:         8,
+			Signatures:    []byte{1, 2, 3},
+		},
+		&actions.BridgeBurn{DestinationChainID: ids.GenerateTestID(), Recipient: addr, Value: 9},
+		&actions.BridgeUnlock{
+			SourceChainID: ids.GenerateTestID(),
+			TransferID:    ids.GenerateTestID(),
+			Recipient:     addr,
+			Value:         10,
+			Signatures:    []byte{4, 5, 6},
+		},
+		&actions.Migrate{},
+	}
+	outputs := []codec.Typed{
+		&actions.TransferResult{SenderBalance: 1, ReceiverBalance: 2},
+		&actions.SetSpendingLimitResult{Limit: 2, Window: 3, EffectiveAt: 4},
+		&actions.CreateSubscriptionResult{SubscriptionID: ids.GenerateTestID()},
+		&actions.ChargeSubscriptionResult{PayerBalance: 5, PayeeBalance: 6, Cycles: 7, Amount: 8},
+		&actions.CancelSubscriptionResult{Cycles: 9},
+		&actions.SweepResult{Value: 10, ReceiverBalance: 11},
+		&actions.BridgeLockResult{TransferID: ids.GenerateTestID(), SenderBalance: 12, Locked: 13},
+		&actions.BridgeMintResult{ReceiverBalance: 14, Minted: 15},
+		&actions.BridgeBurnResult{TransferID: ids.GenerateTestID(), SenderBalance: 16, Minted: 17},
+		&actions.BridgeUnlockResult{ReceiverBalance: 18, Locked: 19},
+		&actions.MigrateResult{Version: 20},
+	}
+	return txActions, outputs
+}
+
+func TestExplorerTx(t *testing.T) {
+	txActions, outputs := explorerTestActions()
+
+	// Every registered type has a value, so that adding a type without
+	// extending the test fails.
+	actionIDs := map[uint8]bool{}
+	for _, action := range txActions {
+		actionIDs[action.GetTypeID()] = true
+	}
+	for _, typ := range ActionParser.GetRegisteredTypes() {
+		require.True(t, actionIDs[typ.GetTypeID()], "no value of action %T", typ)
+	}
+	outputIDs := map[uint8]bool{}
+	for _, output := range outputs {
+		outputIDs[output.GetTypeID()] = true
+	}
+	for _, typ := range OutputParser.GetRegisteredTypes() {
+		require.True(t, outputIDs[typ.GetTypeID()], "no value of output %T", typ)
+	}
+	encodedOutputs := make([][]byte, len(outputs))
+	for i, output := range outputs {
+		b, err := chain.MarshalTyped(output)
+		require.NoError(t, err)
+		encodedOutputs[i] = b
+	}
+
+	ed25519Key, err := ed25519.GeneratePrivateKey()
+	require.NoError(t, err)
+	secp256r1Key, err := secp256r1.GeneratePrivateKey()
+	require.NoError(t, err)
+	blsKey, err := bls.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		authType string
+		factory  chain.AuthFactory
+	}{
+		{
+			authType: auth.ED25519Key,
+			factory:  auth.NewED25519Factory(ed25519Key),
+		},
+		{
+			authType: auth.Secp256r1Key,
+			factory:  auth.NewSECP256R1Factory(secp256r1Key),
+		},
+		{
+			authType: auth.BLSKey,
+			factory:  auth.NewBLSFactory(blsKey),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.authType, func(t *testing.T) {
+			require := require.New(t)
+
+			// The first transaction succeeded, the second failed and has no
+			// outputs.
+			blk, err := chain.NewExecutedBlock(
+				&chain.StatelessBlock{
+					Hght:   1,
+					Tmstmp: 1_000,
+					Txs: []*chain.Transaction{
+						newTestTx(t, tt.factory, 1_000, txActions...),
+						newTestTx(t, tt.factory, 2_000, txActions...),
+					},
+				},
+				[]*chain.Result{
+					{Success: true, Error: []byte{}, Outputs: encodedOutputs, Units: fees.Dimensions{1}, Fee: 1},
+					{Success: false, Error: []byte("failed"), Units: fees.Dimensions{2}, Fee: 2},
+				},
+				fees.Dimensions{},
+			)
+			require.NoError(err)
+			explorerBlk, err := newExplorerBlock(blk)
+			require.NoError(err)
+			require.Len(explorerBlk.Txs, 2)
+
+			for i, success := range []bool{true, false} {
+				tx := blk.Block.Txs[i]
+
+				// Clients read the transaction from its JSON encoding.
+				b, err := json.Marshal(explorerBlk.Txs[i])
+				require.NoError(err)
+				var explorerTx ExplorerTx
+				require.NoError(json.Unmarshal(b, &explorerTx))
+
+				require.Equal(tx.ID(), explorerTx.ID)
+				require.Equal(blk.BlockID, explorerTx.BlockID)
+				require.Equal(i, explorerTx.Index)
+				require.Equal(success, explorerTx.Success)
+				require.Equal(string(blk.Results[i].Error), explorerTx.Error)
+				require.Equal(blk.Results[i].Fee, explorerTx.Fee)
+
+				require.Equal(tt.authType, explorerTx.Auth.Type)
+				require.Equal(tx.Auth.GetTypeID(), explorerTx.Auth.TypeID)
+				require.Equal(tx.Auth.Actor(), explorerTx.Auth.Actor)
+				require.Equal(tx.Auth.Sponsor(), explorerTx.Auth.Sponsor)
+				require.NotEmpty(explorerTx.Auth.Signer)
+				require.NotEmpty(explorerTx.Auth.Signature)
+
+				require.Len(explorerTx.Actions, len(txActions))
+				for j, a := range explorerTx.Actions {
+					require.Equal(fmt.Sprintf("*actions.%s", a.Type), fmt.Sprintf("%T", txActions[j]))
+					require.Equal(txActions[j].GetTypeID(), a.TypeID)
+					action, err := a.Decode()
+					require.NoError(err)
+					require.Equal(txActions[j], action)
+
+					if !success {
+						require.Nil(a.Output)
+						continue
+					}
+					require.NotNil(a.Output)
+					require.Equal(fmt.Sprintf("*actions.%s", a.Output.Type), fmt.Sprintf("%T", outputs[j]))
+					require.Equal(outputs[j].GetTypeID(), a.Output.TypeID)
+					output, err := a.Output.Decode()
+					require.NoError(err)
+					require.Equal(outputs[j], output)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeUnknownType(t *testing.T) {
+	require := require.New(t)
+
+	_, err := (&ExplorerAction{TypeID: 0xff, Data: []byte("{}")}).Decode()
+	require.ErrorIs(err, ErrUnknownTypeID)
+	_, err = (&ExplorerOutput{TypeID: 0xff, Data: []byte("{}")}).Decode()
+	require.ErrorIs(err, ErrUnknownTypeID)
+}
@@ -0,0 +1,108 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/trace"
+
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var (
+	_ genesis.Genesis               = (*Genesis)(nil)
+	_ genesis.GenesisAndRuleFactory = (*GenesisFactory)(nil)
+	_ genesis.RuleFactory           = (*RuleFactory)(nil)
+	_ storage.MigrationRules        = (*Rules)(nil)
+)
+
+// Genesis extends the default genesis with the configuration of the bridge
+// relayers. If [Bridge] is nil, bridged transfers cannot be minted or
+// unlocked on this chain.
+type Genesis struct {
+	*genesis.DefaultGenesis
+	Bridge *storage.BridgeConfig `json:"bridge,omitempty"`
+}
+
+func (g *Genesis) InitializeState(ctx context.Context, tracer trace.Tracer, mu state.Mutable, balanceHandler chain.BalanceHandler) error {
+	// A new chain starts with the latest layout, so no migration ever runs
+	// on it. The version is set first, as records are only written once
+	// the state has reached their layout.
+	if latest := storage.Migrations().Latest(); latest > 0 {
+		if err := storage.SetSchemaVersion(ctx, mu, latest); err != nil {
+			return err
+		}
+	}
+	if err := g.DefaultGenesis.InitializeState(ctx, tracer, mu, balanceHandler); err != nil {
+		return err
+	}
+	if g.Bridge == nil {
+		return nil
+	}
+	return storage.SetBridgeConfig(ctx, mu, g.Bridge)
+}
+
+// Upgrades is read from the upgrade bytes of the chain, which, unlike the
+// genesis, can be changed on an existing chain.
+type Upgrades struct {
+	// Migrations maps the version of every scheduled state migration to the
+	// timestamp, in ms, from which it is active.
+	Migrations map[uint64]int64 `json:"migrations"`
+}
+
+// Rules extends the default rules with the schedule of the state
+// migrations.
+type Rules struct {
+	*genesis.Rules
+	migrations map[uint64]int64
+}
+
+func (r *Rules) GetMigrationTime(version uint64) (int64, bool) {
+	t, ok := r.migrations[version]
+	return t, ok
+}
+
+type RuleFactory struct {
+	Rules *Rules
+}
+
+func (f *RuleFactory) GetRules(_ int64) chain.Rules {
+	return f.Rules
+}
+
+type GenesisFactory struct{}
+
+func (GenesisFactory) Load(genesisBytes []byte, upgradeBytes []byte, networkID uint32, chainID ids.ID) (genesis.Genesis, genesis.RuleFactory, error) {
+	g := &Genesis{}
+	if err := json.Unmarshal(genesisBytes, g); err != nil {
+		return nil, nil, err
+	}
+	if g.Bridge != nil {
+		if err := g.Bridge.Verify(); err != nil {
+			return nil, nil, err
+		}
+	}
+	upgrades := &Upgrades{}
+	if len(upgradeBytes) > 0 {
+		if err := json.Unmarshal(upgradeBytes, upgrades); err != nil {
+			return nil, nil, err
+		}
+	}
+	for version := range upgrades.Migrations {
+		if version == 0 || version > storage.Migrations().Latest() {
+			return nil, nil, fmt.Errorf("%w: migration %d is not registered", storage.ErrInvalidMigration, version)
+		}
+	}
+	g.Rules.NetworkID = networkID
+	g.Rules.ChainID = chainID
+
+	return g, &RuleFactory{Rules: &Rules{Rules: g.Rules, migrations: upgrades.Migrations}}, nil
+}
@@ -0,0 +1,707 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/graph-gophers/graphql-go"
+
+	"github.com/ava-labs/hypersdk-starter-kit/apiguard"
+	"github.com/ava-labs/hypersdk-starter-kit/blockstore"
+	"github.com/ava-labs/hypersdk-starter-kit/history"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/api"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/fees"
+)
+
+const (
+	GraphQLEndpoint = "/morpheusgraphql"
+
+	// maxGraphQLDepth bounds the nesting of queries, which can otherwise
+	// walk from blocks to transactions to accounts indefinitely.
+	maxGraphQLDepth = 10
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const graphQLSchema = `
+schema {
+	query: Query
+}
+
+"An unsigned 64 bit integer, encoded as a decimal string."
+scalar Uint64
+
+"Any JSON value."
+scalar JSON
+
+type Query {
+	account(address: String!): Account!
+	"Returns null if the transaction is not in the block store."
+	transaction(id: ID!): Transaction
+	"Returns the block at height or with id, or the last stored block."
+	block(height: Uint64, id: ID): Block
+	"Returns the stored blocks, newest first."
+	blocks(first: Int, after: String): BlockPage!
+	genesis: Genesis!
+}
+
+type PageInfo {
+	endCursor: String
+	hasNextPage: Boolean!
+}
+
+type Account {
+	address: String!
+	"The auth type that derives the address, or null if none does."
+	authType: String
+	balance: Uint64!
+	"The transactions that touched the account, newest first. Needs the history index."
+	history(first: Int, after: String): HistoryPage!
+}
+
+type HistoryPage {
+	nodes: [HistoryEntry!]!
+	pageInfo: PageInfo!
+}
+
+type HistoryEntry {
+	txID: ID!
+	height: Uint64!
+	timestamp: Uint64!
+	success: Boolean!
+	sent: Uint64!
+	received: Uint64!
+	fee: Uint64!
+	transaction: Transaction
+}
+
+type BlockPage {
+	nodes: [Block!]!
+	pageInfo: PageInfo!
+}
+
+type Block {
+	id: ID!
+	height: Uint64!
+	parent: ID!
+	timestamp: Uint64!
+	stateRoot: ID!
+	unitPrices: Units!
+	transactions: [Transaction!]!
+}
+
+type Units {
+	bandwidth: Uint64!
+	compute: Uint64!
+	storageRead: Uint64!
+	storageAllocate: Uint64!
+	storageWrite: Uint64!
+}
+
+type Transaction {
+	id: ID!
+	block: Block!
+	index: Int!
+	expiry: Uint64!
+	maxFee: Uint64!
+	actor: String!
+	sponsor: String!
+	authType: String
+	success: Boolean!
+	error: String
+	fee: Uint64!
+	units: Units!
+	actions: [Action!]!
+}
+
+type Action {
+	type: String!
+	typeID: Int!
+	data: JSON!
+	"The output of the action, or null if the transaction failed."
+	output: Output
+}
+
+type Output {
+	type: String!
+	typeID: Int!
+	data: JSON!
+}
+
+type Genesis {
+	rules: JSON!
+	allocations: [Allocation!]!
+	bridge: JSON
+}
+
+type Allocation {
+	address: String!
+	balance: Uint64!
+}
+`
+
+// Uint64 is the GraphQL scalar of uint64 values. It is a string, as JSON
+// numbers cannot hold every uint64 in most clients.
+type Uint64 uint64
+
+func (Uint64) ImplementsGraphQLType(name string) bool {
+	return name == "Uint64"
+}
+
+func (u *Uint64) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case string:
+		v, err := strconv.ParseUint(input, 10, 64)
+		if err != nil {
+			return err
+		}
+		*u = Uint64(v)
+		return nil
+	case int32:
+		if input < 0 {
+			return fmt.Errorf("%w: negative Uint64", ErrInvalidRequest)
+		}
+		*u = Uint64(input)
+		return nil
+	default:
+		return fmt.Errorf("%w: invalid Uint64", ErrInvalidRequest)
+	}
+}
+
+func (u Uint64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatUint(uint64(u), 10))
+}
+
+// JSON is the GraphQL scalar of values with a dynamic shape, such as
+// decoded actions.
+type JSON struct {
+	Value any
+}
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *JSON) UnmarshalGraphQL(input interface{}) error {
+	j.Value = input
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Value)
+}
+
+var _ api.HandlerFactory[api.VM] = (*graphQLFactory)(nil)
+
+type graphQLFactory struct {
+	history *history.Indexer
+	blocks  *blockstore.Store
+	guard   *apiguard.Guard
+}
+
+func (f graphQLFactory) New(vm api.VM) (api.Handler, error) {
+	schema, err := graphql.ParseSchema(
+		graphQLSchema,
+		&graphQLResolver{vm: vm, history: f.history, blocks: f.blocks},
+		graphql.MaxDepth(maxGraphQLDepth),
+	)
+	if err != nil {
+		return api.Handler{}, err
+	}
+	return api.Handler{
+		Path: GraphQLEndpoint,
+		Handler: f.guard.Method(GraphQLMethod, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.Header().Set("Allow", http.MethodPost)
+				writeJSON(w, http.StatusMethodNotAllowed, &RESTError{Error: "method not allowed"})
+				return
+			}
+			var params struct {
+				Query         string         `json:"query"`
+				OperationName string         `json:"operationName"`
+				Variables     map[string]any `json:"variables"`
+			}
+			if err := decodeBody(r, &params); err != nil {
+				writeJSON(w, http.StatusBadRequest, &RESTError{Error: err.Error()})
+				return
+			}
+			ctx, span := vm.Tracer().Start(r.Context(), "GraphQL.Exec")
+			defer span.End()
+
+			writeJSON(w, http.StatusOK, schema.Exec(ctx, params.Query, params.OperationName, params.Variables))
+		})),
+	}, nil
+}
+
+// graphQLResolver resolves the queries of [graphQLSchema]. [history] is nil
+// unless the history index is enabled.
+type graphQLResolver struct {
+	vm      api.VM
+	history *history.Indexer
+	blocks  *blockstore.Store
+}
+
+type pageArgs struct {
+	First *int32
+	After *string
+}
+
+// page returns the limit and decoded cursor of [args].
+func (args *pageArgs) page() (int, []byte, error) {
+	limit := 0
+	if args.First != nil {
+		limit = int(*args.First)
+	}
+	if args.After == nil {
+		return limit, nil, nil
+	}
+	cursor, err := base64.RawURLEncoding.DecodeString(*args.After)
+	if err != nil || len(cursor) == 0 {
+		return 0, nil, ErrInvalidCursor
+	}
+	return limit, cursor, nil
+}
+
+type pageInfo struct {
+	next []byte
+}
+
+func (p *pageInfo) EndCursor() *string {
+	if len(p.next) == 0 {
+		return nil
+	}
+	cursor := base64.RawURLEncoding.EncodeToString(p.next)
+	return &cursor
+}
+
+func (p *pageInfo) HasNextPage() bool {
+	return len(p.next) > 0
+}
+
+func (r *graphQLResolver) Account(args struct{ Address string }) (*accountResolver, error) {
+	addr, err := codec.StringToAddress(args.Address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid address: %w", ErrInvalidRequest, err)
+	}
+	return &accountResolver{r: r, addr: addr}, nil
+}
+
+func (r *graphQLResolver) Transaction(args struct{ ID graphql.ID }) (*txResolver, error) {
+	txID, err := ids.FromString(string(args.ID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ID: %w", ErrInvalidRequest, err)
+	}
+	return r.tx(txID)
+}
+
+// tx returns the resolver of [txID], or nil if it is not stored.
+func (r *graphQLResolver) tx(txID ids.ID) (*txResolver, error) {
+	blk, index, err := r.blocks.Tx(txID)
+	if errors.Is(err, blockstore.ErrTxNotFound) || errors.Is(err, blockstore.ErrBlockNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &txResolver{r: r, blk: blk, index: index}, nil
+}
+
+func (r *graphQLResolver) Block(args struct {
+	Height *Uint64
+	ID     *graphql.ID
+}) (*blockResolver, error) {
+	var (
+		blk *chain.ExecutedBlock
+		err error
+	)
+	switch {
+	case args.ID != nil:
+		blkID, parseErr := ids.FromString(string(*args.ID))
+		if parseErr != nil {
+			return nil, fmt.Errorf("%w: invalid ID: %w", ErrInvalidRequest, parseErr)
+		}
+		blk, err = r.blocks.BlockByID(blkID)
+	case args.Height != nil:
+		blk, err = r.blocks.Block(uint64(*args.Height))
+	default:
+		_, last, ok := r.blocks.Heights()
+		if !ok {
+			return nil, nil
+		}
+		blk, err = r.blocks.Block(last)
+	}
+	if errors.Is(err, blockstore.ErrBlockNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &blockResolver{r: r, blk: blk}, nil
+}
+
+func (r *graphQLResolver) Blocks(args pageArgs) (*blockPage, error) {
+	limit, cursor, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+	blks, next, err := r.blocks.Blocks(cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &blockPage{pageInfo: &pageInfo{next: next}}
+	for _, blk := range blks {
+		page.nodes = append(page.nodes, &blockResolver{r: r, blk: blk})
+	}
+	return page, nil
+}
+
+func (r *graphQLResolver) Genesis() *genesisResolver {
+	return &genesisResolver{g: r.vm.Genesis().(*Genesis)}
+}
+
+type accountResolver struct {
+	r    *graphQLResolver
+	addr codec.Address
+}
+
+func (a *accountResolver) Address() string {
+	return a.addr.String()
+}
+
+func (a *accountResolver) AuthType() *string {
+	authType, ok := AuthType(a.addr)
+	if !ok {
+		return nil
+	}
+	return &authType
+}
+
+func (a *accountResolver) Balance(ctx context.Context) (Uint64, error) {
+	balance, err := storage.GetBalanceFromState(ctx, a.r.vm.ReadState, a.addr)
+	return Uint64(balance), err
+}
+
+func (a *accountResolver) History(args pageArgs) (*historyPage, error) {
+	if a.r.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+	limit, cursor, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+	entries, next, err := a.r.history.History(a.addr, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &historyPage{pageInfo: &pageInfo{next: next}}
+	for _, entry := range entries {
+		page.nodes = append(page.nodes, &historyEntryResolver{r: a.r, e: entry})
+	}
+	return page, nil
+}
+
+type historyPage struct {
+	nodes    []*historyEntryResolver
+	pageInfo *pageInfo
+}
+
+func (p *historyPage) Nodes() []*historyEntryResolver {
+	return p.nodes
+}
+
+func (p *historyPage) PageInfo() *pageInfo {
+	return p.pageInfo
+}
+
+type historyEntryResolver struct {
+	r *graphQLResolver
+	e *history.Entry
+}
+
+func (h *historyEntryResolver) TxID() graphql.ID {
+	return graphql.ID(h.e.TxID.String())
+}
+
+func (h *historyEntryResolver) Height() Uint64 {
+	return Uint64(h.e.Height)
+}
+
+func (h *historyEntryResolver) Timestamp() Uint64 {
+	return Uint64(h.e.Timestamp)
+}
+
+func (h *historyEntryResolver) Success() bool {
+	return h.e.Success
+}
+
+func (h *historyEntryResolver) Sent() Uint64 {
+	return Uint64(h.e.Sent)
+}
+
+func (h *historyEntryResolver) Received() Uint64 {
+	return Uint64(h.e.Received)
+}
+
+func (h *historyEntryResolver) Fee() Uint64 {
+	return Uint64(h.e.Fee)
+}
+
+func (h *historyEntryResolver) Transaction() (*txResolver, error) {
+	return h.r.tx(h.e.TxID)
+}
+
+type blockPage struct {
+	nodes    []*blockResolver
+	pageInfo *pageInfo
+}
+
+func (p *blockPage) Nodes() []*blockResolver {
+	return p.nodes
+}
+
+func (p *blockPage) PageInfo() *pageInfo {
+	return p.pageInfo
+}
+
+type blockResolver struct {
+	r   *graphQLResolver
+	blk *chain.ExecutedBlock
+}
+
+func (b *blockResolver) ID() graphql.ID {
+	return graphql.ID(b.blk.BlockID.String())
+}
+
+func (b *blockResolver) Height() Uint64 {
+	return Uint64(b.blk.Block.Hght)
+}
+
+func (b *blockResolver) Parent() graphql.ID {
+	return graphql.ID(b.blk.Block.Prnt.String())
+}
+
+func (b *blockResolver) Timestamp() Uint64 {
+	return Uint64(b.blk.Block.Tmstmp)
+}
+
+func (b *blockResolver) StateRoot() graphql.ID {
+	return graphql.ID(b.blk.Block.StateRoot.String())
+}
+
+func (b *blockResolver) UnitPrices() *unitsResolver {
+	return &unitsResolver{b.blk.UnitPrices}
+}
+
+func (b *blockResolver) Transactions() []*txResolver {
+	txs := make([]*txResolver, len(b.blk.Block.Txs))
+	for i := range txs {
+		txs[i] = &txResolver{r: b.r, blk: b.blk, index: i}
+	}
+	return txs
+}
+
+type unitsResolver struct {
+	d fees.Dimensions
+}
+
+func (u *unitsResolver) Bandwidth() Uint64 {
+	return Uint64(u.d[fees.Bandwidth])
+}
+
+func (u *unitsResolver) Compute() Uint64 {
+	return Uint64(u.d[fees.Compute])
+}
+
+func (u *unitsResolver) StorageRead() Uint64 {
+	return Uint64(u.d[fees.StorageRead])
+}
+
+func (u *unitsResolver) StorageAllocate() Uint64 {
+	return Uint64(u.d[fees.StorageAllocate])
+}
+
+func (u *unitsResolver) StorageWrite() Uint64 {
+	return Uint64(u.d[fees.StorageWrite])
+}
+
+type txResolver struct {
+	r     *graphQLResolver
+	blk   *chain.ExecutedBlock
+	index int
+}
+
+func (t *txResolver) tx() *chain.Transaction {
+	return t.blk.Block.Txs[t.index]
+}
+
+func (t *txResolver) result() *chain.Result {
+	return t.blk.Results[t.index]
+}
+
+func (t *txResolver) ID() graphql.ID {
+	return graphql.ID(t.tx().ID().String())
+}
+
+func (t *txResolver) Block() *blockResolver {
+	return &blockResolver{r: t.r, blk: t.blk}
+}
+
+func (t *txResolver) Index() int32 {
+	return int32(t.index)
+}
+
+func (t *txResolver) Expiry() Uint64 {
+	return Uint64(t.tx().Base.Timestamp)
+}
+
+func (t *txResolver) MaxFee() Uint64 {
+	return Uint64(t.tx().Base.MaxFee)
+}
+
+func (t *txResolver) Actor() string {
+	return t.tx().Auth.Actor().String()
+}
+
+func (t *txResolver) Sponsor() string {
+	return t.tx().Auth.Sponsor().String()
+}
+
+func (t *txResolver) AuthType() *string {
+	authType, ok := authTypes[t.tx().Auth.GetTypeID()]
+	if !ok {
+		return nil
+	}
+	return &authType
+}
+
+func (t *txResolver) Success() bool {
+	return t.result().Success
+}
+
+func (t *txResolver) Error() *string {
+	if len(t.result().Error) == 0 {
+		return nil
+	}
+	msg := string(t.result().Error)
+	return &msg
+}
+
+func (t *txResolver) Fee() Uint64 {
+	return Uint64(t.result().Fee)
+}
+
+func (t *txResolver) Units() *unitsResolver {
+	return &unitsResolver{t.result().Units}
+}
+
+func (t *txResolver) Actions() ([]*actionResolver, error) {
+	tx := t.tx()
+	outputs, err := decodeOutputs(tx, t.result())
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]*actionResolver, len(tx.Actions))
+	for i, action := range tx.Actions {
+		actions[i] = &actionResolver{action: action, output: outputs[i]}
+	}
+	return actions, nil
+}
+
+type actionResolver struct {
+	action chain.Action
+
+	// output is nil if the transaction failed.
+	output codec.Typed
+}
+
+func (a *actionResolver) Type() string {
+	return typeName(a.action)
+}
+
+func (a *actionResolver) TypeID() int32 {
+	return int32(a.action.GetTypeID())
+}
+
+func (a *actionResolver) Data() JSON {
+	return JSON{a.action}
+}
+
+func (a *actionResolver) Output() *outputResolver {
+	if a.output == nil {
+		return nil
+	}
+	return &outputResolver{a.output}
+}
+
+type outputResolver struct {
+	output codec.Typed
+}
+
+func (o *outputResolver) Type() string {
+	return typeName(o.output)
+}
+
+func (o *outputResolver) TypeID() int32 {
+	return int32(o.output.GetTypeID())
+}
+
+func (o *outputResolver) Data() JSON {
+	return JSON{o.output}
+}
+
+// typeName returns the name of the type of a registered action or output.
+func typeName(v codec.Typed) string {
+	return reflect.Indirect(reflect.ValueOf(v)).Type().Name()
+}
+
+type genesisResolver struct {
+	g *Genesis
+}
+
+func (g *genesisResolver) Rules() JSON {
+	return JSON{g.g.Rules}
+}
+
+func (g *genesisResolver) Allocations() []*allocationResolver {
+	allocations := make([]*allocationResolver, len(g.g.CustomAllocation))
+	for i, allocation := range g.g.CustomAllocation {
+		allocations[i] = &allocationResolver{
+			address: allocation.Address.String(),
+			balance: Uint64(allocation.Balance),
+		}
+	}
+	return allocations
+}
+
+func (g *genesisResolver) Bridge() *JSON {
+	if g.g.Bridge == nil {
+		return nil
+	}
+	return &JSON{g.g.Bridge}
+}
+
+type allocationResolver struct {
+	address string
+	balance Uint64
+}
+
+func (a *allocationResolver) Address() string {
+	return a.address
+}
+
+func (a *allocationResolver) Balance() Uint64 {
+	return a.balance
+}
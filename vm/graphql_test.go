@@ -0,0 +1,240 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/apiguard"
+	"github.com/ava-labs/hypersdk-starter-kit/blockstore"
+	"github.com/ava-labs/hypersdk-starter-kit/history"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/api"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/genesis"
+)
+
+// newTestTx returns [actions] signed by [factory].
+func newTestTx(t *testing.T, factory chain.AuthFactory, timestamp int64, actions ...chain.Action) *chain.Transaction {
+	tx, err := chain.NewTxData(
+		&chain.Base{ChainID: ids.GenerateTestID(), Timestamp: timestamp, MaxFee: 10},
+		actions,
+	).Sign(factory)
+	require.NoError(t, err)
+	return tx
+}
+
+// mustJSON returns the JSON encoding of [v].
+func mustJSON(t *testing.T, v any) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestGraphQL(t *testing.T) {
+	vm := newTestVM()
+	key, err := bls.GeneratePrivateKey()
+	require.NoError(t, err)
+	factory := auth.NewBLSFactory(key)
+	actor := auth.NewBLSAddress(bls.PublicFromPrivateKey(key))
+	recipient := codectest.NewRandomAddress()
+	vm.genesis.CustomAllocation = []*genesis.CustomAllocation{{Address: actor, Balance: 5}}
+	vm.genesis.Bridge = &storage.BridgeConfig{Quorum: 1, Relayers: []codec.Bytes{make([]byte, bls.PublicKeyLen)}}
+	require.NoError(t, storage.SetBalance(context.Background(), vm.store, actor, 3))
+
+	// The first block holds a transfer, the second a transfer that failed.
+	transfer := &actions.Transfer{To: recipient, Value: 2, Memo: []byte{}}
+	transferResult := &actions.TransferResult{SenderBalance: 3, ReceiverBalance: 2}
+	output, err := chain.MarshalTyped(transferResult)
+	require.NoError(t, err)
+	blk1, err := chain.NewExecutedBlock(
+		&chain.StatelessBlock{Hght: 1, Tmstmp: 1_000, Txs: []*chain.Transaction{newTestTx(t, factory, 1_000, transfer)}},
+		[]*chain.Result{{Success: true, Error: []byte{}, Outputs: [][]byte{output}, Units: fees.Dimensions{1}, Fee: 1}},
+		fees.Dimensions{1, 2, 3, 4, 5},
+	)
+	require.NoError(t, err)
+	failed := &actions.Transfer{To: recipient, Value: 10, Memo: []byte{}}
+	blk2, err := chain.NewExecutedBlock(
+		&chain.StatelessBlock{Prnt: blk1.BlockID, Hght: 2, Tmstmp: 2_000, Txs: []*chain.Transaction{newTestTx(t, factory, 2_000, failed)}},
+		[]*chain.Result{{Success: false, Error: []byte("insufficient balance"), Units: fees.Dimensions{1}, Fee: 2}},
+		fees.Dimensions{1, 2, 3, 4, 5},
+	)
+	require.NoError(t, err)
+	tx1, tx2 := blk1.Block.Txs[0], blk2.Block.Txs[0]
+
+	parser := NewParser(vm.genesis.DefaultGenesis)
+	blocks, err := blockstore.New(t.TempDir(), parser, 10)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, blocks.Close()) })
+	indexer, err := history.New(t.TempDir(), parser)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, indexer.Close()) })
+	for _, blk := range []*chain.ExecutedBlock{blk1, blk2} {
+		require.NoError(t, blocks.Accept(blk))
+		require.NoError(t, indexer.Accept(blk))
+	}
+
+	url := servePlugin(t, vm, []api.HandlerFactory[api.VM]{graphQLFactory{
+		history: indexer,
+		blocks:  blocks,
+		guard:   apiguard.New(apiguard.NewDefaultConfig(), nil),
+	}}) + GraphQLEndpoint
+
+	tests := []struct {
+		name  string
+		query string
+		// expected is the data of the reply, or empty if the query fails.
+		expected string
+	}{
+		{
+			name:  "Account",
+			query: fmt.Sprintf(`{account(address: %q) {address authType balance history {nodes {txID success sent fee} pageInfo {hasNextPage}}}}`, actor),
+			expected: fmt.Sprintf(`{"account": {
+				"address": %q,
+				"authType": %q,
+				"balance": "3",
+				"history": {
+					"nodes": [
+						{"txID": %q, "success": false, "sent": "0", "fee": "2"},
+						{"txID": %q, "success": true, "sent": "2", "fee": "1"}
+					],
+					"pageInfo": {"hasNextPage": false}
+				}
+			}}`, actor, auth.BLSKey, tx2.ID(), tx1.ID()),
+		},
+		{
+			name:     "AccountWithoutAuthType",
+			query:    fmt.Sprintf(`{account(address: %q) {authType balance}}`, codec.CreateAddress(0xff, ids.GenerateTestID())),
+			expected: `{"account": {"authType": null, "balance": "0"}}`,
+		},
+		{
+			name:  "InvalidAddress",
+			query: `{account(address: "invalid") {balance}}`,
+		},
+		{
+			name:  "Transaction",
+			query: fmt.Sprintf(`{transaction(id: %q) {id block {height} index actor sponsor authType success error fee actions {type typeID data output {type typeID data}}}}`, tx1.ID()),
+			expected: fmt.Sprintf(`{"transaction": {
+				"id": %q,
+				"block": {"height": "1"},
+				"index": 0,
+				"actor": %q,
+				"sponsor": %q,
+				"authType": %q,
+				"success": true,
+				"error": null,
+				"fee": "1",
+				"actions": [{
+					"type": "Transfer",
+					"typeID": %d,
+					"data": %s,
+					"output": {"type": "TransferResult", "typeID": %d, "data": %s}
+				}]
+			}}`, tx1.ID(), actor, actor, auth.BLSKey, transfer.GetTypeID(), mustJSON(t, transfer), transferResult.GetTypeID(), mustJSON(t, transferResult)),
+		},
+		{
+			name:  "FailedTransaction",
+			query: fmt.Sprintf(`{transaction(id: %q) {success error fee actions {type data output {type}}}}`, tx2.ID()),
+			expected: fmt.Sprintf(`{"transaction": {
+				"success": false,
+				"error": "insufficient balance",
+				"fee": "2",
+				"actions": [{"type": "Transfer", "data": %s, "output": null}]
+			}}`, mustJSON(t, failed)),
+		},
+		{
+			name:     "UnknownTransaction",
+			query:    fmt.Sprintf(`{transaction(id: %q) {id}}`, ids.GenerateTestID()),
+			expected: `{"transaction": null}`,
+		},
+		{
+			name:  "BlockByHeight",
+			query: `{block(height: 1) {id height parent timestamp unitPrices {bandwidth storageWrite} transactions {id}}}`,
+			expected: fmt.Sprintf(`{"block": {
+				"id": %q,
+				"height": "1",
+				"parent": %q,
+				"timestamp": "1000",
+				"unitPrices": {"bandwidth": "1", "storageWrite": "5"},
+				"transactions": [{"id": %q}]
+			}}`, blk1.BlockID, ids.Empty, tx1.ID()),
+		},
+		{
+			name:     "BlockByID",
+			query:    fmt.Sprintf(`{block(id: %q) {height parent}}`, blk2.BlockID),
+			expected: fmt.Sprintf(`{"block": {"height": "2", "parent": %q}}`, blk1.BlockID),
+		},
+		{
+			name:     "LastBlock",
+			query:    `{block {height}}`,
+			expected: `{"block": {"height": "2"}}`,
+		},
+		{
+			name:     "UnknownBlock",
+			query:    `{block(height: 3) {height}}`,
+			expected: `{"block": null}`,
+		},
+		{
+			name:     "Blocks",
+			query:    `{blocks(first: 1) {nodes {height} pageInfo {hasNextPage}}}`,
+			expected: `{"blocks": {"nodes": [{"height": "2"}], "pageInfo": {"hasNextPage": true}}}`,
+		},
+		{
+			name:  "InvalidCursor",
+			query: `{blocks(after: "") {nodes {height}}}`,
+		},
+		{
+			name:  "Genesis",
+			query: `{genesis {rules allocations {address balance} bridge}}`,
+			expected: fmt.Sprintf(`{"genesis": {
+				"rules": %s,
+				"allocations": [{"address": %q, "balance": "5"}],
+				"bridge": %s
+			}}`, mustJSON(t, vm.genesis.Rules), actor, mustJSON(t, vm.genesis.Bridge)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			body, err := json.Marshal(map[string]string{"query": tt.query})
+			require.NoError(err)
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(err)
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(err)
+			defer res.Body.Close()
+			require.Equal(http.StatusOK, res.StatusCode)
+			b, err := io.ReadAll(res.Body)
+			require.NoError(err)
+
+			var reply struct {
+				Data   json.RawMessage   `json:"data"`
+				Errors []json.RawMessage `json:"errors"`
+			}
+			require.NoError(json.Unmarshal(b, &reply))
+			if tt.expected == "" {
+				require.NotEmpty(reply.Errors)
+				return
+			}
+			require.Empty(reply.Errors, string(b))
+			require.JSONEq(tt.expected, string(reply.Data))
+		})
+	}
+}
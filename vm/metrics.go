@@ -0,0 +1,257 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow"
+	"github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/consts"
+	"github.com/ava-labs/hypersdk-starter-kit/metrics"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/state"
+	"github.com/ava-labs/hypersdk/vm"
+)
+
+// failureLabels maps the errors returned by actions to the label of the
+// transactions they fail.
+var failureLabels = []struct {
+	err   error
+	label string
+}{
+	{actions.ErrOutputValueZero, "ErrOutputValueZero"},
+	{actions.ErrEncryptedMemoTooLarge, "ErrEncryptedMemoTooLarge"},
+	{actions.ErrOutputMemoTooLarge, "ErrOutputMemoTooLarge"},
+	{storage.ErrInvalidBalance, "ErrInvalidBalance"},
+	{storage.ErrSpendingLimitExceeded, "ErrSpendingLimitExceeded"},
+	{actions.ErrInvalidWindow, "ErrInvalidWindow"},
+	{actions.ErrNoSpendingLimit, "ErrNoSpendingLimit"},
+	{storage.ErrTooManySubscriptions, "ErrTooManySubscriptions"},
+	{storage.ErrInvalidSubscriptionIndex, "ErrInvalidSubscriptionIndex"},
+	{actions.ErrInvalidPeriod, "ErrInvalidPeriod"},
+	{actions.ErrSubscriptionNotFound, "ErrSubscriptionNotFound"},
+	{actions.ErrNotPayer, "ErrNotPayer"},
+	{actions.ErrPayerMismatch, "ErrPayerMismatch"},
+	{actions.ErrChargeTooEarly, "ErrChargeTooEarly"},
+	{actions.ErrInvalidBridgeChain, "ErrInvalidBridgeChain"},
+	{actions.ErrBridgeNotConfigured, "ErrBridgeNotConfigured"},
+	{actions.ErrBridgeTransferProcessed, "ErrBridgeTransferProcessed"},
+	{actions.ErrInvalidBridgeSigners, "ErrInvalidBridgeSigners"},
+	{actions.ErrInsufficientSigners, "ErrInsufficientSigners"},
+	{actions.ErrInvalidBridgeSignature, "ErrInvalidBridgeSignature"},
+	{actions.ErrInsufficientBridgeFunds, "ErrInsufficientBridgeFunds"},
+	{storage.ErrInvalidBridgeAmount, "ErrInvalidBridgeAmount"},
+	{storage.ErrInvalidBridgeTransfer, "ErrInvalidBridgeTransfer"},
+	{actions.ErrNoMigration, "ErrNoMigration"},
+	{storage.ErrSchemaBehind, "ErrSchemaBehind"},
+}
+
+// resultError returns the error recorded, as a message, in the result of a
+// failed transaction. Actions return their errors either as is or wrapped as
+// "<err>: <details>", so the error of [msg] is the labelled error with the
+// message before the first ": ".
+func resultError(msg string) error {
+	text, details, wrapped := strings.Cut(msg, ": ")
+	for _, f := range failureLabels {
+		switch {
+		case f.err.Error() != text:
+		case wrapped:
+			return fmt.Errorf("%w: %s", f.err, details)
+		default:
+			return f.err
+		}
+	}
+	return errors.New(msg)
+}
+
+// failureLabel returns the label of a transaction that failed with [err].
+func failureLabel(err error) string {
+	for _, f := range failureLabels {
+		if errors.Is(err, f.err) {
+			return f.label
+		}
+	}
+	return metrics.OtherError
+}
+
+var _ event.Subscription[*chain.ExecutedBlock] = (*metricsSubscription)(nil)
+
+// metricsSubscription records the transfers, failures and account changes of
+// accepted blocks.
+//
+// Accounts are counted from the balances written by a block, as of the
+// state root it was built on and the root after it. If the state has
+// already moved past a block, the root after it is the one carried by the
+// next block, so its accounts are counted when the next block is accepted.
+type metricsSubscription struct {
+	log     logging.Logger
+	metrics *metrics.Metrics
+	bh      chain.BalanceHandler
+
+	// state is nil if the VM does not expose its state, in which case
+	// accounts are not counted.
+	state func() (storage.RootState, error)
+
+	// pending is the last accepted block if its accounts were not counted
+	// yet.
+	pending *pendingAccounts
+}
+
+type pendingAccounts struct {
+	height uint64
+	root   ids.ID
+	addrs  []codec.Address
+}
+
+func (m *metricsSubscription) Accept(blk *chain.ExecutedBlock) error {
+	for i, tx := range blk.Block.Txs {
+		result := blk.Results[i]
+		if !result.Success {
+			m.metrics.FailedTx(failureLabel(resultError(string(result.Error))))
+			continue
+		}
+		for _, action := range tx.Actions {
+			if transfer, ok := action.(*actions.Transfer); ok {
+				m.metrics.Transfer(transfer.Value, len(transfer.Memo))
+			}
+		}
+	}
+	if m.state == nil {
+		return nil
+	}
+	err := m.countAccounts(context.Background(), blk)
+	if errors.Is(err, merkledb.ErrInsufficientHistory) {
+		// The state history no longer holds the roots of the block.
+		m.log.Warn("skipping account metrics",
+			zap.Uint64("height", blk.Block.Hght),
+			zap.Error(err),
+		)
+		m.pending = nil
+		return nil
+	}
+	return err
+}
+
+func (m *metricsSubscription) countAccounts(ctx context.Context, blk *chain.ExecutedBlock) error {
+	s, err := m.state()
+	if err != nil {
+		return err
+	}
+	pending := m.pending
+	m.pending = nil
+	if pending != nil && pending.height+1 == blk.Block.Hght {
+		// [blk] carries the root of the state after the pending block.
+		if err := m.count(ctx, s, pending.root, blk.Block.StateRoot, pending.addrs); err != nil {
+			return err
+		}
+	}
+
+	written := set.Set[codec.Address]{}
+	for _, tx := range blk.Block.Txs {
+		stateKeys, err := tx.StateKeys(m.bh)
+		if err != nil {
+			return err
+		}
+		for k, perm := range stateKeys {
+			if !perm.Has(state.Write) {
+				continue
+			}
+			if addr, ok := storage.ParseBalanceKey([]byte(k)); ok {
+				written.Add(addr)
+			}
+		}
+	}
+	if written.Len() == 0 {
+		return nil
+	}
+	addrs := written.List()
+	root, ok, err := storage.RootAfter(ctx, s, blk.Block.Hght)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		m.pending = &pendingAccounts{height: blk.Block.Hght, root: blk.Block.StateRoot, addrs: addrs}
+		return nil
+	}
+	return m.count(ctx, s, blk.Block.StateRoot, root, addrs)
+}
+
+// count records the addresses of [addrs] that have a balance at [after] but
+// not at [before], and the reverse.
+func (m *metricsSubscription) count(ctx context.Context, s storage.RootState, before ids.ID, after ids.ID, addrs []codec.Address) error {
+	old, errs := storage.GetBalancesFromState(ctx, storage.ReadStateAtRoot(s, before), addrs)
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	balances, errs := storage.GetBalancesFromState(ctx, storage.ReadStateAtRoot(s, after), addrs)
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	created, removed := 0, 0
+	for i := range addrs {
+		switch {
+		case old[i] == 0 && balances[i] > 0:
+			created++
+		case old[i] > 0 && balances[i] == 0:
+			removed++
+		}
+	}
+	m.metrics.Accounts(created, removed)
+	return nil
+}
+
+func (*metricsSubscription) Close() error {
+	return nil
+}
+
+// ChainVM is the VM served to the node. The chain does not expose its
+// metrics to the VM options, so it registers the VM metrics with them, under
+// [consts.Name], when it is initialized.
+type ChainVM struct {
+	*vm.VM
+	registry *prometheus.Registry
+}
+
+// NewChainVM returns the VM returned by [New], serving the VM metrics.
+func NewChainVM(options ...vm.Option) (*ChainVM, error) {
+	registry := prometheus.NewRegistry()
+	v, err := newVM(registry, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainVM{VM: v, registry: registry}, nil
+}
+
+func (c *ChainVM) Initialize(
+	ctx context.Context,
+	snowCtx *snow.Context,
+	db database.Database,
+	genesisBytes []byte,
+	upgradeBytes []byte,
+	configBytes []byte,
+	toEngine chan<- common.Message,
+	fxs []*common.Fx,
+	appSender common.AppSender,
+) error {
+	if err := snowCtx.Metrics.Register(consts.Name, c.registry); err != nil {
+		return err
+	}
+	return c.VM.Initialize(ctx, snowCtx, db, genesisBytes, upgradeBytes, configBytes, toEngine, fxs, appSender)
+}
@@ -4,27 +4,220 @@
 package vm
 
 import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/hypersdk-starter-kit/apiguard"
+	"github.com/ava-labs/hypersdk-starter-kit/archive"
+	"github.com/ava-labs/hypersdk-starter-kit/balancefeed"
+	"github.com/ava-labs/hypersdk-starter-kit/blockstore"
+	"github.com/ava-labs/hypersdk-starter-kit/consts"
+	"github.com/ava-labs/hypersdk-starter-kit/history"
+	"github.com/ava-labs/hypersdk-starter-kit/invariant"
+	"github.com/ava-labs/hypersdk-starter-kit/metrics"
+	"github.com/ava-labs/hypersdk-starter-kit/richlist"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
 	"github.com/ava-labs/hypersdk/api"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/event"
 	"github.com/ava-labs/hypersdk/vm"
 )
 
 const Namespace = "controller"
 
+// The endpoints that are not JSON-RPC are enabled, in [Config.API], by the
+// method every request to them counts as. The REST routes count as the
+// JSON-RPC method they call.
+const (
+	GraphQLMethod     = consts.Name + ".graphql"
+	SchemaMethod      = consts.Name + ".schema"
+	BalanceFeedMethod = consts.Name + ".balanceFeed"
+)
+
+var (
+	ErrStateUnavailable = errors.New("vm does not expose its state")
+	ErrInvalidAPIConfig = errors.New("invalid api config")
+)
+
+// stateVM is implemented by VMs that expose their merkle state, which the
+// indexes that scan the whole state need.
+type stateVM interface {
+	State() (merkledb.MerkleDB, error)
+}
+
 type Config struct {
 	Enabled bool `json:"enabled"`
+
+	// API controls access to every endpoint of the VM: the enabled methods,
+	// rate limits, auth tokens and the size of requests. The rate limits are
+	// shared by the endpoints.
+	API apiguard.Config `json:"api"`
+
+	// History enables the per-address transaction history index served by
+	// the "history" method.
+	History bool `json:"history"`
+
+	// RichList enables the sorted balance index served by the "topHolders"
+	// and "distribution" methods.
+	RichList bool `json:"richList"`
+
+	// Archive records the balances changed by every block so that "balance"
+	// can be queried at a past height. Heights more than ArchiveRetention
+	// blocks old are pruned. A retention of 0 keeps every height.
+	Archive          bool   `json:"archive"`
+	ArchiveRetention uint64 `json:"archiveRetention"`
+
+	// Invariants re-executes every accepted block and logs the transactions
+	// that change the sum of balances by more than their fees and declared
+	// mints and burns, or write outside of the registered prefixes. It is a
+	// debug mode that doubles the execution cost of every block.
+	Invariants bool `json:"invariants"`
+
+	// BalanceFeed serves the WebSocket feed of balance changes at
+	// [BalanceFeedEndpoint].
+	BalanceFeed bool `json:"balanceFeed"`
+
+	// GraphQL serves accounts, blocks, transactions and the genesis at
+	// [GraphQLEndpoint].
+	GraphQL bool `json:"graphql"`
+
+	// Explorer serves the blocks and transactions, with their actions, auth
+	// and outputs decoded, with the "block", "blocks" and "transaction"
+	// methods.
+	Explorer bool `json:"explorer"`
+
+	// BlockRetention bounds the block store used by GraphQL and Explorer,
+	// which prunes heights more than BlockRetention blocks old. A retention
+	// of 0 keeps every height.
+	BlockRetention uint64 `json:"blockRetention"`
 }
 
 func NewDefaultConfig() Config {
 	return Config{
 		Enabled: true,
+		API:     apiguard.NewDefaultConfig(),
 	}
 }
 
-func With() vm.Option {
+// With returns the option serving the MorpheusVM APIs and indexes. The VM
+// metrics are registered with [registry].
+func With(registry prometheus.Registerer) vm.Option {
 	return vm.NewOption(Namespace, NewDefaultConfig(), func(v api.VM, config Config) (vm.Opt, error) {
 		if !config.Enabled {
 			return vm.NewOpt(), nil
 		}
-		return vm.WithVMAPIs(jsonRPCServerFactory{}), nil
+		if err := config.API.Verify(apiMethodNames()); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidAPIConfig, err)
+		}
+		m, err := metrics.New(registry)
+		if err != nil {
+			return nil, err
+		}
+		sv, ok := v.(stateVM)
+		if !ok && (config.RichList || config.Archive || config.Invariants || config.BalanceFeed) {
+			return nil, ErrStateUnavailable
+		}
+		metricsSub := &metricsSubscription{log: v.Logger(), metrics: m, bh: v.BalanceHandler()}
+		if ok {
+			metricsSub.state = func() (storage.RootState, error) { return sv.State() }
+		}
+		guard := apiguard.New(config.API, m.ObserveMethod)
+		factory := jsonRPCServerFactory{guard: guard}
+		opts := []vm.Opt{vm.WithBlockSubscriptions(subscriptionFactory{metricsSub})}
+		if config.History {
+			indexer, err := history.New(filepath.Join(v.GetDataDir(), history.Namespace), v)
+			if err != nil {
+				return nil, err
+			}
+			factory.history = indexer
+			opts = append(opts, vm.WithBlockSubscriptions(subscriptionFactory{indexer}))
+		}
+		if config.RichList {
+			index, err := richlist.New(
+				filepath.Join(v.GetDataDir(), richlist.Namespace),
+				func() (richlist.State, error) { return sv.State() },
+				v.BalanceHandler(),
+			)
+			if err != nil {
+				return nil, err
+			}
+			factory.richList = index
+			opts = append(opts, vm.WithBlockSubscriptions(index))
+		}
+		if config.Archive {
+			a, err := archive.New(
+				v.Logger(),
+				filepath.Join(v.GetDataDir(), archive.Namespace),
+				func() (archive.State, error) { return sv.State() },
+				config.ArchiveRetention,
+			)
+			if err != nil {
+				return nil, err
+			}
+			factory.archive = a
+			opts = append(opts, vm.WithBlockSubscriptions(subscriptionFactory{a}))
+		}
+		if config.Invariants {
+			monitor := invariant.NewMonitor(
+				v.Logger(),
+				func() (invariant.State, error) { return sv.State() },
+				v.BalanceHandler(),
+				v.Rules,
+			)
+			opts = append(opts, vm.WithBlockSubscriptions(subscriptionFactory{monitor}))
+		}
+		var blocks *blockstore.Store
+		if config.GraphQL || config.Explorer {
+			blocks, err = blockstore.New(
+				filepath.Join(v.GetDataDir(), blockstore.Namespace),
+				v,
+				config.BlockRetention,
+			)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, vm.WithBlockSubscriptions(subscriptionFactory{blocks}))
+		}
+		if config.Explorer {
+			factory.blocks = blocks
+		}
+		apis := append([]api.HandlerFactory[api.VM]{factory, schemaHandlerFactory{guard: guard}}, restHandlerFactories(factory)...)
+		if config.GraphQL {
+			apis = append(apis, graphQLFactory{history: factory.history, blocks: blocks, guard: guard})
+		}
+		if config.BalanceFeed {
+			feed := balancefeed.New(
+				v.Logger(),
+				func() (balancefeed.State, error) { return sv.State() },
+				v.BalanceHandler(),
+			)
+			opts = append(opts, vm.WithBlockSubscriptions(feed))
+			apis = append(apis, balanceFeedFactory{feed: feed, guard: guard})
+		}
+		return vm.NewOpt(append(opts, vm.WithVMAPIs(apis...))...), nil
 	})
 }
+
+// apiMethodNames returns the names of the methods the requests to the VM
+// APIs count as.
+func apiMethodNames() []string {
+	names := jsonRPCMethodNames()
+	for _, route := range restRoutes {
+		names = append(names, route.rpcMethod)
+	}
+	return append(names, GraphQLMethod, SchemaMethod, BalanceFeedMethod)
+}
+
+var _ event.SubscriptionFactory[*chain.ExecutedBlock] = (*subscriptionFactory)(nil)
+
+type subscriptionFactory struct {
+	subscription event.Subscription[*chain.ExecutedBlock]
+}
+
+func (s subscriptionFactory) New() (event.Subscription[*chain.ExecutedBlock], error) {
+	return s.subscription, nil
+}
@@ -0,0 +1,345 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/apiguard"
+	"github.com/ava-labs/hypersdk-starter-kit/consts"
+	"github.com/ava-labs/hypersdk-starter-kit/schema"
+	"github.com/ava-labs/hypersdk/api"
+	"github.com/ava-labs/hypersdk/api/jsonrpc"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+const (
+	RESTEndpoint = "/morpheusrest"
+
+	// OpenAPIPath is the path, under [RESTEndpoint], of the OpenAPI document
+	// describing the REST API.
+	OpenAPIPath = "/openapi.json"
+
+	// maxRESTBodySize bounds the body of POST requests.
+	maxRESTBodySize = 4 * 1024 * 1024
+)
+
+var ErrInvalidRequest = errors.New("invalid request")
+
+var (
+	_ api.HandlerFactory[api.VM] = (*restRouteFactory)(nil)
+	_ api.HandlerFactory[api.VM] = (*restDocumentFactory)(nil)
+)
+
+// RESTError is the body of every failed REST request.
+type RESTError struct {
+	Error string `json:"error"`
+}
+
+// restRoute is a REST route and its description in the OpenAPI document.
+type restRoute struct {
+	method  string
+	path    string
+	id      string
+	summary string
+	params  []*schema.Parameter
+
+	// rpcMethod is the JSON-RPC method the route calls. Requests to the
+	// route count as calls to it in [Config.API].
+	rpcMethod string
+
+	// request and response are values of the request body and response
+	// types. There is no request body if [request] is nil.
+	request  any
+	response any
+
+	// handle serves a request to the route. [vars] holds the value of every
+	// variable in [path].
+	handle func(s *restServer, r *http.Request, vars map[string]string) (any, error)
+}
+
+type restServer struct {
+	rpc  *JSONRPCServer
+	core *jsonrpc.JSONRPCServer
+}
+
+var addressParam = &schema.Parameter{
+	Name:     "address",
+	In:       "path",
+	Required: true,
+	Schema:   &schema.Schema{Type: "string"},
+}
+
+var restRoutes = []*restRoute{
+	{
+		method:    http.MethodGet,
+		path:      "/genesis",
+		id:        "getGenesis",
+		summary:   "Returns the genesis of the chain",
+		rpcMethod: consts.Name + ".genesis",
+		response:  &GenesisReply{},
+		handle: func(s *restServer, r *http.Request, _ map[string]string) (any, error) {
+			reply := &GenesisReply{}
+			return reply, s.rpc.Genesis(r, nil, reply)
+		},
+	},
+	{
+		method:    http.MethodGet,
+		path:      "/balances/{address}",
+		id:        "getBalance",
+		summary:   "Returns the balance of an address",
+		rpcMethod: consts.Name + ".balance",
+		params: []*schema.Parameter{
+			addressParam,
+			{
+				Name:        "height",
+				In:          "query",
+				Description: "height to read the balance at, if the balance archive is enabled",
+				Schema:      &schema.Schema{Type: "integer", Format: "int64"},
+			},
+		},
+		response: &BalanceReply{},
+		handle: func(s *restServer, r *http.Request, vars map[string]string) (any, error) {
+			args := &BalanceArgs{}
+			if err := pathAddress(vars, &args.Address); err != nil {
+				return nil, err
+			}
+			if raw := r.URL.Query().Get("height"); raw != "" {
+				height, err := strconv.ParseUint(raw, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid height: %w", ErrInvalidRequest, err)
+				}
+				args.Height = &height
+			}
+			reply := &BalanceReply{}
+			return reply, s.rpc.Balance(r, args, reply)
+		},
+	},
+	{
+		method:    http.MethodGet,
+		path:      "/accounts/{address}",
+		id:        "getAccount",
+		summary:   "Returns every record stored for an address",
+		rpcMethod: consts.Name + ".account",
+		params:    []*schema.Parameter{addressParam},
+		response:  &AccountReply{},
+		handle: func(s *restServer, r *http.Request, vars map[string]string) (any, error) {
+			args := &AccountArgs{}
+			if err := pathAddress(vars, &args.Address); err != nil {
+				return nil, err
+			}
+			reply := &AccountReply{}
+			return reply, s.rpc.Account(r, args, reply)
+		},
+	},
+	{
+		method:    http.MethodPost,
+		path:      "/simulate",
+		id:        "simulateActions",
+		summary:   "Executes actions against the current state without committing them",
+		rpcMethod: api.Name + ".simulateActions",
+		request:   &jsonrpc.SimulatActionsArgs{},
+		response:  &jsonrpc.SimulateActionsReply{},
+		handle: func(s *restServer, r *http.Request, _ map[string]string) (any, error) {
+			args := &jsonrpc.SimulatActionsArgs{}
+			if err := decodeBody(r, args); err != nil {
+				return nil, err
+			}
+			reply := &jsonrpc.SimulateActionsReply{}
+			return reply, s.core.SimulateActions(r, args, reply)
+		},
+	},
+	{
+		method:    http.MethodPost,
+		path:      "/txs",
+		id:        "submitTx",
+		summary:   "Submits a signed transaction",
+		rpcMethod: api.Name + ".submitTx",
+		request:   &jsonrpc.SubmitTxArgs{},
+		response:  &jsonrpc.SubmitTxReply{},
+		handle: func(s *restServer, r *http.Request, _ map[string]string) (any, error) {
+			args := &jsonrpc.SubmitTxArgs{}
+			if err := decodeBody(r, args); err != nil {
+				return nil, err
+			}
+			reply := &jsonrpc.SubmitTxReply{}
+			return reply, s.core.SubmitTx(r, args, reply)
+		},
+	},
+}
+
+// restHandlerFactories returns a factory for the handler of every REST route
+// and of the OpenAPI document. The node routes every request whose path
+// matches a handler path to it, but the VM runs as a plugin that serves a
+// copy of the request, without the variables the node's router matched. The
+// variables in a route's path, such as {address}, are read from the request
+// path instead.
+func restHandlerFactories(rpc jsonRPCServerFactory) []api.HandlerFactory[api.VM] {
+	factories := []api.HandlerFactory[api.VM]{restDocumentFactory{guard: rpc.guard}}
+	for _, route := range restRoutes {
+		factories = append(factories, restRouteFactory{rpc: rpc, route: route})
+	}
+	return factories
+}
+
+type restRouteFactory struct {
+	rpc   jsonRPCServerFactory
+	route *restRoute
+}
+
+func (f restRouteFactory) New(vm api.VM) (api.Handler, error) {
+	s := &restServer{
+		rpc:  f.rpc.server(vm),
+		core: jsonrpc.NewJSONRPCServer(vm),
+	}
+	route := f.route
+	return api.Handler{
+		Path: RESTEndpoint + route.path,
+		Handler: f.rpc.guard.Method(route.rpcMethod, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != route.method {
+				w.Header().Set("Allow", route.method)
+				writeJSON(w, http.StatusMethodNotAllowed, &RESTError{Error: "method not allowed"})
+				return
+			}
+			vars, ok := pathVars(route.path, r.URL.Path)
+			if !ok {
+				writeJSON(w, http.StatusNotFound, &RESTError{Error: "not found"})
+				return
+			}
+			reply, err := route.handle(s, r, vars)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, &RESTError{Error: err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, reply)
+		})),
+	}, nil
+}
+
+type restDocumentFactory struct {
+	guard *apiguard.Guard
+}
+
+func (f restDocumentFactory) New(vm api.VM) (api.Handler, error) {
+	doc, err := json.Marshal(restDocument(vm, restRoutes))
+	return api.Handler{
+		Path: RESTEndpoint + OpenAPIPath,
+		Handler: f.guard.Method(SchemaMethod, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(doc)
+		})),
+	}, err
+}
+
+// restDocument returns the OpenAPI document of [routes].
+func restDocument(vm api.VM, routes []*restRoute) *schema.OpenAPI {
+	d := newDocument(schema.Info{
+		Title:       consts.Name + " REST API",
+		Description: "REST gateway to the " + consts.Name + " JSON-RPC API.",
+		Version:     consts.Version.String(),
+	})
+	d.AddServer(&schema.Server{URL: "/ext/bc/" + vm.ChainID().String() + RESTEndpoint})
+	errorResponse := &schema.Response{
+		Description: "The request failed",
+		Content:     schema.JSON(d.Schema(&RESTError{})),
+	}
+	for _, route := range routes {
+		op := &schema.Operation{
+			OperationID: route.id,
+			Summary:     route.summary,
+			Parameters:  route.params,
+			Responses: map[string]*schema.Response{
+				"200": {
+					Description: "OK",
+					Content:     schema.JSON(d.Schema(route.response)),
+				},
+				"default": errorResponse,
+			},
+		}
+		if route.request != nil {
+			op.RequestBody = &schema.RequestBody{
+				Required: true,
+				Content:  schema.JSON(d.Schema(route.request)),
+			}
+		}
+		d.AddOperation(route.method, route.path, op)
+	}
+	return d.OpenAPI()
+}
+
+// newDocument returns an OpenAPI document that knows the JSON encoding of
+// the hypersdk types used by the API.
+func newDocument(info schema.Info) *schema.Document {
+	d := schema.NewDocument(info)
+	addOverrides(d.Generator)
+	return d
+}
+
+// addOverrides makes [g] use the JSON encoding of the hypersdk types that
+// have a custom one.
+func addOverrides(g *schema.Generator) {
+	g.Override(reflect.TypeOf(ids.ID{}), &schema.Schema{Type: "string"})
+	g.Override(reflect.TypeOf(fees.Dimensions{}), g.Schema(&fees.DimensionJSON{}))
+	g.Override(reflect.TypeOf(state.Keys{}), &schema.Schema{
+		Type:                 "object",
+		AdditionalProperties: &schema.Schema{Type: "string"},
+	})
+}
+
+// pathVars matches [pattern] against the end of [path], as the node serves
+// the API under a prefix such as /ext/bc/<chainID>. It returns the value of
+// every {variable} in [pattern], or false if [path] does not match.
+func pathVars(pattern string, path string) (map[string]string, bool) {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(got) < len(want) {
+		return nil, false
+	}
+	got = got[len(got)-len(want):]
+	vars := map[string]string{}
+	for i, segment := range want {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			vars[strings.TrimSuffix(name, "}")] = got[i]
+			continue
+		}
+		if segment != got[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+func pathAddress(vars map[string]string, addr *codec.Address) error {
+	parsed, err := codec.StringToAddress(vars["address"])
+	if err != nil {
+		return fmt.Errorf("%w: invalid address: %w", ErrInvalidRequest, err)
+	}
+	*addr = parsed
+	return nil
+}
+
+func decodeBody(r *http.Request, v any) error {
+	d := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRESTBodySize))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
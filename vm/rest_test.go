@@ -0,0 +1,234 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/trace"
+	"github.com/ava-labs/avalanchego/vms/rpcchainvm/ghttp"
+	"github.com/ava-labs/avalanchego/vms/rpcchainvm/grpcutils"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk-starter-kit/apiguard"
+	"github.com/ava-labs/hypersdk-starter-kit/storage"
+	"github.com/ava-labs/hypersdk/api"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/genesis"
+
+	httppb "github.com/ava-labs/avalanchego/proto/pb/http"
+)
+
+// testVM serves the parts of [api.VM] the VM APIs read. Calling any other
+// method panics.
+type testVM struct {
+	api.VM
+
+	chainID ids.ID
+	genesis *Genesis
+	store   *chaintest.InMemoryStore
+}
+
+func newTestVM() *testVM {
+	return &testVM{
+		chainID: ids.GenerateTestID(),
+		genesis: &Genesis{DefaultGenesis: genesis.NewDefaultGenesis(nil)},
+		store:   chaintest.NewInMemoryStore(),
+	}
+}
+
+func (vm *testVM) ChainID() ids.ID {
+	return vm.chainID
+}
+
+func (vm *testVM) Genesis() genesis.Genesis {
+	return vm.genesis
+}
+
+func (*testVM) Tracer() trace.Tracer {
+	return trace.Noop
+}
+
+func (vm *testVM) ReadState(ctx context.Context, keys [][]byte) ([][]byte, []error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		values[i], errs[i] = vm.store.GetValue(ctx, key)
+	}
+	return values, errs
+}
+
+// servePlugin serves the handlers of [factories] the way a node serves the
+// APIs of a VM plugin: the node routes each request by the handler path and
+// forwards it over gRPC to the plugin, which serves a copy of it. It returns
+// the URL of the chain's APIs.
+func servePlugin(t *testing.T, vm *testVM, factories []api.HandlerFactory[api.VM]) string {
+	require := require.New(t)
+
+	router := mux.NewRouter()
+	base := "/ext/bc/" + vm.chainID.String()
+	for _, factory := range factories {
+		handler, err := factory.New(vm)
+		require.NoError(err)
+
+		listener, err := grpcutils.NewListener()
+		require.NoError(err)
+		server := grpcutils.NewServer()
+		httppb.RegisterHTTPServer(server, ghttp.NewServer(handler.Handler))
+		go grpcutils.Serve(listener, server)
+		t.Cleanup(server.Stop)
+
+		conn, err := grpcutils.Dial(listener.Addr().String())
+		require.NoError(err)
+		t.Cleanup(func() { _ = conn.Close() })
+		router.Handle(base+handler.Path, ghttp.NewClient(httppb.NewHTTPClient(conn)))
+	}
+	node := httptest.NewServer(router)
+	t.Cleanup(node.Close)
+	return node.URL + base
+}
+
+func TestRESTHandlers(t *testing.T) {
+	vm := newTestVM()
+	addr := codec.CreateAddress(auth.ED25519ID, ids.GenerateTestID())
+	unknown := codec.CreateAddress(auth.ED25519ID, ids.GenerateTestID())
+	require.NoError(t, storage.SetBalance(context.Background(), vm.store, addr, 5))
+
+	rpc := jsonRPCServerFactory{guard: apiguard.New(apiguard.NewDefaultConfig(), nil)}
+	url := servePlugin(t, vm, restHandlerFactories(rpc)) + RESTEndpoint
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		// expected is the body of the reply if [expectedStatus] is
+		// [http.StatusOK].
+		expected any
+	}{
+		{
+			name:           "Genesis",
+			method:         http.MethodGet,
+			path:           "/genesis",
+			expectedStatus: http.StatusOK,
+			expected:       &GenesisReply{Genesis: vm.genesis},
+		},
+		{
+			name:           "Balance",
+			method:         http.MethodGet,
+			path:           "/balances/" + addr.String(),
+			expectedStatus: http.StatusOK,
+			expected:       &BalanceReply{Amount: 5},
+		},
+		{
+			name:           "UnknownBalance",
+			method:         http.MethodGet,
+			path:           "/balances/" + unknown.String(),
+			expectedStatus: http.StatusOK,
+			expected:       &BalanceReply{},
+		},
+		{
+			name:           "InvalidAddress",
+			method:         http.MethodGet,
+			path:           "/balances/invalid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Account",
+			method:         http.MethodGet,
+			path:           "/accounts/" + addr.String(),
+			expectedStatus: http.StatusOK,
+			expected: &AccountReply{
+				Account:  storage.Account{Exists: true, Balance: 5},
+				AuthType: authTypes[auth.ED25519ID],
+			},
+		},
+		{
+			name:           "MethodNotAllowed",
+			method:         http.MethodPost,
+			path:           "/accounts/" + addr.String(),
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			req, err := http.NewRequestWithContext(context.Background(), tt.method, url+tt.path, strings.NewReader(""))
+			require.NoError(err)
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(err)
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			require.NoError(err)
+			require.Equal(tt.expectedStatus, res.StatusCode, string(body))
+			if tt.expectedStatus != http.StatusOK {
+				var reply RESTError
+				require.NoError(json.Unmarshal(body, &reply))
+				require.NotEmpty(reply.Error)
+				return
+			}
+
+			expected, err := json.Marshal(tt.expected)
+			require.NoError(err)
+			require.JSONEq(string(expected), string(body))
+		})
+	}
+}
+
+func TestPathVars(t *testing.T) {
+	tests := []struct {
+		name         string
+		pattern      string
+		path         string
+		expectedOK   bool
+		expectedVars map[string]string
+	}{
+		{
+			name:         "Static",
+			pattern:      "/genesis",
+			path:         "/ext/bc/chain/morpheusrest/genesis",
+			expectedOK:   true,
+			expectedVars: map[string]string{},
+		},
+		{
+			name:         "Variable",
+			pattern:      "/balances/{address}",
+			path:         "/ext/bc/chain/morpheusrest/balances/addr",
+			expectedOK:   true,
+			expectedVars: map[string]string{"address": "addr"},
+		},
+		{
+			name:       "Mismatch",
+			pattern:    "/balances/{address}",
+			path:       "/ext/bc/chain/morpheusrest/accounts/addr",
+			expectedOK: false,
+		},
+		{
+			name:       "TooShort",
+			pattern:    "/balances/{address}",
+			path:       "/addr",
+			expectedOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			vars, ok := pathVars(tt.pattern, tt.path)
+			require.Equal(tt.expectedOK, ok)
+			require.Equal(tt.expectedVars, vars)
+		})
+	}
+}
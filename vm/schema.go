@@ -0,0 +1,195 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ava-labs/hypersdk-starter-kit/apiguard"
+	"github.com/ava-labs/hypersdk-starter-kit/consts"
+	"github.com/ava-labs/hypersdk-starter-kit/schema"
+	"github.com/ava-labs/hypersdk/api"
+	"github.com/ava-labs/hypersdk/codec"
+)
+
+const (
+	// SchemaEndpoint serves the JSON Schema of the VM, or its OpenAPI
+	// document with ?format=openapi.
+	SchemaEndpoint = "/morpheusschema"
+
+	FormatJSONSchema = "jsonschema"
+	FormatOpenAPI    = "openapi"
+
+	// ActionDefinition and OutputDefinition are the names of the definitions
+	// accepting any registered action and output.
+	ActionDefinition = "Action"
+	OutputDefinition = "Output"
+)
+
+var ErrUnknownFormat = errors.New("unknown schema format")
+
+var (
+	_ api.HandlerFactory[api.VM] = (*schemaHandlerFactory)(nil)
+
+	typeOfRequest = reflect.TypeOf((*http.Request)(nil))
+	typeOfError   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// jsonRPCMethod is a method of [JSONRPCServer] served over JSON-RPC.
+type jsonRPCMethod struct {
+	name  string
+	args  reflect.Type
+	reply reflect.Type
+}
+
+// jsonRPCMethods returns the methods of [JSONRPCServer] the JSON-RPC handler
+// serves, by the name clients call them with.
+func jsonRPCMethods() []*jsonRPCMethod {
+	t := reflect.TypeOf((*JSONRPCServer)(nil))
+	methods := make([]*jsonRPCMethod, 0, t.NumMethod())
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i).Type
+		if m.NumIn() != 4 || m.In(1) != typeOfRequest ||
+			m.In(2).Kind() != reflect.Pointer || m.In(3).Kind() != reflect.Pointer ||
+			m.NumOut() != 1 || m.Out(0) != typeOfError {
+			continue
+		}
+		// Method names start with a lowercase letter.
+		name := t.Method(i).Name
+		first, size := utf8.DecodeRuneInString(name)
+		methods = append(methods, &jsonRPCMethod{
+			name:  consts.Name + "." + string(unicode.ToLower(first)) + name[size:],
+			args:  m.In(2).Elem(),
+			reply: m.In(3).Elem(),
+		})
+	}
+	return methods
+}
+
+// jsonRPCMethodNames returns the names of the methods served at
+// [JSONRPCEndpoint].
+func jsonRPCMethodNames() []string {
+	methods := jsonRPCMethods()
+	names := make([]string, len(methods))
+	for i, m := range methods {
+		names[i] = m.name
+	}
+	return names
+}
+
+// addTypes defines the registered actions and outputs in [g], and a
+// definition accepting any of them.
+func addTypes(g *schema.Generator) {
+	addRegistered(g, ActionDefinition, ActionParser.GetRegisteredTypes())
+	addRegistered(g, OutputDefinition, OutputParser.GetRegisteredTypes())
+}
+
+func addRegistered(g *schema.Generator, name string, types []codec.Typed) {
+	def := &schema.Schema{}
+	for _, typed := range types {
+		t := reflect.TypeOf(typed)
+		ref := g.TypeSchema(t)
+		if typeDef, ok := g.Definitions()[schema.Name(t.Elem())]; ok {
+			typeID := typed.GetTypeID()
+			typeDef.Title = t.Elem().Name()
+			typeDef.TypeID = &typeID
+		}
+		def.OneOf = append(def.OneOf, ref)
+	}
+	g.Definitions()[name] = def
+}
+
+// JSONSchema returns a JSON Schema defining the registered actions and
+// outputs and the arguments and replies of every JSON-RPC method.
+func JSONSchema() *schema.JSONSchema {
+	g := schema.NewGenerator(schema.DefsPrefix)
+	addOverrides(g)
+	addTypes(g)
+	for _, m := range jsonRPCMethods() {
+		g.TypeSchema(m.args)
+		g.TypeSchema(m.reply)
+	}
+	doc := schema.NewJSONSchema(consts.Name, g)
+	doc.Description = "Actions, outputs and JSON-RPC messages of " + consts.Name + "."
+	return doc
+}
+
+// OpenAPI returns an OpenAPI document of the JSON-RPC API. Every method is
+// a POST operation on [JSONRPCEndpoint], told apart by a fragment holding
+// its name. The registered actions and outputs are in the components.
+func OpenAPI() *schema.OpenAPI {
+	d := newDocument(schema.Info{
+		Title:       consts.Name + " JSON-RPC API",
+		Description: "JSON-RPC API of " + consts.Name + ".",
+		Version:     consts.Version.String(),
+	})
+	addTypes(d.Generator)
+	for _, m := range jsonRPCMethods() {
+		d.AddOperation(http.MethodPost, JSONRPCEndpoint+"#"+m.name, &schema.Operation{
+			OperationID: m.name,
+			RequestBody: &schema.RequestBody{
+				Required: true,
+				Content:  schema.JSON(schema.JSONRPCRequest(m.name, d.TypeSchema(m.args))),
+			},
+			Responses: map[string]*schema.Response{
+				"200": {
+					Description: "OK",
+					Content:     schema.JSON(schema.JSONRPCResponse(d.TypeSchema(m.reply))),
+				},
+			},
+		})
+	}
+	return d.OpenAPI()
+}
+
+// Schema returns the document of [format].
+func Schema(format string) (any, error) {
+	switch format {
+	case FormatJSONSchema:
+		return JSONSchema(), nil
+	case FormatOpenAPI:
+		return OpenAPI(), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type schemaHandlerFactory struct {
+	guard *apiguard.Guard
+}
+
+func (f schemaHandlerFactory) New(vm api.VM) (api.Handler, error) {
+	jsonSchema, err := json.Marshal(JSONSchema())
+	if err != nil {
+		return api.Handler{}, err
+	}
+	doc := OpenAPI()
+	doc.Servers = []*schema.Server{{URL: "/ext/bc/" + vm.ChainID().String()}}
+	openAPI, err := json.Marshal(doc)
+	if err != nil {
+		return api.Handler{}, err
+	}
+	return api.Handler{
+		Path: SchemaEndpoint,
+		Handler: f.guard.Method(SchemaMethod, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body []byte
+			switch format := r.URL.Query().Get("format"); format {
+			case "", FormatJSONSchema:
+				body = jsonSchema
+			case FormatOpenAPI:
+				body = openAPI
+			default:
+				writeJSON(w, http.StatusBadRequest, &RESTError{Error: ErrUnknownFormat.Error()})
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+		})),
+	}, nil
+}
@@ -4,31 +4,79 @@
 package vm
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ava-labs/hypersdk-starter-kit/apiguard"
+	"github.com/ava-labs/hypersdk-starter-kit/archive"
+	"github.com/ava-labs/hypersdk-starter-kit/blockstore"
 	"github.com/ava-labs/hypersdk-starter-kit/consts"
+	"github.com/ava-labs/hypersdk-starter-kit/history"
+	"github.com/ava-labs/hypersdk-starter-kit/richlist"
 	"github.com/ava-labs/hypersdk-starter-kit/storage"
 	"github.com/ava-labs/hypersdk/api"
 	"github.com/ava-labs/hypersdk/codec"
-	"github.com/ava-labs/hypersdk/genesis"
 )
 
-const JSONRPCEndpoint = "/morpheusapi"
+const (
+	JSONRPCEndpoint = "/morpheusapi"
+
+	// MaxSubscriptionsLimit bounds the page size of "payeeSubscriptions".
+	MaxSubscriptionsLimit = 100
+)
+
+var (
+	ErrHistoryDisabled  = errors.New("history index is disabled")
+	ErrRichListDisabled = errors.New("rich list index is disabled")
+	ErrArchiveDisabled  = errors.New("balance archive is disabled")
+	ErrTooManyAddresses = errors.New("too many addresses")
+)
 
 var _ api.HandlerFactory[api.VM] = (*jsonRPCServerFactory)(nil)
 
-type jsonRPCServerFactory struct{}
+type jsonRPCServerFactory struct {
+	// guard is enforced on every request to the VM APIs.
+	guard    *apiguard.Guard
+	history  *history.Indexer
+	richList *richlist.Index
+	archive  *archive.Archive
+	blocks   *blockstore.Store
+}
 
-func (jsonRPCServerFactory) New(vm api.VM) (api.Handler, error) {
-	handler, err := api.NewJSONRPCHandler(consts.Name, NewJSONRPCServer(vm))
+func (f jsonRPCServerFactory) New(vm api.VM) (api.Handler, error) {
+	handler, err := api.NewJSONRPCHandler(consts.Name, f.server(vm))
+	if err != nil {
+		return api.Handler{}, err
+	}
 	return api.Handler{
 		Path:    JSONRPCEndpoint,
-		Handler: handler,
-	}, err
+		Handler: f.guard.JSONRPC(handler),
+	}, nil
+}
+
+// server returns a server backed by the enabled indexes.
+func (f jsonRPCServerFactory) server(vm api.VM) *JSONRPCServer {
+	server := NewJSONRPCServer(vm)
+	server.history = f.history
+	server.richList = f.richList
+	server.archive = f.archive
+	server.blocks = f.blocks
+	return server
 }
 
 type JSONRPCServer struct {
 	vm api.VM
+
+	// history, richList, archive and blocks are nil unless their index is
+	// enabled.
+	history  *history.Indexer
+	richList *richlist.Index
+	archive  *archive.Archive
+	blocks   *blockstore.Store
 }
 
 func NewJSONRPCServer(vm api.VM) *JSONRPCServer {
@@ -36,16 +84,20 @@ func NewJSONRPCServer(vm api.VM) *JSONRPCServer {
 }
 
 type GenesisReply struct {
-	Genesis *genesis.DefaultGenesis `json:"genesis"`
+	Genesis *Genesis `json:"genesis"`
 }
 
 func (j *JSONRPCServer) Genesis(_ *http.Request, _ *struct{}, reply *GenesisReply) (err error) {
-	reply.Genesis = j.vm.Genesis().(*genesis.DefaultGenesis)
+	reply.Genesis = j.vm.Genesis().(*Genesis)
 	return nil
 }
 
 type BalanceArgs struct {
 	Address codec.Address `json:"address"`
+
+	// Height, if set, is the height to read the balance at. The node must
+	// run with the balance archive enabled.
+	Height *uint64 `json:"height,omitempty"`
 }
 
 type BalanceReply struct {
@@ -56,6 +108,17 @@ func (j *JSONRPCServer) Balance(req *http.Request, args *BalanceArgs, reply *Bal
 	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Balance")
 	defer span.End()
 
+	if args.Height != nil {
+		if j.archive == nil {
+			return ErrArchiveDisabled
+		}
+		balance, err := j.archive.Balance(args.Address, *args.Height)
+		if err != nil {
+			return err
+		}
+		reply.Amount = balance
+		return nil
+	}
 	balance, err := storage.GetBalanceFromState(ctx, j.vm.ReadState, args.Address)
 	if err != nil {
 		return err
@@ -63,3 +126,295 @@ func (j *JSONRPCServer) Balance(req *http.Request, args *BalanceArgs, reply *Bal
 	reply.Amount = balance
 	return err
 }
+
+// MaxBalancesAddresses is the maximum number of addresses a single
+// "balances" call can look up.
+const MaxBalancesAddresses = 1024
+
+type BalancesArgs struct {
+	Addresses []codec.Address `json:"addresses"`
+}
+
+// BalanceResult is the balance of a single address. If it could not be
+// read, [Error] is set.
+type BalanceResult struct {
+	Address codec.Address `json:"address"`
+	Amount  uint64        `json:"amount"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type BalancesReply struct {
+	Balances []*BalanceResult `json:"balances"`
+}
+
+// Balances returns the balances of [args.Addresses], in order, read from
+// state in a single call.
+func (j *JSONRPCServer) Balances(req *http.Request, args *BalancesArgs, reply *BalancesReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Balances")
+	defer span.End()
+
+	if len(args.Addresses) > MaxBalancesAddresses {
+		return fmt.Errorf("%w: %d > %d", ErrTooManyAddresses, len(args.Addresses), MaxBalancesAddresses)
+	}
+	balances, errs := storage.GetBalancesFromState(ctx, j.vm.ReadState, args.Addresses)
+	reply.Balances = make([]*BalanceResult, len(args.Addresses))
+	for i, addr := range args.Addresses {
+		result := &BalanceResult{
+			Address: addr,
+			Amount:  balances[i],
+		}
+		if errs[i] != nil {
+			result.Error = errs[i].Error()
+		}
+		reply.Balances[i] = result
+	}
+	return nil
+}
+
+type AccountArgs struct {
+	Address codec.Address `json:"address"`
+}
+
+type AccountReply struct {
+	storage.Account
+
+	// AuthType is the auth type that derives the address, or empty if the
+	// VM does not accept any.
+	AuthType string `json:"authType"`
+}
+
+// Account returns every record stored for [args.Address].
+func (j *JSONRPCServer) Account(req *http.Request, args *AccountArgs, reply *AccountReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Account")
+	defer span.End()
+
+	account, err := storage.GetAccountFromState(ctx, j.vm.ReadState, args.Address, time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+	reply.Account = *account
+	reply.AuthType, _ = AuthType(args.Address)
+	return nil
+}
+
+type SubscriptionsArgs struct {
+	Address codec.Address `json:"address"`
+
+	// Cursor is the [SubscriptionsReply.Next] of the previous page, and Limit
+	// the size of the page, up to [MaxSubscriptionsLimit]. They only apply to
+	// "payeeSubscriptions", as a payer has at most
+	// [storage.MaxSubscriptionsPerAccount] subscriptions.
+	Cursor ids.ID `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
+
+type SubscriptionReply struct {
+	ID ids.ID `json:"id"`
+	storage.Subscription
+}
+
+type SubscriptionsReply struct {
+	Subscriptions []*SubscriptionReply `json:"subscriptions"`
+
+	// Next is the cursor of the next page. It is empty after the last page.
+	Next ids.ID `json:"next"`
+}
+
+// PayerSubscriptions returns the subscriptions paid by [args.Address].
+func (j *JSONRPCServer) PayerSubscriptions(req *http.Request, args *SubscriptionsArgs, reply *SubscriptionsReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.PayerSubscriptions")
+	defer span.End()
+
+	subscriptionIDs, subscriptions, err := storage.GetPayerSubscriptionsFromState(ctx, j.vm.ReadState, args.Address)
+	if err != nil {
+		return err
+	}
+	reply.Subscriptions = newSubscriptionReplies(subscriptionIDs, subscriptions)
+	return nil
+}
+
+// PayeeSubscriptions returns a page of the subscriptions that [args.Address]
+// can charge, in ID order.
+func (j *JSONRPCServer) PayeeSubscriptions(req *http.Request, args *SubscriptionsArgs, reply *SubscriptionsReply) error {
+	_, span := j.vm.Tracer().Start(req.Context(), "Server.PayeeSubscriptions")
+	defer span.End()
+
+	limit := args.Limit
+	if limit == 0 {
+		limit = MaxSubscriptionsLimit
+	}
+	if limit < 0 || limit > MaxSubscriptionsLimit {
+		return fmt.Errorf("%w: limit %d", ErrInvalidRequest, args.Limit)
+	}
+	sv, ok := j.vm.(stateVM)
+	if !ok {
+		return ErrStateUnavailable
+	}
+	db, err := sv.State()
+	if err != nil {
+		return err
+	}
+	subscriptionIDs, subscriptions, next, err := storage.GetPayeeSubscriptions(db, args.Address, args.Cursor, limit)
+	if err != nil {
+		return err
+	}
+	reply.Subscriptions = newSubscriptionReplies(subscriptionIDs, subscriptions)
+	reply.Next = next
+	return nil
+}
+
+func newSubscriptionReplies(subscriptionIDs []ids.ID, subscriptions []*storage.Subscription) []*SubscriptionReply {
+	replies := make([]*SubscriptionReply, len(subscriptionIDs))
+	for i, id := range subscriptionIDs {
+		replies[i] = &SubscriptionReply{
+			ID:           id,
+			Subscription: *subscriptions[i],
+		}
+	}
+	return replies
+}
+
+type BridgeArgs struct {
+	ChainID ids.ID `json:"chainID"`
+}
+
+type BridgeReply struct {
+	Config *storage.BridgeConfig `json:"config"`
+	Locked uint64                `json:"locked"`
+	Minted uint64                `json:"minted"`
+}
+
+// Bridge returns the relayer configuration along with the amount escrowed
+// for, and minted from, [args.ChainID].
+func (j *JSONRPCServer) Bridge(req *http.Request, args *BridgeArgs, reply *BridgeReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Bridge")
+	defer span.End()
+
+	config, _, err := storage.GetBridgeConfigFromState(ctx, j.vm.ReadState)
+	if err != nil {
+		return err
+	}
+	locked, err := storage.GetBridgeEscrowFromState(ctx, j.vm.ReadState, args.ChainID)
+	if err != nil {
+		return err
+	}
+	minted, err := storage.GetBridgeMintedFromState(ctx, j.vm.ReadState, args.ChainID)
+	if err != nil {
+		return err
+	}
+	reply.Config = config
+	reply.Locked = locked
+	reply.Minted = minted
+	return nil
+}
+
+type BridgeTransferArgs struct {
+	SourceChainID ids.ID `json:"sourceChainID"`
+	TransferID    ids.ID `json:"transferID"`
+}
+
+type BridgeTransferReply struct {
+	Processed   bool  `json:"processed"`
+	ProcessedAt int64 `json:"processedAt"`
+}
+
+// BridgeTransfer reports whether a transfer from another chain has already
+// been minted or unlocked on this chain.
+func (j *JSONRPCServer) BridgeTransfer(req *http.Request, args *BridgeTransferArgs, reply *BridgeTransferReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.BridgeTransfer")
+	defer span.End()
+
+	processedAt, processed, err := storage.GetBridgeTransferFromState(ctx, j.vm.ReadState, args.SourceChainID, args.TransferID)
+	if err != nil {
+		return err
+	}
+	reply.Processed = processed
+	reply.ProcessedAt = processedAt
+	return nil
+}
+
+type HistoryArgs struct {
+	Address codec.Address `json:"address"`
+
+	// Cursor is the [HistoryReply.Next] of the previous page. It is empty
+	// for the first page.
+	Cursor codec.Bytes `json:"cursor"`
+	Limit  int         `json:"limit"`
+}
+
+type HistoryReply struct {
+	Entries []*history.Entry `json:"entries"`
+	Next    codec.Bytes      `json:"next"`
+
+	// Height is the height of the last indexed block.
+	Height uint64 `json:"height"`
+}
+
+// History returns the transactions that touched [args.Address], newest first.
+// The node must run with the history index enabled.
+func (j *JSONRPCServer) History(req *http.Request, args *HistoryArgs, reply *HistoryReply) error {
+	_, span := j.vm.Tracer().Start(req.Context(), "Server.History")
+	defer span.End()
+
+	if j.history == nil {
+		return ErrHistoryDisabled
+	}
+	height := j.history.Height()
+	entries, next, err := j.history.History(args.Address, args.Cursor, args.Limit)
+	if err != nil {
+		return err
+	}
+	reply.Entries = entries
+	reply.Next = next
+	reply.Height = height
+	return nil
+}
+
+type TopHoldersArgs struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type TopHoldersReply struct {
+	Holders []*richlist.Holder `json:"holders"`
+
+	// Total is the number of addresses with a non-zero balance.
+	Total uint64 `json:"total"`
+}
+
+// TopHolders returns the addresses with the highest balances. The node must
+// run with the rich list index enabled.
+func (j *JSONRPCServer) TopHolders(req *http.Request, args *TopHoldersArgs, reply *TopHoldersReply) error {
+	_, span := j.vm.Tracer().Start(req.Context(), "Server.TopHolders")
+	defer span.End()
+
+	if j.richList == nil {
+		return ErrRichListDisabled
+	}
+	holders, err := j.richList.TopHolders(args.Limit, args.Offset)
+	if err != nil {
+		return err
+	}
+	reply.Holders = holders
+	reply.Total = j.richList.Holders()
+	return nil
+}
+
+type DistributionReply struct {
+	Buckets []*richlist.Bucket `json:"buckets"`
+	Total   uint64             `json:"total"`
+}
+
+// Distribution returns the number of holders by order of magnitude of their
+// balance. The node must run with the rich list index enabled.
+func (j *JSONRPCServer) Distribution(req *http.Request, _ *struct{}, reply *DistributionReply) error {
+	_, span := j.vm.Tracer().Start(req.Context(), "Server.Distribution")
+	defer span.End()
+
+	if j.richList == nil {
+		return ErrRichListDisabled
+	}
+	reply.Buckets = j.richList.Distribution()
+	reply.Total = j.richList.Holders()
+	return nil
+}
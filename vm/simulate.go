@@ -0,0 +1,220 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/state/tstate"
+)
+
+var (
+	ErrNoActions        = errors.New("no actions")
+	ErrTooManyActions   = errors.New("too many actions")
+	ErrUnknownAuthType  = errors.New("unknown auth type")
+	ErrAuthTypeMismatch = errors.New("auth type does not derive actor")
+	ErrActionNotActive  = errors.New("action is not active")
+	errActionExtraBytes = errors.New("action has extra bytes")
+)
+
+type SimulateArgs struct {
+	// Actions are the actions of the transaction, each marshalled with its
+	// type ID.
+	Actions []codec.Bytes `json:"actions"`
+	Actor   codec.Address `json:"actor"`
+
+	// AuthType is the auth type that will sign the transaction, such as
+	// "ed25519". If it is empty, it is the auth type that derives [Actor].
+	AuthType string `json:"authType"`
+}
+
+// SimulateActionResult is the result of an executed action. [Output] is
+// empty if the action failed with [Error].
+type SimulateActionResult struct {
+	Output codec.Bytes `json:"output"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type SimulateReply struct {
+	// Success is true if the transaction would pay its fee and every action
+	// would succeed.
+	Success bool `json:"success"`
+
+	// Error is set if the actions would not run, as when the actor cannot
+	// pay the fee.
+	Error string `json:"error,omitempty"`
+
+	// Results holds the result of every executed action, in order. Actions
+	// after the first failure do not run.
+	Results []*SimulateActionResult `json:"results"`
+
+	// Units are the units a transaction of the actions signed by the auth
+	// type consumes, and Fee their price at UnitPrices.
+	Units      fees.Dimensions `json:"units"`
+	UnitPrices fees.Dimensions `json:"unitPrices"`
+	Fee        uint64          `json:"fee"`
+}
+
+// Simulate executes [args.Actions] on behalf of [args.Actor] against the
+// current state without committing them, as a transaction signed with
+// [args.AuthType] would, and returns their outputs, the units they consume
+// and the fee they cost at the current prices.
+func (j *JSONRPCServer) Simulate(req *http.Request, args *SimulateArgs, reply *SimulateReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Simulate")
+	defer span.End()
+
+	now := time.Now().UnixMilli()
+	r := j.vm.Rules(now)
+	actions, err := parseActions(args.Actions)
+	if err != nil {
+		return err
+	}
+	if len(actions) > int(r.GetMaxActionsPerTx()) {
+		return fmt.Errorf("%w: %d > %d", ErrTooManyActions, len(actions), r.GetMaxActionsPerTx())
+	}
+	auth, err := simulationAuth(args.Actor, args.AuthType)
+	if err != nil {
+		return err
+	}
+
+	// Units depend on the size of the signed transaction and on the keys it
+	// touches, so they are computed on a transaction signed with an auth
+	// of the same size and actor.
+	base := &chain.Base{ChainID: j.vm.ChainID()}
+	tx, err := chain.NewTxData(base, actions).Sign(&simulationAuthFactory{auth})
+	if err != nil {
+		return err
+	}
+	bh := j.vm.BalanceHandler()
+	reply.Units, err = tx.Units(bh, r)
+	if err != nil {
+		return err
+	}
+	reply.UnitPrices, err = j.vm.UnitPrices(ctx)
+	if err != nil {
+		return err
+	}
+	reply.Fee, err = fees.MulSum(reply.UnitPrices, reply.Units)
+	if err != nil {
+		return err
+	}
+
+	for i, action := range actions {
+		start, end := action.ValidRange(r)
+		if (start >= 0 && now < start) || (end >= 0 && now > end) {
+			reply.Error = fmt.Sprintf("%s: action %d", ErrActionNotActive, i)
+			return nil
+		}
+	}
+	im, err := j.vm.ImmutableState(ctx)
+	if err != nil {
+		return err
+	}
+	ts := tstate.NewRecorder(im)
+	if err := bh.Deduct(ctx, args.Actor, ts, reply.Fee); err != nil {
+		reply.Error = err.Error()
+		return nil
+	}
+	for i, action := range actions {
+		output, err := action.Execute(ctx, r, ts, now, args.Actor, chain.CreateActionID(tx.ID(), uint8(i)))
+		if err != nil {
+			reply.Results = append(reply.Results, &SimulateActionResult{Output: []byte{}, Error: err.Error()})
+			return nil
+		}
+		result := &SimulateActionResult{Output: []byte{}}
+		if output != nil {
+			result.Output, err = chain.MarshalTyped(output)
+			if err != nil {
+				return err
+			}
+		}
+		reply.Results = append(reply.Results, result)
+	}
+	reply.Success = true
+	return nil
+}
+
+func parseActions(actionBytes []codec.Bytes) (chain.Actions, error) {
+	if len(actionBytes) == 0 {
+		return nil, ErrNoActions
+	}
+	actions := make(chain.Actions, len(actionBytes))
+	for i, b := range actionBytes {
+		r := codec.NewReader(b, len(b))
+		action, err := ActionParser.Unmarshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("action %d: %w", i, err)
+		}
+		if !r.Empty() {
+			return nil, fmt.Errorf("action %d: %w", i, errActionExtraBytes)
+		}
+		actions[i] = action
+	}
+	return actions, nil
+}
+
+// simulationAuth returns an unsigned auth of [authType] acting for [actor].
+func simulationAuth(actor codec.Address, authType string) (*simulatedAuth, error) {
+	actorType, ok := AuthType(actor)
+	switch {
+	case authType == "" && !ok:
+		return nil, fmt.Errorf("%w: no auth type derives %s", ErrUnknownAuthType, actor)
+	case authType == "":
+		authType = actorType
+	case authType != actorType:
+		return nil, fmt.Errorf("%w: %s", ErrAuthTypeMismatch, authType)
+	}
+	for _, typ := range AuthParser.GetRegisteredTypes() {
+		if name, ok := authTypes[typ.GetTypeID()]; ok && name == authType {
+			return &simulatedAuth{Auth: typ.(chain.Auth), actor: actor}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownAuthType, authType)
+}
+
+var (
+	_ chain.Auth        = (*simulatedAuth)(nil)
+	_ chain.AuthFactory = (*simulationAuthFactory)(nil)
+)
+
+// simulatedAuth has the type, size and compute units of [Auth], but no
+// signature, and acts and pays for [actor].
+type simulatedAuth struct {
+	chain.Auth
+	actor codec.Address
+}
+
+func (a *simulatedAuth) Actor() codec.Address {
+	return a.actor
+}
+
+func (a *simulatedAuth) Sponsor() codec.Address {
+	return a.actor
+}
+
+func (a *simulatedAuth) Marshal(p *codec.Packer) {
+	p.PackFixedBytes(make([]byte, a.Size()))
+}
+
+type simulationAuthFactory struct {
+	auth *simulatedAuth
+}
+
+func (f *simulationAuthFactory) Sign([]byte) (chain.Auth, error) {
+	return f.auth, nil
+}
+
+func (f *simulationAuthFactory) MaxUnits() (uint64, uint64) {
+	return uint64(f.auth.Size()), f.auth.ComputeUnits(nil)
+}
+
+func (f *simulationAuthFactory) Address() codec.Address {
+	return f.auth.actor
+}
@@ -5,6 +5,7 @@ package vm
 
 import (
 	"github.com/ava-labs/avalanchego/utils/wrappers"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/ava-labs/hypersdk-starter-kit/actions"
 	"github.com/ava-labs/hypersdk-starter-kit/consts"
@@ -12,7 +13,6 @@ import (
 	"github.com/ava-labs/hypersdk/auth"
 	"github.com/ava-labs/hypersdk/chain"
 	"github.com/ava-labs/hypersdk/codec"
-	"github.com/ava-labs/hypersdk/genesis"
 	"github.com/ava-labs/hypersdk/state/metadata"
 	"github.com/ava-labs/hypersdk/vm"
 	"github.com/ava-labs/hypersdk/vm/defaultvm"
@@ -41,6 +41,16 @@ func init() {
 		// When registering new actions, ALWAYS make sure to append at the end.
 		// Pass nil as second argument if manual marshalling isn't needed (if in doubt, you probably don't)
 		ActionParser.Register(&actions.Transfer{}, nil),
+		ActionParser.Register(&actions.SetSpendingLimit{}, nil),
+		ActionParser.Register(&actions.CreateSubscription{}, nil),
+		ActionParser.Register(&actions.ChargeSubscription{}, nil),
+		ActionParser.Register(&actions.CancelSubscription{}, nil),
+		ActionParser.Register(&actions.Sweep{}, nil),
+		ActionParser.Register(&actions.BridgeLock{}, nil),
+		ActionParser.Register(&actions.BridgeMint{}, nil),
+		ActionParser.Register(&actions.BridgeBurn{}, nil),
+		ActionParser.Register(&actions.BridgeUnlock{}, nil),
+		ActionParser.Register(&actions.Migrate{}, nil),
 
 		// When registering new auth, ALWAYS make sure to append at the end.
 		AuthParser.Register(&auth.ED25519{}, auth.UnmarshalED25519),
@@ -48,6 +58,16 @@ func init() {
 		AuthParser.Register(&auth.BLS{}, auth.UnmarshalBLS),
 
 		OutputParser.Register(&actions.TransferResult{}, nil),
+		OutputParser.Register(&actions.SetSpendingLimitResult{}, nil),
+		OutputParser.Register(&actions.CreateSubscriptionResult{}, nil),
+		OutputParser.Register(&actions.ChargeSubscriptionResult{}, nil),
+		OutputParser.Register(&actions.CancelSubscriptionResult{}, nil),
+		OutputParser.Register(&actions.SweepResult{}, nil),
+		OutputParser.Register(&actions.BridgeLockResult{}, nil),
+		OutputParser.Register(&actions.BridgeMintResult{}, nil),
+		OutputParser.Register(&actions.BridgeBurnResult{}, nil),
+		OutputParser.Register(&actions.BridgeUnlockResult{}, nil),
+		OutputParser.Register(&actions.MigrateResult{}, nil),
 	)
 
 	if errs.Errored() {
@@ -55,12 +75,34 @@ func init() {
 	}
 }
 
-// NewWithOptions returns a VM with the specified options
+// authTypes maps the first byte of an address to the auth type that derives
+// it. It must match the auth types registered with [AuthParser].
+var authTypes = map[uint8]string{
+	auth.ED25519ID:   auth.ED25519Key,
+	auth.SECP256R1ID: auth.Secp256r1Key,
+	auth.BLSID:       auth.BLSKey,
+}
+
+// AuthType returns the auth type that derives [addr]. It returns false if
+// [addr] cannot be derived by an auth type the VM accepts.
+func AuthType(addr codec.Address) (string, bool) {
+	authType, ok := authTypes[addr[0]]
+	return authType, ok
+}
+
+// NewWithOptions returns a VM with the specified options. Its metrics are
+// not served; [NewChainVM] serves them.
 func New(options ...vm.Option) (*vm.VM, error) {
-	options = append(options, With()) // Add MorpheusVM API
+	return newVM(prometheus.NewRegistry(), options...)
+}
+
+// newVM returns a VM with the specified options, registering its metrics
+// with [registry].
+func newVM(registry prometheus.Registerer, options ...vm.Option) (*vm.VM, error) {
+	options = append(options, With(registry)) // Add MorpheusVM API
 	return defaultvm.New(
 		consts.Version,
-		genesis.DefaultGenesisFactory{},
+		GenesisFactory{},
 		&storage.BalanceHandler{},
 		metadata.NewDefaultManager(),
 		ActionParser,